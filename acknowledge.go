@@ -0,0 +1,56 @@
+package backend
+
+import (
+	"context"
+	"sync"
+)
+
+type updatesContextKey struct{}
+
+// updateAcknowledgement counts the frequency updates persisted while resolving a GraphQL request,
+// reported in the meta extension once a search asks for it
+type updateAcknowledgement struct {
+	mu        sync.Mutex
+	requested bool
+	applied   int
+}
+
+// withUpdateAcknowledgement returns a context counting the frequency updates of a request
+func withUpdateAcknowledgement(ctx context.Context) context.Context {
+	return context.WithValue(ctx, updatesContextKey{}, &updateAcknowledgement{})
+}
+
+// requestUpdateAcknowledgement reports the number of frequency updates applied by the request in
+// the meta extension of its response
+func requestUpdateAcknowledgement(ctx context.Context) {
+	if ack, ok := ctx.Value(updatesContextKey{}).(*updateAcknowledgement); ok {
+		ack.mu.Lock()
+		ack.requested = true
+		ack.mu.Unlock()
+	}
+}
+
+// acknowledgeUpdate counts a frequency update persisted for the request
+func acknowledgeUpdate(ctx context.Context) {
+	if ack, ok := ctx.Value(updatesContextKey{}).(*updateAcknowledgement); ok {
+		ack.mu.Lock()
+		ack.applied++
+		ack.mu.Unlock()
+	}
+}
+
+// updatesAppliedFromContext returns the number of frequency updates persisted for the request, or
+// nil when no search asked for it
+func updatesAppliedFromContext(ctx context.Context) *int {
+	ack, ok := ctx.Value(updatesContextKey{}).(*updateAcknowledgement)
+	if !ok {
+		return nil
+	}
+	ack.mu.Lock()
+	defer ack.mu.Unlock()
+	if !ack.requested {
+		return nil
+	}
+	applied := ack.applied
+	return &applied
+}
@@ -0,0 +1,69 @@
+package backend
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/graphql-go/graphql"
+)
+
+// addState validates and inserts a new state into the tenant's repository, then rebuilds its trie
+func addState(ctx context.Context, state *State) (*State, error) {
+	normalizeState(state)
+	if strings.TrimSpace(state.Name) == "" {
+		return nil, invalidInput("name must not be empty")
+	}
+	if err := inputPolicy.Validate(state.Name); err != nil {
+		return nil, err
+	}
+	if err := validateCode(state.Code, config.StateCodeLength); err != nil {
+		return nil, err
+	}
+	tenantStore, err := storeFor(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if findState(tenantStore.Root(), state.Name) != nil {
+		return nil, fmt.Errorf("state %q already exists", state.Name)
+	}
+
+	if err := tenantStore.Repository().Insert(ctx, state); err != nil {
+		return nil, err
+	}
+	if err := tenantStore.RebuildTrie(withPrimaryReads(ctx)); err != nil {
+		return nil, err
+	}
+	log.Printf("Added state: %s, Code: %s, Kind: %s", state.Name, state.Code, state.Kind)
+	return findState(tenantStore.Root(), state.Name), nil
+}
+
+// addStateField adds a state to the tenant's states
+var addStateField = &graphql.Field{
+	Type: stateType,
+	Args: graphql.FieldConfigArgument{
+		"name": &graphql.ArgumentConfig{
+			Type: graphql.NewNonNull(graphql.String),
+		},
+		"code": &graphql.ArgumentConfig{
+			Type: graphql.NewNonNull(graphql.String),
+		},
+		"kind": &graphql.ArgumentConfig{
+			Type:         stateKindEnum,
+			DefaultValue: KindState,
+		},
+	},
+	Resolve: audited("addState", stateSnapshot, func(p graphql.ResolveParams) (interface{}, error) {
+		if _, err := requireAdmin(p.Context); err != nil {
+			return nil, err
+		}
+		kind, _ := p.Args["kind"].(string)
+		return addState(p.Context, &State{
+			Name:    p.Args["name"].(string),
+			Code:    p.Args["code"].(string),
+			Enabled: true,
+			Kind:    kind,
+		})
+	}),
+}
@@ -0,0 +1,89 @@
+package backend
+
+import (
+	"github.com/graphql-go/graphql"
+)
+
+// allStates returns a page of the enabled states passing the filters sorted in the order, read from
+// the store's flat list of states rather than walking the trie. A negative limit returns every state
+// from the offset.
+func allStates(s *TrieStore, order, locale string, limit, offset int, filters ...stateFilter) []*State {
+	states := filterStates(append([]*State(nil), s.States()...), append([]stateFilter{isEnabled}, filters...))
+	if order == orderFrequency {
+		sortStatesByFrequency(states)
+	} else {
+		sortStates(states, order, locale)
+	}
+	if offset >= len(states) {
+		return []*State{}
+	}
+	states = states[offset:]
+	if limit >= 0 && limit < len(states) {
+		states = states[:limit]
+	}
+	return states
+}
+
+// allStatesField lists every enabled state page by page for exports, the A–Z page and admin
+// tables, without updating their frequency
+var allStatesField = &graphql.Field{
+	Type: graphql.NewList(stateType),
+	Args: graphql.FieldConfigArgument{
+		"orderBy": &graphql.ArgumentConfig{
+			Type:         stateOrderEnum,
+			DefaultValue: orderNameAsc,
+		},
+		"locale": &graphql.ArgumentConfig{
+			Type:        graphql.String,
+			Description: "Locale of the names to sort by and display, defaulting to the Accept-Language header",
+		},
+		"limit": &graphql.ArgumentConfig{
+			Type:        graphql.Int,
+			Description: "Maximum number of states returned, every state by default",
+		},
+		"offset": &graphql.ArgumentConfig{
+			Type:         graphql.Int,
+			DefaultValue: 0,
+		},
+		"minFrequency": &graphql.ArgumentConfig{
+			Type:        graphql.Int,
+			Description: "Only list states selected at least this many times",
+		},
+		"maxFrequency": &graphql.ArgumentConfig{
+			Type:        graphql.Int,
+			Description: "Only list states selected at most this many times, e.g. 0 for states never selected",
+		},
+	},
+	Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+		limit, ok := p.Args["limit"].(int)
+		if !ok {
+			limit = -1
+		} else if limit < 0 {
+			return nil, invalidInput("limit must not be negative")
+		}
+		offset, _ := p.Args["offset"].(int)
+		if offset < 0 {
+			return nil, invalidInput("offset must not be negative")
+		}
+		var filters []stateFilter
+		if frequencies, err := frequencyRange(p.Args["minFrequency"], p.Args["maxFrequency"]); err != nil {
+			return nil, err
+		} else if frequencies != nil {
+			filters = append(filters, frequencies)
+		}
+		tenantStore, err := storeFor(p.Context)
+		if err != nil {
+			return nil, err
+		}
+		locale, _ := p.Args["locale"].(string)
+		locale = resolveLocale(p.Context, tenantStore, locale)
+		order, _ := p.Args["orderBy"].(string)
+		states := allStates(tenantStore, order, locale, limit, offset, filters...)
+		if locale == "" {
+			return states, nil
+		}
+		wrapped := wrapResults(states)
+		attachDisplayNames(wrapped, locale)
+		return wrapped, nil
+	},
+}
@@ -0,0 +1,88 @@
+package backend
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+func TestAllStatesOrderAndPages(t *testing.T) {
+	newTestStore(t,
+		State{Name: "Texas", Code: "TX", Enabled: true, Kind: KindState, Frequency: 9},
+		State{Name: "Utah", Code: "UT", Enabled: true, Kind: KindState, Frequency: 3},
+		State{Name: "Évora", Code: "EV", Enabled: true, Kind: KindProvince, Frequency: 1},
+		State{Name: "Florida", Code: "FL", Enabled: true, Kind: KindState, Frequency: 5},
+		State{Name: "Idaho", Code: "ID", Enabled: false, Kind: KindState, Frequency: 20},
+	)
+	tests := []struct {
+		query string
+		want  string
+	}{
+		// accented names are collated with their base letter rather than after z
+		{`{ allStates { name } }`, `{"allStates":[{"name":"Évora"},{"name":"Florida"},{"name":"Texas"},{"name":"Utah"}]}`},
+		{`{ allStates(orderBy: NAME_DESC) { name } }`, `{"allStates":[{"name":"Utah"},{"name":"Texas"},{"name":"Florida"},{"name":"Évora"}]}`},
+		{`{ allStates(orderBy: FREQUENCY) { name } }`, `{"allStates":[{"name":"Texas"},{"name":"Florida"},{"name":"Utah"},{"name":"Évora"}]}`},
+		{`{ allStates(limit: 2) { name } }`, `{"allStates":[{"name":"Évora"},{"name":"Florida"}]}`},
+		{`{ allStates(limit: 2, offset: 2) { name } }`, `{"allStates":[{"name":"Texas"},{"name":"Utah"}]}`},
+		{`{ allStates(offset: 3) { name } }`, `{"allStates":[{"name":"Utah"}]}`},
+		{`{ allStates(offset: 4) { name } }`, `{"allStates":[]}`},
+		{`{ allStates(limit: 0) { name } }`, `{"allStates":[]}`},
+	}
+	for _, test := range tests {
+		if got := resolveCodeQuery(t, test.query); got != test.want {
+			t.Errorf("%s = %s, want %s", test.query, got, test.want)
+		}
+	}
+	for _, query := range []string{`{ allStates(limit: -1) { name } }`, `{ allStates(offset: -1) { name } }`} {
+		if result := runGraphQL(t, context.Background(), query); len(result.Errors) != 1 || result.Errors[0].Extensions["code"] != invalidInputCode {
+			t.Errorf("%s = %v, want an %s error", query, result.Errors, invalidInputCode)
+		}
+	}
+}
+
+func TestAllStatesFollowsChanges(t *testing.T) {
+	withUnreachableMongo(t)
+	s := newTestStore(t,
+		State{Name: "Texas", Code: "TX", Enabled: true, Kind: KindState},
+		State{Name: "Utah", Code: "UT", Enabled: true, Kind: KindState},
+	)
+	ctx := context.Background()
+	names := func() []string {
+		names := []string{}
+		for _, state := range s.States() {
+			names = append(names, state.Name)
+		}
+		return names
+	}
+
+	if result := runGraphQL(t, asAdmin(ctx, "ops"), `mutation { addState(name: "Iowa", code: "IA") { name } }`); len(result.Errors) > 0 {
+		t.Fatal(result.Errors)
+	}
+	if got := names(); !reflect.DeepEqual(got, []string{"Iowa", "Texas", "Utah"}) {
+		t.Errorf("states after adding Iowa = %v", got)
+	}
+
+	if err := s.Repository().Delete(ctx, "Texas"); err != nil {
+		t.Fatal(err)
+	}
+	syncLoadedTries(ctx)
+	if got := names(); !reflect.DeepEqual(got, []string{"Iowa", "Utah"}) {
+		t.Errorf("states after syncing the deletion of Texas = %v", got)
+	}
+
+	if err := s.Repository().Insert(ctx, &State{Name: "Ohio", Code: "OH", Enabled: true, Kind: KindState}); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.RebuildTrie(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if got := names(); !reflect.DeepEqual(got, []string{"Iowa", "Ohio", "Utah"}) {
+		t.Errorf("states after a reload = %v", got)
+	}
+	// every listed state is the one the trie holds
+	for _, state := range s.States() {
+		if findState(s.Root(), state.Name) != state {
+			t.Errorf("listed %s is not the state in the trie", state.Name)
+		}
+	}
+}
@@ -0,0 +1,168 @@
+package backend
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"log"
+	mathrand "math/rand"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// Analytics modes selectable per deployment
+const (
+	// AnalyticsModeRaw stores the searched prefix as typed
+	AnalyticsModeRaw = "raw"
+	// AnalyticsModeAnonymized stores only a salted hash of the prefix and its length
+	AnalyticsModeAnonymized = "anonymized"
+	// AnalyticsModeOff records no analytics events
+	AnalyticsModeOff = "off"
+)
+
+// saltDateLayout is the layout of the UTC day a salt belongs to
+const saltDateLayout = "2006-01-02"
+
+// AnalyticsEvent records one search. In anonymized mode Prefix is always empty.
+type AnalyticsEvent struct {
+	Prefix       string    `bson:"prefix,omitempty" json:"prefix,omitempty"`
+	PrefixHash   string    `bson:"prefixHash,omitempty" json:"prefixHash,omitempty"`
+	PrefixLength int       `bson:"prefixLength" json:"prefixLength"`
+	Results      int       `bson:"results" json:"results"`
+	ClientID     string    `bson:"clientId" json:"clientId"`
+	Tenant       string    `bson:"tenant" json:"tenant"`
+	Timestamp    time.Time `bson:"timestamp" json:"timestamp"`
+}
+
+// AnalyticsSink stores analytics events
+type AnalyticsSink interface {
+	Write(ctx context.Context, event *AnalyticsEvent) error
+}
+
+// MongoAnalyticsSink stores analytics events in a MongoDB collection
+type MongoAnalyticsSink struct {
+	collection *mongo.Collection
+}
+
+// Analytics samples searches and writes them to a sink according to the configured mode
+type Analytics struct {
+	mode       string
+	sampleRate float64
+	secret     []byte
+	sink       AnalyticsSink
+	now        func() time.Time
+
+	mu      sync.Mutex
+	random  *mathrand.Rand
+	saltDay string
+	salt    []byte
+}
+
+var analytics *Analytics
+
+// NewMongoAnalyticsSink creates a sink writing to the given collection
+func NewMongoAnalyticsSink(collection *mongo.Collection) *MongoAnalyticsSink {
+	return &MongoAnalyticsSink{collection: collection}
+}
+
+// Write inserts the event into the collection
+func (s *MongoAnalyticsSink) Write(ctx context.Context, event *AnalyticsEvent) error {
+	_, err := s.collection.InsertOne(ctx, event)
+	return err
+}
+
+// NewAnalytics creates analytics in the given mode recording the sampleRate fraction of searches.
+// Salts are derived from the secret per UTC day when it is set, otherwise generated randomly per day.
+func NewAnalytics(mode string, sampleRate float64, secret string, sink AnalyticsSink, now func() time.Time) *Analytics {
+	switch mode {
+	case AnalyticsModeRaw, AnalyticsModeAnonymized, AnalyticsModeOff:
+	default:
+		log.Printf("Unknown analytics mode %q, using %s", mode, AnalyticsModeAnonymized)
+		mode = AnalyticsModeAnonymized
+	}
+	return &Analytics{
+		mode:       mode,
+		sampleRate: sampleRate,
+		secret:     []byte(secret),
+		sink:       sink,
+		now:        now,
+		random:     mathrand.New(mathrand.NewSource(now().UnixNano())),
+	}
+}
+
+// StoresRawPrefixes reports whether raw search prefixes may be persisted
+func (a *Analytics) StoresRawPrefixes() bool {
+	return a.mode == AnalyticsModeRaw
+}
+
+// sampled reports whether the next event falls within the sample rate
+func (a *Analytics) sampled() bool {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.random.Float64() < a.sampleRate
+}
+
+// saltFor returns the salt of the UTC day of the given time
+func (a *Analytics) saltFor(at time.Time) []byte {
+	day := at.UTC().Format(saltDateLayout)
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if day == a.saltDay {
+		return a.salt
+	}
+	if len(a.secret) > 0 {
+		mac := hmac.New(sha256.New, a.secret)
+		mac.Write([]byte(day))
+		a.salt = mac.Sum(nil)
+	} else {
+		a.salt = make([]byte, 32)
+		if _, err := rand.Read(a.salt); err != nil {
+			log.Printf("Error generating analytics salt: %v", err)
+		}
+	}
+	a.saltDay = day
+	return a.salt
+}
+
+// hashPrefix returns the salted hash of the prefix
+func hashPrefix(salt []byte, prefix string) string {
+	mac := hmac.New(sha256.New, salt)
+	mac.Write([]byte(prefix))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// NewEvent builds the event for a search, leaving out the raw prefix unless the mode is raw
+func (a *Analytics) NewEvent(prefix, clientID, tenantID string, results int) *AnalyticsEvent {
+	now := a.now()
+	normalized := normalizePrefix(prefix)
+	event := &AnalyticsEvent{
+		PrefixLength: utf8.RuneCountInString(normalized),
+		Results:      results,
+		ClientID:     clientID,
+		Tenant:       tenantID,
+		Timestamp:    now,
+	}
+	if a.mode == AnalyticsModeRaw {
+		event.Prefix = normalized
+	} else {
+		event.PrefixHash = hashPrefix(a.saltFor(now), normalized)
+	}
+	return event
+}
+
+// Record writes a sampled event for the search to the sink
+func (a *Analytics) Record(ctx context.Context, prefix string, results int) {
+	if a.mode == AnalyticsModeOff || !a.sampled() {
+		return
+	}
+	event := a.NewEvent(prefix, clientIDFromContext(ctx), tenantFromContext(ctx), results)
+	if err := a.sink.Write(ctx, event); err != nil {
+		log.Printf("Error writing analytics event: %v", err)
+	}
+}
@@ -0,0 +1,207 @@
+package backend
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/graphql-go/graphql"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	// anonymousActor is recorded for mutations called without admin credentials
+	anonymousActor = "anonymous"
+	// redactedValue replaces secret arguments in audit entries
+	redactedValue = "[redacted]"
+	// defaultAuditLogLimit is the number of entries returned when no limit is given
+	defaultAuditLogLimit = 50
+)
+
+// redactedArguments lists mutation arguments that are never written to the audit log
+var redactedArguments = map[string]bool{
+	"confirm": true,
+}
+
+var auditWriteFailuresTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+	Name: "audit_log_write_failures_total",
+	Help: "Total number of audit log entries that could not be written.",
+}, []string{"operation"})
+
+// AuditEntry records a single mutation call
+type AuditEntry struct {
+	ID        primitive.ObjectID     `bson:"_id,omitempty" json:"id"`
+	Actor     string                 `bson:"actor" json:"actor"`
+	Operation string                 `bson:"operation" json:"operation"`
+	Arguments map[string]interface{} `bson:"arguments" json:"arguments"`
+	Before    bson.M                 `bson:"before" json:"before"`
+	After     bson.M                 `bson:"after" json:"after"`
+	Error     string                 `bson:"error,omitempty" json:"error"`
+	Timestamp time.Time              `bson:"timestamp" json:"timestamp"`
+	RequestID string                 `bson:"requestId" json:"requestId"`
+}
+
+// auditSnapshotFn captures the state a mutation is about to change
+type auditSnapshotFn func(p graphql.ResolveParams) bson.M
+
+// redactArguments copies the arguments with secret values replaced
+func redactArguments(args map[string]interface{}) map[string]interface{} {
+	redacted := make(map[string]interface{}, len(args))
+	for name, value := range args {
+		if redactedArguments[name] {
+			value = redactedValue
+		}
+		redacted[name] = value
+	}
+	return redacted
+}
+
+// writeAuditEntry appends the entry to the audit log. Failures are logged and counted, never returned.
+func writeAuditEntry(ctx context.Context, entry *AuditEntry) {
+	collection := client.Database(config.MongoDB).Collection("auditLog")
+	if _, err := collection.InsertOne(ctx, entry); err != nil {
+		auditWriteFailuresTotal.WithLabelValues(entry.Operation).Inc()
+		log.Printf("Error writing audit entry for %s by %s: %v", entry.Operation, entry.Actor, err)
+	}
+}
+
+// audited wraps a mutation resolver so every call is appended to the audit log, including
+// calls that fail. The snapshot function captures the affected data before and after the call.
+func audited(operation string, snapshot auditSnapshotFn, resolve graphql.FieldResolveFn) graphql.FieldResolveFn {
+	return func(p graphql.ResolveParams) (interface{}, error) {
+		actor, ok := actorFromContext(p.Context)
+		if !ok {
+			actor = anonymousActor
+		}
+		entry := &AuditEntry{
+			Actor:     actor,
+			Operation: operation,
+			Arguments: redactArguments(p.Args),
+			Timestamp: time.Now(),
+			RequestID: requestIDFromContext(p.Context),
+		}
+		if snapshot != nil {
+			entry.Before = snapshot(p)
+		}
+
+		result, err := resolve(p)
+
+		if snapshot != nil {
+			entry.After = snapshot(p)
+		}
+		if err != nil {
+			entry.Error = err.Error()
+		}
+		writeAuditEntry(p.Context, entry)
+		return result, err
+	}
+}
+
+// stateCountSnapshot captures the number of states in the tenant's trie
+func stateCountSnapshot(p graphql.ResolveParams) bson.M {
+	tenantStore, err := storeFor(p.Context)
+	if err != nil {
+		return nil
+	}
+	return bson.M{"states": countLeaves(tenantStore.Root())}
+}
+
+// fetchAuditLog returns audit entries newest first, starting after the entry with the given ID
+func fetchAuditLog(ctx context.Context, limit int, after string) ([]*AuditEntry, error) {
+	filter := bson.M{}
+	if after != "" {
+		id, err := primitive.ObjectIDFromHex(after)
+		if err != nil {
+			return nil, err
+		}
+		filter["_id"] = bson.M{"$lt": id}
+	}
+	opts := options.Find().SetSort(bson.D{{Key: "_id", Value: -1}}).SetLimit(int64(limit))
+
+	collection := client.Database(config.MongoDB).Collection("auditLog")
+	cursor, err := collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	entries := []*AuditEntry{}
+	if err := cursor.All(ctx, &entries); err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+// jsonScalar passes arbitrary values through as JSON
+var jsonScalar = graphql.NewScalar(graphql.ScalarConfig{
+	Name:        "JSON",
+	Description: "The `JSON` scalar type represents an arbitrary JSON value.",
+	Serialize: func(value interface{}) interface{} {
+		return value
+	},
+})
+
+// Define the GraphQL audit entry type
+var auditEntryType = graphql.NewObject(graphql.ObjectConfig{
+	Name: "AuditEntry",
+	Fields: graphql.Fields{
+		"id": &graphql.Field{
+			Type: graphql.String,
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				return p.Source.(*AuditEntry).ID.Hex(), nil
+			},
+		},
+		"actor": &graphql.Field{
+			Type: graphql.String,
+		},
+		"operation": &graphql.Field{
+			Type: graphql.String,
+		},
+		"arguments": &graphql.Field{
+			Type: jsonScalar,
+		},
+		"before": &graphql.Field{
+			Type: jsonScalar,
+		},
+		"after": &graphql.Field{
+			Type: jsonScalar,
+		},
+		"error": &graphql.Field{
+			Type: graphql.String,
+		},
+		"timestamp": &graphql.Field{
+			Type: graphql.DateTime,
+		},
+		"requestId": &graphql.Field{
+			Type: graphql.String,
+		},
+	},
+})
+
+// auditLogField lists the audit log for admins, newest entries first
+var auditLogField = &graphql.Field{
+	Type: graphql.NewList(auditEntryType),
+	Args: graphql.FieldConfigArgument{
+		"limit": &graphql.ArgumentConfig{
+			Type: graphql.Int,
+		},
+		"after": &graphql.ArgumentConfig{
+			Type: graphql.String,
+		},
+	},
+	Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+		if _, err := requireAdmin(p.Context); err != nil {
+			return nil, err
+		}
+		limit, ok := p.Args["limit"].(int)
+		if !ok || limit <= 0 {
+			limit = defaultAuditLogLimit
+		}
+		after, _ := p.Args["after"].(string)
+		return fetchAuditLog(p.Context, limit, after)
+	},
+}
@@ -0,0 +1,73 @@
+package backend
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"net/http"
+	"strings"
+)
+
+// errUnauthorized is returned when an admin operation is called without valid admin credentials
+var errUnauthorized = errors.New("admin authorization required")
+
+type actorContextKey struct{}
+
+// parseAPIKeys parses a comma separated list of actor:key pairs into a key to actor map
+func parseAPIKeys(value string) map[string]string {
+	keys := make(map[string]string)
+	for _, pair := range strings.Split(value, ",") {
+		actor, key, ok := strings.Cut(strings.TrimSpace(pair), ":")
+		if !ok || actor == "" || key == "" {
+			continue
+		}
+		keys[key] = actor
+	}
+	return keys
+}
+
+// requestAPIKey extracts the API key from the Authorization bearer token or the X-API-Key header
+func requestAPIKey(r *http.Request) string {
+	if auth := r.Header.Get("Authorization"); strings.HasPrefix(auth, "Bearer ") {
+		return strings.TrimPrefix(auth, "Bearer ")
+	}
+	return r.Header.Get("X-API-Key")
+}
+
+// lookupActor returns the admin actor owning the API key, if any
+func lookupActor(apiKey string) (string, bool) {
+	if apiKey == "" {
+		return "", false
+	}
+	for key, actor := range config.AdminAPIKeys {
+		if subtle.ConstantTimeCompare([]byte(key), []byte(apiKey)) == 1 {
+			return actor, true
+		}
+	}
+	return "", false
+}
+
+// withAuth attaches the admin actor identified by the request's API key to the request context
+func withAuth(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if actor, ok := lookupActor(requestAPIKey(r)); ok {
+			r = r.WithContext(context.WithValue(r.Context(), actorContextKey{}, actor))
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// actorFromContext returns the admin actor attached to the context, if any
+func actorFromContext(ctx context.Context) (string, bool) {
+	actor, ok := ctx.Value(actorContextKey{}).(string)
+	return actor, ok
+}
+
+// requireAdmin returns the admin actor attached to the context or errUnauthorized
+func requireAdmin(ctx context.Context) (string, error) {
+	actor, ok := actorFromContext(ctx)
+	if !ok {
+		return "", errUnauthorized
+	}
+	return actor, nil
+}
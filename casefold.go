@@ -0,0 +1,24 @@
+package backend
+
+import (
+	"context"
+	"strings"
+)
+
+// foldKey returns the key a name is indexed under for case-insensitive matching, also normalized
+// by the collation policy
+func foldKey(name string) string {
+	return collationKey(strings.ToLower(name))
+}
+
+// buildFoldedTrie builds a trie keyed by the lowercased state names under root. Names differing
+// only by case share a terminal node and are all kept on it.
+func buildFoldedTrie(root *TrieNode) *TrieNode {
+	folded := newTrieRoot()
+	var states []*State
+	collectStates(context.Background(), root, &states)
+	for _, state := range states {
+		insertKey(folded, foldKey(state.Name), state)
+	}
+	return folded
+}
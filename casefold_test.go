@@ -0,0 +1,52 @@
+package backend
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+func TestIgnoreCaseKeepsCaseCollidingStates(t *testing.T) {
+	withUnreachableMongo(t)
+	s := newTestStore(t,
+		State{Name: "georgia", Code: "G1", Enabled: true, Kind: KindState},
+		State{Name: "Georgia", Code: "GA", Enabled: true, Kind: KindState},
+		State{Name: "GEORGIA", Code: "G2", Enabled: true, Kind: KindState},
+		State{Name: "Guam", Code: "GU", Enabled: true, Kind: KindTerritory},
+	)
+	if node := findNode(s.Folded(), foldKey("Georgia")); node == nil || len(node.States) != 3 {
+		t.Fatalf("folded trie holds %v under georgia, want all three spellings", node)
+	}
+	search := func(prefix string, ignoreCase bool) []string {
+		root, key := searchRoot(s, prefix, searchOptions{IgnoreCase: ignoreCase})
+		var names []string
+		for _, state := range searchStates(context.Background(), root, key) {
+			names = append(names, state.Name)
+		}
+		return names
+	}
+
+	tests := []struct {
+		search     string
+		ignoreCase bool
+		want       []string
+	}{
+		// names differing only by case are returned apart, ordered by their original name
+		{"geo", true, []string{"GEORGIA", "Georgia", "georgia"}},
+		{"GEORGIA", true, []string{"GEORGIA", "Georgia", "georgia"}},
+		{"g", true, []string{"GEORGIA", "Georgia", "Guam", "georgia"}},
+		{"Geo", false, []string{"Georgia"}},
+		{"geo", false, []string{"georgia"}},
+	}
+	for _, test := range tests {
+		if names := search(test.search, test.ignoreCase); !reflect.DeepEqual(names, test.want) {
+			t.Errorf("searching %q ignoring case %t = %v, want %v", test.search, test.ignoreCase, names, test.want)
+		}
+	}
+
+	// each spelling keeps its own frequency
+	updateFrequency(context.Background(), s, "georgia")
+	if names := search("GEO", true); !reflect.DeepEqual(names, []string{"georgia", "GEORGIA", "Georgia"}) {
+		t.Errorf("searching GEO ignoring case after selecting georgia = %v, want georgia first", names)
+	}
+}
@@ -0,0 +1,219 @@
+package backend
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	// changeStreamsUnsupportedCode is the MongoDB error code for change streams on a standalone server
+	changeStreamsUnsupportedCode = 40573
+	// maxWatchRetryDelay caps the wait between attempts to reopen a change stream
+	maxWatchRetryDelay = 30 * time.Second
+)
+
+// frequencyOnlyUpdates drops update events that only change a document's frequency. Every search
+// increments a frequency the trie already holds, so looking up the full document for each would
+// only repeat the write; other instances' increments are picked up by the next sync.
+var frequencyOnlyUpdates = bson.D{{Key: "$match", Value: bson.M{"$expr": bson.M{"$or": bson.A{
+	bson.M{"$ne": bson.A{"$operationType", "update"}},
+	bson.M{"$gt": bson.A{bson.M{"$size": bson.M{"$ifNull": bson.A{"$updateDescription.removedFields", bson.A{}}}}, 0}},
+	bson.M{"$gt": bson.A{bson.M{"$size": bson.M{"$setDifference": bson.A{
+		bson.M{"$map": bson.M{"input": bson.M{"$objectToArray": "$updateDescription.updatedFields"}, "in": "$$this.k"}},
+		bson.A{"frequency"},
+	}}}, 0}},
+}}}}}
+
+// stateChangeEvent is the part of a change stream event the watcher applies. FullDocument is set
+// for inserts and replaces, and for updates through the updateLookup option.
+type stateChangeEvent struct {
+	OperationType string `bson:"operationType"`
+	DocumentKey   struct {
+		ID interface{} `bson:"_id"`
+	} `bson:"documentKey"`
+	FullDocument *State `bson:"fullDocument"`
+}
+
+// stateWatcher applies the changes a MongoDB change stream reports on a states collection to a
+// store's trie as they happen. Delete events only carry the document _id, so the watcher keeps the
+// name of every document it has seen.
+type stateWatcher struct {
+	store      *TrieStore
+	collection *mongo.Collection
+	names      map[string]string
+}
+
+// newStateWatcher creates a watcher applying the collection's changes to the store
+func newStateWatcher(s *TrieStore, collection *mongo.Collection) *stateWatcher {
+	return &stateWatcher{store: s, collection: collection, names: make(map[string]string)}
+}
+
+// documentID returns the map key of a document _id
+func documentID(id interface{}) string {
+	return fmt.Sprint(id)
+}
+
+// loadNames reads the _id and name of every document in the collection
+func (w *stateWatcher) loadNames(ctx context.Context) error {
+	cursor, err := w.collection.Find(ctx, bson.M{}, options.Find().SetProjection(bson.M{"name": 1}))
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(ctx)
+
+	names := make(map[string]string)
+	for cursor.Next(ctx) {
+		var doc struct {
+			ID   interface{} `bson:"_id"`
+			Name string      `bson:"name"`
+		}
+		if err := cursor.Decode(&doc); err != nil {
+			return err
+		}
+		names[documentID(doc.ID)] = nfc(doc.Name)
+	}
+	if err := cursor.Err(); err != nil {
+		return err
+	}
+	w.names = names
+	return nil
+}
+
+// changesFor returns the trie changes the event makes. A document whose name changed is removed
+// under its old name and inserted under its new one.
+func (w *stateWatcher) changesFor(event stateChangeEvent) stateChanges {
+	root := w.store.Root()
+	id := documentID(event.DocumentKey.ID)
+	changes := stateChanges{}
+
+	switch event.OperationType {
+	case "insert", "update", "replace":
+		state := event.FullDocument
+		if state == nil {
+			// The document was deleted before the update could be looked up; its delete event follows
+			return changes
+		}
+		normalizeState(state)
+		if oldName, ok := w.names[id]; ok && oldName != state.Name {
+			if old := findState(root, oldName); old != nil {
+				changes.Deleted = append(changes.Deleted, old)
+			}
+		}
+		w.names[id] = state.Name
+		existing := findState(root, state.Name)
+		switch {
+		case existing == nil && w.store.pruned.contains(state.Name):
+			// Pruned states are reloaded from the collection when searched
+		case existing == nil:
+			changes.Inserted = append(changes.Inserted, state)
+		case !sameState(existing, state):
+			changes.Updated = append(changes.Updated, state)
+		}
+	case "delete":
+		name, ok := w.names[id]
+		if !ok {
+			return changes
+		}
+		delete(w.names, id)
+		if old := findState(root, name); old != nil {
+			changes.Deleted = append(changes.Deleted, old)
+		}
+	}
+	return changes
+}
+
+// apply applies the event to the trie under the store's write lock
+func (w *stateWatcher) apply(ctx context.Context, event stateChangeEvent) {
+	if event.FullDocument != nil {
+		if err := addFrequencies(ctx, w.store.Frequencies(), []*State{event.FullDocument}); err != nil {
+			log.Printf("Error reading frequency of state %s: %v", event.FullDocument.Name, err)
+			return
+		}
+	}
+	w.store.writeMu.Lock()
+	defer w.store.writeMu.Unlock()
+	changes := w.changesFor(event)
+	if len(changes.Inserted)+len(changes.Updated)+len(changes.Deleted) == 0 {
+		return
+	}
+	applyStateChanges(ctx, w.store, changes)
+	log.Printf("Applied state change: %s, Inserted: %d, Updated: %d, Deleted: %d",
+		event.OperationType, len(changes.Inserted), len(changes.Updated), len(changes.Deleted))
+}
+
+// resync catches the trie up with changes the change stream may have missed. It reads from the
+// primary, since changes a lagging replica has not applied yet are not in the stream either.
+func (w *stateWatcher) resync(ctx context.Context) error {
+	if err := w.loadNames(ctx); err != nil {
+		return err
+	}
+	_, err := syncTrie(withPrimaryReads(ctx), w.store)
+	return err
+}
+
+// watch applies the events of one change stream until it fails or is invalidated, returning the
+// resume token of the last applied event
+func (w *stateWatcher) watch(ctx context.Context, stream *mongo.ChangeStream, token bson.Raw) (bson.Raw, error) {
+	defer stream.Close(ctx)
+	for stream.Next(ctx) {
+		var event stateChangeEvent
+		if err := stream.Decode(&event); err != nil {
+			log.Printf("Error decoding state change: %v", err)
+			token = stream.ResumeToken()
+			continue
+		}
+		switch event.OperationType {
+		case "drop", "rename", "invalidate":
+			// The stream ends after an invalidate; start over without resuming
+			return nil, fmt.Errorf("collection %s", event.OperationType)
+		}
+		w.apply(ctx, event)
+		token = stream.ResumeToken()
+	}
+	return token, stream.Err()
+}
+
+// Run applies the collection's changes until ctx is done. Brief disconnects resume from the last
+// applied event; when resuming fails the trie is resynced and a new stream started. Run returns
+// right away when MongoDB does not support change streams, leaving syncing to polling.
+func (w *stateWatcher) Run(ctx context.Context) {
+	var token bson.Raw
+	delay := time.Second
+	for ctx.Err() == nil {
+		opts := options.ChangeStream().SetFullDocument(options.UpdateLookup)
+		if token != nil {
+			opts.SetResumeAfter(token)
+		}
+		stream, err := w.collection.Watch(ctx, mongo.Pipeline{frequencyOnlyUpdates}, opts)
+		var commandErr mongo.CommandError
+		if errors.As(err, &commandErr) && commandErr.Code == changeStreamsUnsupportedCode {
+			log.Printf("Change streams unavailable, polling for state changes: %v", err)
+			return
+		}
+		if err != nil {
+			log.Printf("Error watching state changes, retrying in %s: %v", delay, err)
+			token = nil
+			time.Sleep(delay)
+			if delay *= 2; delay > maxWatchRetryDelay {
+				delay = maxWatchRetryDelay
+			}
+			continue
+		}
+		delay = time.Second
+		if token == nil {
+			// Events from here on are in the stream, so catch up with anything earlier
+			if err := w.resync(ctx); err != nil {
+				log.Printf("Error resyncing trie for state changes: %v", err)
+			}
+		}
+		token, err = w.watch(ctx, stream, token)
+		log.Printf("Stopped watching state changes, resuming: %v", err)
+	}
+}
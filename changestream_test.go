@@ -0,0 +1,92 @@
+package backend
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+// changeEvent builds a change stream event for the document with the given _id
+func changeEvent(operationType string, id int, state *State) stateChangeEvent {
+	event := stateChangeEvent{OperationType: operationType, FullDocument: state}
+	event.DocumentKey.ID = id
+	return event
+}
+
+func TestStateWatcherAppliesChangeEvents(t *testing.T) {
+	s := newTestStore(t,
+		State{Name: "Texas", Code: "TX", Enabled: true, Kind: KindState, Frequency: 4},
+		State{Name: "Utah", Code: "UT", Enabled: true, Kind: KindState},
+	)
+	w := newStateWatcher(s, nil)
+	// as loadNames would have read them from the collection
+	w.names = map[string]string{documentID(2): "Texas", documentID(3): "Utah"}
+	ctx := context.Background()
+
+	w.apply(ctx, changeEvent("insert", 1, &State{Name: "Tamaulipas", Code: "tm", Enabled: true, Kind: KindProvince}))
+	if state := findState(s.Root(), "Tamaulipas"); state == nil || state.Code != "TM" {
+		t.Errorf("Tamaulipas after its insert event = %+v, want it with its code uppercased", state)
+	}
+
+	w.apply(ctx, changeEvent("update", 2, &State{Name: "Texas", Code: "TX", Enabled: true, Kind: KindTerritory, Frequency: 4}))
+	if state := findState(s.Root(), "Texas"); state == nil || state.Kind != KindTerritory {
+		t.Errorf("Texas after its update event = %+v, want a territory", state)
+	}
+
+	// a replace that renames the document moves it to its new name
+	w.apply(ctx, changeEvent("replace", 2, &State{Name: "Tejas", Code: "TX", Enabled: true, Kind: KindState, Frequency: 4}))
+	if findState(s.Root(), "Texas") != nil {
+		t.Error("Texas is still in the trie after it was renamed")
+	}
+	if state := findState(s.Root(), "Tejas"); state == nil || state.loadFrequency() != 4 {
+		t.Errorf("Tejas after the rename = %+v, want it with the frequency of Texas", state)
+	}
+
+	w.apply(ctx, changeEvent("delete", 3, nil))
+	if findState(s.Root(), "Utah") != nil || findNode(s.Root(), collationKey("U")) != nil {
+		t.Error("Utah and its nodes are still in the trie after its delete event")
+	}
+
+	// names are normalized like those loaded from the repository
+	w.apply(ctx, changeEvent("insert", 4, &State{Name: quebecNFD, Code: "QC", Enabled: true, Kind: KindProvince}))
+	if state := findState(s.Root(), quebecNFC); state == nil || state.Name != quebecNFC {
+		t.Errorf("Québec inserted decomposed = %+v, want it in NFC", state)
+	}
+	w.apply(ctx, changeEvent("delete", 4, nil))
+	if findState(s.Root(), quebecNFC) != nil {
+		t.Error("Québec is still in the trie after its delete event")
+	}
+
+	if names := searchNames(s.Root(), "T"); !reflect.DeepEqual(names, []string{"Tejas", "Tamaulipas"}) {
+		t.Errorf("searching T after the events = %v, want Tejas and Tamaulipas", names)
+	}
+	if count := s.Root().SubtreeCount; count != 2 {
+		t.Errorf("trie counts %d states after the events, want 2", count)
+	}
+}
+
+func TestStateWatcherIgnoresEventsWithoutChanges(t *testing.T) {
+	s := newTestStore(t, State{Name: "Texas", Code: "TX", Enabled: true, Kind: KindState, Frequency: 4})
+	w := newStateWatcher(s, nil)
+	texas := findState(s.Root(), "Texas")
+
+	for _, event := range []stateChangeEvent{
+		// an update whose document was deleted before it could be looked up
+		changeEvent("update", 1, nil),
+		// a delete of a document the watcher never saw
+		changeEvent("delete", 2, nil),
+		// an insert of a state the trie already holds as it is
+		changeEvent("insert", 3, &State{Name: "Texas", Code: "TX", Enabled: true, Kind: KindState, Frequency: 4}),
+	} {
+		if changes := w.changesFor(event); len(changes.Inserted)+len(changes.Updated)+len(changes.Deleted) != 0 {
+			t.Errorf("%s event of document %v made the changes %+v, want none", event.OperationType, event.DocumentKey.ID, changes)
+		}
+	}
+	if findState(s.Root(), "Texas") != texas {
+		t.Error("Texas was replaced by events without changes")
+	}
+	// the insert is remembered, so its delete event removes the state
+	if changes := w.changesFor(changeEvent("delete", 3, nil)); len(changes.Deleted) != 1 || changes.Deleted[0] != texas {
+		t.Errorf("delete event of Texas made the changes %+v, want Texas deleted", changes)
+	}
+}
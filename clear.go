@@ -0,0 +1,74 @@
+package backend
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"log"
+
+	"github.com/graphql-go/graphql"
+)
+
+var (
+	// errClearAllDisabled is returned when no confirmation token is configured
+	errClearAllDisabled = errors.New("clearAll is disabled: CLEAR_ALL_TOKEN is not configured")
+	// errClearAllInProduction is returned when clearAll is called in production without the override
+	errClearAllInProduction = errors.New("clearAll is not allowed in production")
+	// errClearAllConfirmation is returned when the confirmation token does not match
+	errClearAllConfirmation = errors.New("confirmation token does not match")
+)
+
+// checkClearAllAllowed verifies the environment and confirmation token guarding clearAll
+func checkClearAllAllowed(cfg *Config, confirm string) error {
+	if cfg.Env == "production" && !cfg.AllowClearAllInProduction {
+		return errClearAllInProduction
+	}
+	if cfg.ClearAllToken == "" {
+		return errClearAllDisabled
+	}
+	if subtle.ConstantTimeCompare([]byte(confirm), []byte(cfg.ClearAllToken)) != 1 {
+		return errClearAllConfirmation
+	}
+	return nil
+}
+
+// clearAll empties the tenant's states collection and resets its trie, returning the number of deleted states
+func clearAll(ctx context.Context) (int, error) {
+	tenantStore, err := storeFor(ctx)
+	if err != nil {
+		return 0, err
+	}
+	deleted, err := tenantStore.Repository().DeleteAll(ctx)
+	if err != nil {
+		return 0, err
+	}
+	tenantStore.Reset()
+	return deleted, nil
+}
+
+// clearAllField deletes every state after checking admin auth and the confirmation token
+var clearAllField = &graphql.Field{
+	Type: graphql.Int,
+	Args: graphql.FieldConfigArgument{
+		"confirm": &graphql.ArgumentConfig{
+			Type: graphql.NewNonNull(graphql.String),
+		},
+	},
+	Resolve: audited("clearAll", stateCountSnapshot, func(p graphql.ResolveParams) (interface{}, error) {
+		actor, err := requireAdmin(p.Context)
+		if err != nil {
+			return nil, err
+		}
+		if err := checkClearAllAllowed(config, p.Args["confirm"].(string)); err != nil {
+			log.Printf("Rejected clearAll by %s: %v", actor, err)
+			return nil, err
+		}
+		deleted, err := clearAll(p.Context)
+		if err != nil {
+			log.Printf("Error clearing all states for %s: %v", actor, err)
+			return nil, err
+		}
+		log.Printf("Cleared all states for %s, Deleted: %d", actor, deleted)
+		return deleted, nil
+	}),
+}
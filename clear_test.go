@@ -0,0 +1,75 @@
+package backend
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestCheckClearAllAllowed(t *testing.T) {
+	tests := []struct {
+		env      string
+		token    string
+		override bool
+		confirm  string
+		want     error
+	}{
+		{"staging", "reset-me", false, "reset-me", nil},
+		{"staging", "reset-me", false, "reset-you", errClearAllConfirmation},
+		{"staging", "reset-me", false, "", errClearAllConfirmation},
+		{"staging", "", false, "", errClearAllDisabled},
+		{"production", "reset-me", false, "reset-me", errClearAllInProduction},
+		{"production", "reset-me", true, "reset-me", nil},
+		{"production", "", true, "", errClearAllDisabled},
+	}
+	for _, test := range tests {
+		cfg := &Config{Env: test.env, ClearAllToken: test.token, AllowClearAllInProduction: test.override}
+		if err := checkClearAllAllowed(cfg, test.confirm); err != test.want {
+			t.Errorf("clearAll in %s with token %q, override %t and confirmation %q: %v, want %v",
+				test.env, test.token, test.override, test.confirm, err, test.want)
+		}
+	}
+}
+
+func TestClearAllMutation(t *testing.T) {
+	withUnreachableMongo(t)
+	s := newTestStore(t,
+		State{Name: "Texas", Code: "TX", Enabled: true, Kind: KindState},
+		State{Name: "Utah", Code: "UT", Enabled: true, Kind: KindState},
+	)
+	previousEnv, previousToken := config.Env, config.ClearAllToken
+	defer func() { config.Env, config.ClearAllToken = previousEnv, previousToken }()
+	config.Env, config.ClearAllToken = "staging", "reset-me"
+	ctx := context.Background()
+
+	for _, test := range []struct {
+		ctx     context.Context
+		confirm string
+		err     string
+	}{
+		{ctx, "reset-me", errUnauthorized.Error()},
+		{asAdmin(ctx, "ops"), "wrong", errClearAllConfirmation.Error()},
+	} {
+		result := runGraphQL(t, test.ctx, `mutation { clearAll(confirm: "`+test.confirm+`") }`)
+		if len(result.Errors) != 1 || !strings.Contains(result.Errors[0].Message, test.err) {
+			t.Errorf("clearAll with %q = %v, want %q", test.confirm, result.Errors, test.err)
+		}
+		if findState(s.Root(), "Texas") == nil {
+			t.Fatalf("clearAll with %q cleared the states", test.confirm)
+		}
+	}
+
+	result := runGraphQL(t, asAdmin(ctx, "ops"), `mutation { clearAll(confirm: "reset-me") }`)
+	if len(result.Errors) > 0 {
+		t.Fatal(result.Errors)
+	}
+	if deleted := result.Data.(map[string]interface{})["clearAll"]; deleted != 2 {
+		t.Errorf("clearAll deleted %v states, want 2", deleted)
+	}
+	if states := AllStates(s.Root()); len(states) != 0 {
+		t.Errorf("trie holds %d states after clearAll", len(states))
+	}
+	if count, _ := s.Repository().Count(ctx); count != 0 {
+		t.Errorf("repository holds %d states after clearAll", count)
+	}
+}
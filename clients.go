@@ -0,0 +1,179 @@
+package backend
+
+import (
+	"context"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/graphql-go/graphql"
+)
+
+const (
+	// clientIDHeader identifies the frontend making a request
+	clientIDHeader = "X-Client-Id"
+	// unknownClientID is used for requests without an allowed client ID
+	unknownClientID = "unknown"
+	// clientTopPrefixLimit is the number of top prefixes reported per client
+	clientTopPrefixLimit = 10
+)
+
+type clientIDContextKey struct{}
+
+// ClientUsage summarizes the searches of one client within a window
+type ClientUsage struct {
+	ClientID       string       `json:"clientId"`
+	TotalSearches  int          `json:"totalSearches"`
+	ZeroResultRate float64      `json:"zeroResultRate"`
+	TopPrefixes    []PrefixStat `json:"topPrefixes"`
+}
+
+// ClientStats keeps separate search stats per client ID
+type ClientStats struct {
+	mu      sync.Mutex
+	now     func() time.Time
+	clients map[string]*SearchStats
+}
+
+var clientStats = NewClientStats(time.Now)
+
+// NewClientStats creates per-client stats that read the current time from the given clock
+func NewClientStats(now func() time.Time) *ClientStats {
+	return &ClientStats{
+		now:     now,
+		clients: make(map[string]*SearchStats),
+	}
+}
+
+// parseIDSet parses a comma separated allowlist of IDs
+func parseIDSet(value string) map[string]bool {
+	ids := make(map[string]bool)
+	for _, id := range strings.Split(value, ",") {
+		if id = strings.TrimSpace(id); id != "" {
+			ids[id] = true
+		}
+	}
+	return ids
+}
+
+// resolveClientID returns the client ID if it is allowed, otherwise unknownClientID
+func resolveClientID(clientID string, allowed map[string]bool) string {
+	if allowed[clientID] {
+		return clientID
+	}
+	return unknownClientID
+}
+
+// withClientID attaches the validated X-Client-Id of the request to its context
+func withClientID(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		clientID := resolveClientID(r.Header.Get(clientIDHeader), config.ClientIDs)
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), clientIDContextKey{}, clientID)))
+	})
+}
+
+// clientIDFromContext returns the client ID attached to the context, or unknownClientID
+func clientIDFromContext(ctx context.Context) string {
+	if clientID, ok := ctx.Value(clientIDContextKey{}).(string); ok {
+		return clientID
+	}
+	return unknownClientID
+}
+
+// For returns the search stats of the client, creating them on first use
+func (c *ClientStats) For(clientID string) *SearchStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	stats, ok := c.clients[clientID]
+	if !ok {
+		stats = NewSearchStats(c.now)
+		c.clients[clientID] = stats
+	}
+	return stats
+}
+
+// Usage summarizes the searches of every client within the window, busiest client first
+func (c *ClientStats) Usage(window time.Duration) []*ClientUsage {
+	c.mu.Lock()
+	clients := make(map[string]*SearchStats, len(c.clients))
+	for clientID, stats := range c.clients {
+		clients[clientID] = stats
+	}
+	c.mu.Unlock()
+
+	usage := []*ClientUsage{}
+	for clientID, stats := range clients {
+		summary := stats.Summary(window)
+		usage = append(usage, &ClientUsage{
+			ClientID:       clientID,
+			TotalSearches:  summary.TotalSearches,
+			ZeroResultRate: summary.ZeroResultRate,
+			TopPrefixes:    stats.TopPrefixes(window, clientTopPrefixLimit),
+		})
+	}
+	sort.Slice(usage, func(i, j int) bool {
+		if usage[i].TotalSearches != usage[j].TotalSearches {
+			return usage[i].TotalSearches > usage[j].TotalSearches
+		}
+		return usage[i].ClientID < usage[j].ClientID
+	})
+	return usage
+}
+
+// recordSearch records a search in the overall and per-client stats and metrics
+func recordSearch(ctx context.Context, prefix string, results int, latency time.Duration) {
+	clientID := clientIDFromContext(ctx)
+	searchesTotal.WithLabelValues(tenantFromContext(ctx), clientID).Inc()
+	searchResultsReturned.Observe(float64(results))
+	searchLatencySeconds.Observe(latency.Seconds())
+	if results == 0 {
+		zeroResultSearchesTotal.WithLabelValues(tenantFromContext(ctx), clientID).Inc()
+	}
+
+	searchStats.Record(prefix, results, latency)
+	clientStats.For(clientID).Record(prefix, results, latency)
+}
+
+// Define the GraphQL client usage type
+var clientUsageType = graphql.NewObject(graphql.ObjectConfig{
+	Name: "ClientUsage",
+	Fields: graphql.Fields{
+		"clientId": &graphql.Field{
+			Type: graphql.String,
+		},
+		"totalSearches": &graphql.Field{
+			Type: graphql.Int,
+		},
+		"zeroResultRate": &graphql.Field{
+			Type: graphql.Float,
+		},
+		"topPrefixes": &graphql.Field{
+			Type: graphql.NewList(prefixStatType),
+		},
+	},
+})
+
+// clientUsageField summarizes the searches per client for admins
+var clientUsageField = &graphql.Field{
+	Type: graphql.NewList(clientUsageType),
+	Args: graphql.FieldConfigArgument{
+		"window": &graphql.ArgumentConfig{
+			Type: durationScalar,
+		},
+	},
+	Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+		if _, err := requireAdmin(p.Context); err != nil {
+			return nil, err
+		}
+		window, ok := p.Args["window"].(time.Duration)
+		if !ok || window <= 0 {
+			window = defaultStatsWindow
+		}
+		if window > statsRetention {
+			window = statsRetention
+		}
+		return clientStats.Usage(window), nil
+	},
+}
@@ -0,0 +1,11 @@
+package main
+
+import (
+	backend "state-suggestion-backend"
+)
+
+func main() {
+	backend.Init()
+	go backend.Load()
+	backend.Serve()
+}
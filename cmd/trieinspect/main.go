@@ -0,0 +1,111 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	backend "state-suggestion-backend"
+)
+
+func main() {
+	var verbose bool
+	root := &cobra.Command{
+		Use:   "trieinspect",
+		Short: "Inspect the state trie loaded from MongoDB",
+		Long:  "Loads the states from the MongoDB server and database given by MONGO_URI and MONGO_DB into a trie and inspects it.",
+		PersistentPreRun: func(cmd *cobra.Command, args []string) {
+			if !verbose {
+				log.SetOutput(io.Discard)
+			}
+		},
+		SilenceUsage: true,
+	}
+	root.PersistentFlags().BoolVarP(&verbose, "verbose", "v", false, "print the backend log while loading")
+
+	root.AddCommand(&cobra.Command{
+		Use:   "stats",
+		Short: "Print statistics about the shape of the trie",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			trie, err := loadTrie(cmd.Context())
+			if err != nil {
+				return err
+			}
+			stats := backend.ComputeStats(trie)
+			fmt.Printf("nodes:             %d\n", stats.Nodes)
+			fmt.Printf("states:            %d\n", stats.States)
+			fmt.Printf("max depth:         %d\n", stats.MaxDepth)
+			fmt.Printf("average depth:     %.2f\n", stats.AverageDepth)
+			fmt.Printf("average branching: %.2f\n", stats.AverageBranching)
+			fmt.Printf("total frequency:   %d\n", stats.TotalFrequency)
+			return nil
+		},
+	})
+
+	root.AddCommand(&cobra.Command{
+		Use:   "dump",
+		Short: "Print every state sorted by frequency",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			trie, err := loadTrie(cmd.Context())
+			if err != nil {
+				return err
+			}
+			printStates(backend.AllStates(trie))
+			return nil
+		},
+	})
+
+	root.AddCommand(&cobra.Command{
+		Use:   "search <prefix>",
+		Short: "Print the states matching a prefix without updating their frequency",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			trie, err := loadTrie(cmd.Context())
+			if err != nil {
+				return err
+			}
+			printStates(backend.SearchStates(trie, args[0]))
+			return nil
+		},
+	})
+
+	root.AddCommand(&cobra.Command{
+		Use:   "dot",
+		Short: "Print a Graphviz DOT representation of the trie",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			trie, err := loadTrie(cmd.Context())
+			if err != nil {
+				return err
+			}
+			return backend.WriteDOT(os.Stdout, trie)
+		},
+	})
+
+	if err := root.ExecuteContext(context.Background()); err != nil {
+		os.Exit(1)
+	}
+}
+
+// loadTrie connects to MongoDB and loads its states into a trie
+func loadTrie(ctx context.Context) (*backend.TrieNode, error) {
+	client, err := backend.ConnectMongo(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer client.Disconnect(context.Background())
+	return backend.LoadTrie(ctx, backend.NewMongoStateRepository(backend.StatesCollection(client)))
+}
+
+// printStates prints one state per line
+func printStates(states []*backend.State) {
+	for _, state := range states {
+		fmt.Printf("%s\t%s\t%d\n", state.Name, state.Code, state.Frequency)
+	}
+}
@@ -0,0 +1,102 @@
+package backend
+
+import (
+	"sort"
+	"sync"
+
+	"github.com/graphql-go/graphql"
+	"golang.org/x/text/collate"
+	"golang.org/x/text/language"
+)
+
+const (
+	// orderFrequency sorts states by descending frequency
+	orderFrequency = "FREQUENCY"
+	// orderNameAsc sorts states alphabetically by display name
+	orderNameAsc = "NAME_ASC"
+	// orderNameDesc sorts states reverse alphabetically by display name
+	orderNameDesc = "NAME_DESC"
+	// defaultCollationLocale is the collation used for requests without a locale
+	defaultCollationLocale = "en-US"
+)
+
+// lockedCollator guards a collator, which is not safe for concurrent use
+type lockedCollator struct {
+	mu       sync.Mutex
+	collator *collate.Collator
+}
+
+// CollatorCache keeps one collator per locale since building them is expensive
+type CollatorCache struct {
+	mu        sync.Mutex
+	collators map[string]*lockedCollator
+}
+
+var collators = NewCollatorCache()
+
+// NewCollatorCache creates an empty collator cache
+func NewCollatorCache() *CollatorCache {
+	return &CollatorCache{collators: make(map[string]*lockedCollator)}
+}
+
+// get returns the collator of the locale, building it on first use
+func (c *CollatorCache) get(locale string) *lockedCollator {
+	if locale == "" {
+		locale = defaultCollationLocale
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if collator, ok := c.collators[locale]; ok {
+		return collator
+	}
+	tag, err := language.Parse(locale)
+	if err != nil {
+		tag = language.MustParse(defaultCollationLocale)
+	}
+	collator := &lockedCollator{collator: collate.New(tag)}
+	c.collators[locale] = collator
+	return collator
+}
+
+// SortByName sorts the states by their display name in the locale using its collation
+func (c *CollatorCache) SortByName(states []*State, locale string, descending bool) {
+	collator := c.get(locale)
+	collator.mu.Lock()
+	defer collator.mu.Unlock()
+	sort.SliceStable(states, func(i, j int) bool {
+		cmp := collator.collator.CompareString(states[i].LocalizedName(locale), states[j].LocalizedName(locale))
+		if descending {
+			return cmp > 0
+		}
+		return cmp < 0
+	})
+}
+
+// sortStates orders the states as requested, keeping the frequency order by default
+func sortStates(states []*State, order, locale string) {
+	switch order {
+	case orderNameAsc:
+		collators.SortByName(states, locale, false)
+	case orderNameDesc:
+		collators.SortByName(states, locale, true)
+	}
+}
+
+// Define the GraphQL state order enum
+var stateOrderEnum = graphql.NewEnum(graphql.EnumConfig{
+	Name: "StateOrder",
+	Values: graphql.EnumValueConfigMap{
+		orderFrequency: &graphql.EnumValueConfig{
+			Value:       orderFrequency,
+			Description: "Most frequently selected first",
+		},
+		orderNameAsc: &graphql.EnumValueConfig{
+			Value:       orderNameAsc,
+			Description: "Alphabetical by display name, collated for the locale",
+		},
+		orderNameDesc: &graphql.EnumValueConfig{
+			Value:       orderNameDesc,
+			Description: "Reverse alphabetical by display name, collated for the locale",
+		},
+	},
+})
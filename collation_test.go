@@ -0,0 +1,87 @@
+package backend
+
+import (
+	"reflect"
+	"testing"
+)
+
+// collationFixtures are accented and case-mixed names that byte order sorts wrongly
+func collationFixtures() []*State {
+	var states []*State
+	for _, name := range []string{"Zanesville", "oregon", "Ñandú", "Ohio", "alaska", "Nebraska", "Álamo"} {
+		states = append(states, &State{Name: name})
+	}
+	return states
+}
+
+func TestSortStatesByCollatedName(t *testing.T) {
+	tests := []struct {
+		order  string
+		locale string
+		want   []string
+	}{
+		{orderNameAsc, "en", []string{"Álamo", "alaska", "Ñandú", "Nebraska", "Ohio", "oregon", "Zanesville"}},
+		{orderNameAsc, "", []string{"Álamo", "alaska", "Ñandú", "Nebraska", "Ohio", "oregon", "Zanesville"}},
+		// ñ is a letter of its own after n in Spanish
+		{orderNameAsc, "es", []string{"Álamo", "alaska", "Nebraska", "Ñandú", "Ohio", "oregon", "Zanesville"}},
+		{orderNameDesc, "en", []string{"Zanesville", "oregon", "Ohio", "Nebraska", "Ñandú", "alaska", "Álamo"}},
+		{orderNameDesc, "es", []string{"Zanesville", "oregon", "Ohio", "Ñandú", "Nebraska", "alaska", "Álamo"}},
+		// the frequency order is kept as it is
+		{orderFrequency, "en", []string{"Zanesville", "oregon", "Ñandú", "Ohio", "alaska", "Nebraska", "Álamo"}},
+	}
+	for _, test := range tests {
+		states := collationFixtures()
+		sortStates(states, test.order, test.locale)
+		var names []string
+		for _, state := range states {
+			names = append(names, state.Name)
+		}
+		if !reflect.DeepEqual(names, test.want) {
+			t.Errorf("sorting %s in %q = %v, want %v", test.order, test.locale, names, test.want)
+		}
+	}
+}
+
+func TestSortStatesByLocalizedName(t *testing.T) {
+	states := []*State{
+		{Name: "New York", Translations: map[string]string{"es": "Nueva York"}},
+		{Name: "Nevada"},
+		{Name: "New Mexico", Translations: map[string]string{"es": "Nuevo México"}},
+	}
+	sortStates(states, orderNameAsc, "es")
+	if states[0].Name != "Nevada" || states[1].Name != "New York" || states[2].Name != "New Mexico" {
+		t.Errorf("sorted by Spanish name = %s, %s, %s, want Nevada, Nueva York, Nuevo México",
+			states[0].Name, states[1].Name, states[2].Name)
+	}
+}
+
+func TestCollatorCacheReusesCollators(t *testing.T) {
+	cache := NewCollatorCache()
+	if cache.get("es") != cache.get("es") {
+		t.Error("got a new collator for es on the second use")
+	}
+	if cache.get("") != cache.get(defaultCollationLocale) {
+		t.Error("requests without a locale do not share the default collator")
+	}
+	if cache.get("es") == cache.get("en") {
+		t.Error("es and en share a collator")
+	}
+}
+
+func TestStatesOrderBy(t *testing.T) {
+	withUnreachableMongo(t)
+	newTestStore(t,
+		State{Name: "Ohio", Code: "OH", Enabled: true, Kind: KindState, Frequency: 3},
+		State{Name: "Oklahoma", Code: "OK", Enabled: true, Kind: KindState, Frequency: 5},
+		State{Name: "Oregon", Code: "OR", Enabled: true, Kind: KindState, Frequency: 1},
+	)
+	for order, want := range map[string]string{
+		"FREQUENCY": `{"states":[{"name":"Oklahoma"},{"name":"Ohio"},{"name":"Oregon"}]}`,
+		"NAME_ASC":  `{"states":[{"name":"Ohio"},{"name":"Oklahoma"},{"name":"Oregon"}]}`,
+		"NAME_DESC": `{"states":[{"name":"Oregon"},{"name":"Oklahoma"},{"name":"Ohio"}]}`,
+	} {
+		if got := resolveCodeQuery(t, `{ states(search: "O", orderBy: `+order+`) { name } }`); got != want {
+			t.Errorf("states ordered by %s = %s, want %s", order, got, want)
+		}
+	}
+}
@@ -0,0 +1,77 @@
+package backend
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/runes"
+	"golang.org/x/text/transform"
+	"golang.org/x/text/unicode/norm"
+)
+
+// CollationPolicy selects how state names and searches are normalized into trie keys. Only the keys
+// are normalized; states keep their names as stored.
+type CollationPolicy string
+
+const (
+	// CollationExact matches names exactly as stored
+	CollationExact CollationPolicy = "exact"
+	// CollationCaseFold matches names regardless of case
+	CollationCaseFold CollationPolicy = "caseFold"
+	// CollationCaseAndAccentFold matches names regardless of case and accents, so "quebec" matches "Québec"
+	CollationCaseAndAccentFold CollationPolicy = "caseAndAccentFold"
+)
+
+// collationPolicies lists every policy in the order of their snapshot index
+var collationPolicies = []CollationPolicy{CollationExact, CollationCaseFold, CollationCaseAndAccentFold}
+
+// collationPolicy is the policy the tries are built and searched with, set on startup
+var collationPolicy = CollationExact
+
+// ParseCollationPolicy returns the policy with the given name
+func ParseCollationPolicy(name string) (CollationPolicy, error) {
+	for _, policy := range collationPolicies {
+		if string(policy) == name {
+			return policy, nil
+		}
+	}
+	return "", fmt.Errorf("unknown collation policy %q", name)
+}
+
+// index returns the snapshot index of the policy
+func (p CollationPolicy) index() uint32 {
+	for i, policy := range collationPolicies {
+		if policy == p {
+			return uint32(i)
+		}
+	}
+	return 0
+}
+
+// Key normalizes the text into the key it is stored and searched under. Keys are always in NFC.
+func (p CollationPolicy) Key(text string) string {
+	text = nfc(text)
+	switch p {
+	case CollationCaseFold:
+		return strings.ToLower(text)
+	case CollationCaseAndAccentFold:
+		return removeAccents(strings.ToLower(text))
+	default:
+		return text
+	}
+}
+
+// removeAccents strips combining marks from the text, so "é" becomes "e"
+func removeAccents(text string) string {
+	stripped, _, err := transform.String(transform.Chain(norm.NFD, runes.Remove(runes.In(unicode.Mn)), norm.NFC), text)
+	if err != nil {
+		return text
+	}
+	return stripped
+}
+
+// collationKey normalizes the text with the configured collation policy
+func collationKey(text string) string {
+	return collationPolicy.Key(text)
+}
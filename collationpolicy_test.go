@@ -0,0 +1,71 @@
+package backend
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+// withCollationPolicy sets the collation policy until the test ends
+func withCollationPolicy(t *testing.T, policy CollationPolicy) {
+	t.Helper()
+	previous := collationPolicy
+	t.Cleanup(func() { collationPolicy = previous })
+	collationPolicy = policy
+}
+
+func TestCollationPolicies(t *testing.T) {
+	searches := []string{"Québec", "quebec", "QUÉBEC", "Texas", "texas", "TEXAS"}
+	tests := []struct {
+		policy CollationPolicy
+		found  []bool
+	}{
+		{CollationExact, []bool{true, false, false, true, false, false}},
+		{CollationCaseFold, []bool{true, false, true, true, true, true}},
+		{CollationCaseAndAccentFold, []bool{true, true, true, true, true, true}},
+	}
+	for _, test := range tests {
+		withCollationPolicy(t, test.policy)
+		s := newTestStore(t,
+			State{Name: "Québec", Code: "QC", Enabled: true, Kind: KindProvince},
+			State{Name: "Texas", Code: "TX", Enabled: true, Kind: KindState},
+		)
+		for i, search := range searches {
+			names := searchNames(s.Root(), search)
+			if found := len(names) == 1; found != test.found[i] {
+				t.Errorf("searching %q under %s found %v, want found %t", search, test.policy, names, test.found[i])
+			}
+			// only the keys are normalized, never the names
+			if len(names) == 1 && names[0] != "Québec" && names[0] != "Texas" {
+				t.Errorf("searching %q under %s returned the name %q", search, test.policy, names[0])
+			}
+		}
+	}
+}
+
+func TestSnapshotOfAnotherCollationPolicyIsRebuilt(t *testing.T) {
+	withCollationPolicy(t, CollationExact)
+	s := newTestStore(t, State{Name: "Québec", Code: "QC", Enabled: true, Kind: KindProvince})
+	path := filepath.Join(t.TempDir(), "trie.snapshot")
+	if err := SaveTrieToMmap(s.Root(), path); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadTrieFromMmap(path); err != nil {
+		t.Fatalf("loading the snapshot under the policy it was built with: %v", err)
+	}
+
+	collationPolicy = CollationCaseAndAccentFold
+	if _, err := LoadTrieFromMmap(path); err != errSnapshotCollation {
+		t.Errorf("loading the snapshot under another policy: %v, want %v", err, errSnapshotCollation)
+	}
+}
+
+func TestParseCollationPolicy(t *testing.T) {
+	for _, policy := range collationPolicies {
+		if parsed, err := ParseCollationPolicy(string(policy)); err != nil || parsed != policy {
+			t.Errorf("ParseCollationPolicy(%q) = %q, %v", policy, parsed, err)
+		}
+	}
+	if _, err := ParseCollationPolicy("accentFold"); err == nil {
+		t.Error("ParseCollationPolicy accepted an unknown policy")
+	}
+}
@@ -0,0 +1,80 @@
+package backend
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+// compactionDivisor is the factor every frequency is divided by during compaction
+const compactionDivisor = 2
+
+// maxFrequency returns the highest frequency stored under the given trie node
+func maxFrequency(node *TrieNode) int64 {
+	var highest int64
+	if frequency := node.loadFrequency(); node.IsEnd && frequency > highest {
+		highest = frequency
+	}
+	for _, child := range node.Children {
+		if frequency := maxFrequency(child); frequency > highest {
+			highest = frequency
+		}
+	}
+	return highest
+}
+
+// rescaleFrequencies divides the frequency of every state under the node by the divisor,
+// which keeps their relative ordering, and records by how much each frequency changed. The caller
+// must hold the store's writeMu.
+func rescaleFrequencies(node *TrieNode, divisor int64, deltas map[string]int) {
+	if node.IsEnd {
+		node.storeFrequency(node.loadFrequency() / divisor)
+		for _, state := range node.States {
+			frequency := state.loadFrequency()
+			rescaled := frequency / divisor
+			deltas[state.Name] = int(rescaled - frequency)
+			state.storeFrequency(rescaled)
+		}
+	}
+	for _, child := range node.Children {
+		rescaleFrequencies(child, divisor, deltas)
+	}
+}
+
+// compactFrequencies rescales all frequencies of the store once the highest exceeds the threshold,
+// updating the trie and its frequency store, and returns the number of rescaled states
+func compactFrequencies(ctx context.Context, s *TrieStore, threshold int) (int, error) {
+	s.writeMu.Lock()
+	root := s.Root()
+	highest := maxFrequency(root)
+	if highest <= int64(threshold) {
+		s.writeMu.Unlock()
+		return 0, nil
+	}
+
+	deltas := make(map[string]int)
+	rescaleFrequencies(root, compactionDivisor, deltas)
+	s.writeMu.Unlock()
+
+	if err := s.Frequencies().BulkIncrement(ctx, deltas); err != nil {
+		return len(deltas), err
+	}
+	log.Printf("Compacted %d frequencies, Highest was: %d", len(deltas), highest)
+	return len(deltas), nil
+}
+
+// startFrequencyCompaction periodically compacts the frequencies in the background
+func startFrequencyCompaction(interval time.Duration, threshold int) {
+	if interval <= 0 {
+		return
+	}
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for range ticker.C {
+			if _, err := compactFrequencies(context.Background(), store, threshold); err != nil {
+				log.Printf("Error compacting frequencies: %v", err)
+			}
+		}
+	}()
+}
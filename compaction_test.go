@@ -0,0 +1,67 @@
+package backend
+
+import (
+	"context"
+	"testing"
+)
+
+func TestCompactFrequenciesKeepsOrdering(t *testing.T) {
+	s := newTestStore(t,
+		State{Name: "Texas", Code: "TX", Enabled: true, Kind: KindState, Frequency: 2000001},
+		State{Name: "Tennessee", Code: "TN", Enabled: true, Kind: KindState, Frequency: 900000},
+		State{Name: "Utah", Code: "UT", Enabled: true, Kind: KindState, Frequency: 41},
+		State{Name: "Ohio", Code: "OH", Enabled: true, Kind: KindState},
+	)
+	ctx := context.Background()
+
+	if compacted, err := compactFrequencies(ctx, s, 3000000); err != nil || compacted != 0 {
+		t.Fatalf("compaction below the threshold rescaled %d states: %v", compacted, err)
+	}
+	compacted, err := compactFrequencies(ctx, s, 1000000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if compacted != 4 {
+		t.Errorf("compaction rescaled %d states, want 4", compacted)
+	}
+
+	want := map[string]int64{"Texas": 1000000, "Tennessee": 450000, "Utah": 20, "Ohio": 0}
+	for name, frequency := range want {
+		if got := findState(s.Root(), name).loadFrequency(); got != frequency {
+			t.Errorf("%s has a frequency of %d in the trie after compaction, want %d", name, got, frequency)
+		}
+		persisted, err := s.Repository().FindByName(ctx, name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if persisted.Frequency != frequency {
+			t.Errorf("%s has a frequency of %d in the repository after compaction, want %d", name, persisted.Frequency, frequency)
+		}
+	}
+	var names []string
+	for _, state := range SearchStates(s.Root(), "T") {
+		names = append(names, state.Name)
+	}
+	if len(names) != 2 || names[0] != "Texas" || names[1] != "Tennessee" {
+		t.Errorf("searching T after compaction = %v, want Texas before Tennessee", names)
+	}
+
+	// the highest frequency is no longer above the threshold
+	if compacted, err := compactFrequencies(ctx, s, 1000000); err != nil || compacted != 0 {
+		t.Errorf("compacting again rescaled %d states: %v", compacted, err)
+	}
+}
+
+func TestMaxFrequency(t *testing.T) {
+	s := newTestStore(t,
+		State{Name: "Texas", Code: "TX", Enabled: true, Kind: KindState, Frequency: 7},
+		State{Name: "Tennessee", Code: "TN", Enabled: true, Kind: KindState, Frequency: 12},
+		State{Name: "Utah", Code: "UT", Enabled: true, Kind: KindState, Frequency: 3},
+	)
+	if highest := maxFrequency(s.Root()); highest != 12 {
+		t.Errorf("maxFrequency = %d, want 12", highest)
+	}
+	if highest := maxFrequency(newTrieRoot()); highest != 0 {
+		t.Errorf("maxFrequency of an empty trie = %d, want 0", highest)
+	}
+}
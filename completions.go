@@ -0,0 +1,90 @@
+package backend
+
+import (
+	"sort"
+
+	"github.com/graphql-go/graphql"
+)
+
+// NextChar is a character that can follow a prefix with the number of states reachable through it
+type NextChar struct {
+	Char  string `json:"char"`
+	Count int    `json:"count"`
+}
+
+// Completions are the characters that can follow a prefix in state names
+type Completions struct {
+	Prefix     string     `json:"prefix"`
+	IsComplete bool       `json:"isComplete"`
+	Next       []NextChar `json:"next"`
+}
+
+// completePrefix returns the characters following the prefix in the trie, those leading to the most
+// states first, and whether the prefix is itself the name of a state. It only reads the trie.
+func completePrefix(root *TrieNode, prefix string) *Completions {
+	completions := &Completions{Prefix: prefix, Next: []NextChar{}}
+	node := findNode(root, prefix)
+	if node == nil {
+		return completions
+	}
+	completions.IsComplete = node.IsEnd
+	for char, child := range node.Children {
+		completions.Next = append(completions.Next, NextChar{Char: string(char), Count: child.SubtreeCount})
+	}
+	sort.Slice(completions.Next, func(i, j int) bool {
+		if completions.Next[i].Count != completions.Next[j].Count {
+			return completions.Next[i].Count > completions.Next[j].Count
+		}
+		return completions.Next[i].Char < completions.Next[j].Char
+	})
+	return completions
+}
+
+// Define the GraphQL next char type
+var nextCharType = graphql.NewObject(graphql.ObjectConfig{
+	Name: "NextChar",
+	Fields: graphql.Fields{
+		"char": &graphql.Field{
+			Type: graphql.String,
+		},
+		"count": &graphql.Field{
+			Type:        graphql.Int,
+			Description: "States whose names continue the prefix with the character",
+		},
+	},
+})
+
+// Define the GraphQL completions type
+var completionsType = graphql.NewObject(graphql.ObjectConfig{
+	Name: "Completions",
+	Fields: graphql.Fields{
+		"prefix": &graphql.Field{
+			Type: graphql.String,
+		},
+		"isComplete": &graphql.Field{
+			Type:        graphql.Boolean,
+			Description: "Whether the prefix is itself the name of a state",
+		},
+		"next": &graphql.Field{
+			Type: graphql.NewList(nextCharType),
+		},
+	},
+})
+
+// completionsField lists the characters that can be typed after a prefix, so keyboards can disable
+// the others, without searching or updating frequencies
+var completionsField = &graphql.Field{
+	Type: completionsType,
+	Args: graphql.FieldConfigArgument{
+		"prefix": &graphql.ArgumentConfig{
+			Type: graphql.NewNonNull(graphql.String),
+		},
+	},
+	Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+		tenantStore, err := storeFor(p.Context)
+		if err != nil {
+			return nil, err
+		}
+		return completePrefix(tenantStore.Root(), collationKey(p.Args["prefix"].(string))), nil
+	},
+}
@@ -0,0 +1,35 @@
+package backend
+
+import "testing"
+
+func TestCompletions(t *testing.T) {
+	s := newTestStore(t,
+		State{Name: "Virginia", Code: "VA", Enabled: true, Kind: KindState},
+		State{Name: "Virginia Occidental", Code: "VO", Enabled: true, Kind: KindState},
+		State{Name: "Vermont", Code: "VT", Enabled: true, Kind: KindState},
+		State{Name: "Utah", Code: "UT", Enabled: true, Kind: KindState},
+	)
+	tests := []struct {
+		prefix string
+		want   string
+	}{
+		// the characters leading to the most states come first
+		{"", `{"completions":{"isComplete":false,"next":[{"char":"V","count":3},{"char":"U","count":1}],"prefix":""}}`},
+		{"V", `{"completions":{"isComplete":false,"next":[{"char":"i","count":2},{"char":"e","count":1}],"prefix":"V"}}`},
+		// a complete name that other names continue
+		{"Virginia", `{"completions":{"isComplete":true,"next":[{"char":" ","count":1}],"prefix":"Virginia"}}`},
+		{"Utah", `{"completions":{"isComplete":true,"next":[],"prefix":"Utah"}}`},
+		{"Vx", `{"completions":{"isComplete":false,"next":[],"prefix":"Vx"}}`},
+	}
+	for _, test := range tests {
+		query := `{ completions(prefix: "` + test.prefix + `") { prefix isComplete next { char count } } }`
+		if got := resolveCodeQuery(t, query); got != test.want {
+			t.Errorf("completions of %q = %s, want %s", test.prefix, got, test.want)
+		}
+	}
+	for _, state := range s.States() {
+		if state.loadFrequency() != 0 {
+			t.Errorf("frequency of %s after listing completions = %d, want 0", state.Name, state.loadFrequency())
+		}
+	}
+}
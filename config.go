@@ -0,0 +1,179 @@
+package backend
+
+import (
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Config holds the service configuration read from environment variables
+type Config struct {
+	Env                       string
+	MongoURI                  string
+	MongoReadURI              string
+	MongoDB                   string
+	GraphiQLDefaultQuery      string
+	AdminAPIKeys              map[string]string
+	ClearAllToken             string
+	AllowClearAllInProduction bool
+	TrieSnapshotPath          string
+	TrieSnapshotMaxAge        time.Duration
+	CompactionInterval        time.Duration
+	CompactionThreshold       int
+	RollupInterval            time.Duration
+	ClientIDs                 map[string]bool
+	AnalyticsMode             string
+	AnalyticsSampleRate       float64
+	AnalyticsSaltSecret       string
+	AllowUnpersistedQueries   bool
+	MinPrefixLen              int
+	Tenants                   map[string]bool
+	TenantIdleTimeout         time.Duration
+	MaxLoadedTenants          int
+	StateCodeLength           int
+	SyncInterval              time.Duration
+	SearchBackend             string
+	ESAddresses               []string
+	ESReindexInterval         time.Duration
+	CollationPolicy           string
+	InputCategories           []string
+	InputCharacters           string
+	InputMaxLength            int
+	AllowGETQueries           bool
+	PopularPrefixesInterval   time.Duration
+	GraphQLPath               string
+	QueryCacheTTL             time.Duration
+	RedisAddr                 string
+	LogOutput                 string
+	AdaptiveLimits            string
+	Standby                   bool
+	TrieMaxNodes              int
+	TriePruneInterval         time.Duration
+	FrequencyStore            string
+	FrequencySQLitePath       string
+	CORSPolicies              string
+	MongoFallback             bool
+	TLSCertFile               string
+	TLSKeyFile                string
+	HTTPPort                  int
+	DisableHTTPRedirect       bool
+	DatasetFile               string
+	TrieVerifySample          int
+	TrieVerifyMaxMissing      int
+}
+
+var config = loadConfig()
+
+// loadConfig reads the configuration from the environment, falling back to defaults
+func loadConfig() *Config {
+	return &Config{
+		Env:                       getEnv("ENV", "development"),
+		MongoURI:                  getEnv("MONGO_URI", defaultMongoURI),
+		MongoReadURI:              getEnv("MONGO_READ_URI", ""),
+		MongoDB:                   getEnv("MONGO_DB", defaultMongoDB),
+		GraphiQLDefaultQuery:      getEnv("GRAPHIQL_DEFAULT_QUERY", defaultGraphiQLQuery),
+		AdminAPIKeys:              parseAPIKeys(getEnv("ADMIN_API_KEYS", "")),
+		ClearAllToken:             getEnv("CLEAR_ALL_TOKEN", ""),
+		AllowClearAllInProduction: getEnvBool("ALLOW_CLEAR_ALL_IN_PRODUCTION", false),
+		TrieSnapshotPath:          getEnv("TRIE_SNAPSHOT_PATH", ""),
+		TrieSnapshotMaxAge:        getEnvDuration("TRIE_SNAPSHOT_MAX_AGE", 10*time.Minute),
+		CompactionInterval:        getEnvDuration("COMPACTION_INTERVAL", 0),
+		CompactionThreshold:       getEnvInt("COMPACTION_THRESHOLD", 1000000),
+		RollupInterval:            getEnvDuration("ROLLUP_INTERVAL", 24*time.Hour),
+		ClientIDs:                 parseIDSet(getEnv("CLIENT_IDS", "")),
+		AnalyticsMode:             getEnv("ANALYTICS_MODE", AnalyticsModeRaw),
+		AnalyticsSampleRate:       getEnvFloat("ANALYTICS_SAMPLE_RATE", 1),
+		AnalyticsSaltSecret:       getEnv("ANALYTICS_SALT_SECRET", ""),
+		AllowUnpersistedQueries:   getEnvBool("ALLOW_UNPERSISTED_QUERIES", true),
+		MinPrefixLen:              getEnvInt("MIN_PREFIX_LEN", 0),
+		Tenants:                   parseIDSet(getEnv("TENANTS", "")),
+		TenantIdleTimeout:         getEnvDuration("TENANT_IDLE_TIMEOUT", 30*time.Minute),
+		MaxLoadedTenants:          getEnvInt("MAX_LOADED_TENANTS", 50),
+		StateCodeLength:           getEnvInt("STATE_CODE_LENGTH", 2),
+		SyncInterval:              getEnvDuration("SYNC_INTERVAL", 0),
+		SearchBackend:             getEnv("SEARCH_BACKEND", SearchBackendTrie),
+		ESAddresses:               parseList(getEnv("ES_ADDRESSES", "")),
+		ESReindexInterval:         getEnvDuration("ES_REINDEX_INTERVAL", 10*time.Minute),
+		CollationPolicy:           getEnv("COLLATION_POLICY", string(CollationExact)),
+		InputCategories:           parseList(getEnv("INPUT_CATEGORIES", "L,M,Zs")),
+		InputCharacters:           getEnv("INPUT_CHARACTERS", "-'."),
+		InputMaxLength:            getEnvInt("INPUT_MAX_LENGTH", 100),
+		AllowGETQueries:           getEnvBool("ALLOW_GET_QUERIES", true),
+		PopularPrefixesInterval:   getEnvDuration("POPULAR_PREFIXES_INTERVAL", 24*time.Hour),
+		GraphQLPath:               getEnv("GRAPHQL_PATH", "/graphql"),
+		QueryCacheTTL:             getEnvDuration("QUERY_CACHE_TTL", 0),
+		RedisAddr:                 getEnv("REDIS_ADDR", "localhost:6379"),
+		LogOutput:                 getEnv("LOG_OUTPUT", logOutputStderr),
+		AdaptiveLimits:            getEnv("ADAPTIVE_LIMITS", ""),
+		Standby:                   getEnvBool("STANDBY", false),
+		TrieMaxNodes:              getEnvInt("TRIE_MAX_NODES", 0),
+		TriePruneInterval:         getEnvDuration("TRIE_PRUNE_INTERVAL", 5*time.Minute),
+		FrequencyStore:            getEnv("FREQUENCY_STORE", FrequencyStoreMongo),
+		FrequencySQLitePath:       getEnv("FREQUENCY_SQLITE_PATH", "frequencies.db"),
+		CORSPolicies:              getEnv("CORS_POLICIES", ""),
+		MongoFallback:             getEnvBool("MONGO_FALLBACK", false),
+		TLSCertFile:               getEnv("TLS_CERT_FILE", ""),
+		TLSKeyFile:                getEnv("TLS_KEY_FILE", ""),
+		HTTPPort:                  getEnvInt("HTTP_PORT", 80),
+		DisableHTTPRedirect:       getEnvBool("DISABLE_HTTP_REDIRECT", false),
+		DatasetFile:               getEnv("DATASET_FILE", ""),
+		TrieVerifySample:          getEnvInt("TRIE_VERIFY_SAMPLE", 20),
+		TrieVerifyMaxMissing:      getEnvInt("TRIE_VERIFY_MAX_MISSING", -1),
+	}
+}
+
+// getEnv returns the value of the environment variable or the fallback when it is unset
+func getEnv(key, fallback string) string {
+	if value, ok := os.LookupEnv(key); ok {
+		return value
+	}
+	return fallback
+}
+
+// getEnvBool returns the environment variable parsed as a bool or the fallback when it is unset or invalid
+func getEnvBool(key string, fallback bool) bool {
+	value, err := strconv.ParseBool(getEnv(key, ""))
+	if err != nil {
+		return fallback
+	}
+	return value
+}
+
+// getEnvInt returns the environment variable parsed as an int or the fallback when it is unset or invalid
+func getEnvInt(key string, fallback int) int {
+	value, err := strconv.Atoi(getEnv(key, ""))
+	if err != nil {
+		return fallback
+	}
+	return value
+}
+
+// getEnvFloat returns the environment variable parsed as a float or the fallback when it is unset or invalid
+func getEnvFloat(key string, fallback float64) float64 {
+	value, err := strconv.ParseFloat(getEnv(key, ""), 64)
+	if err != nil {
+		return fallback
+	}
+	return value
+}
+
+// getEnvDuration returns the environment variable parsed as a duration or the fallback when it is unset or invalid
+func getEnvDuration(key string, fallback time.Duration) time.Duration {
+	value, err := time.ParseDuration(getEnv(key, ""))
+	if err != nil {
+		return fallback
+	}
+	return value
+}
+
+// parseList splits a comma separated list, dropping empty entries
+func parseList(value string) []string {
+	var values []string
+	for _, v := range strings.Split(value, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			values = append(values, v)
+		}
+	}
+	return values
+}
@@ -0,0 +1,192 @@
+package backend
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+
+	"github.com/graphql-go/graphql"
+)
+
+// defaultConnectionFirst is the page size used when the query does not give one
+const defaultConnectionFirst = 10
+
+// errInvalidCursor is returned when an after cursor cannot be decoded or names no matching state
+var errInvalidCursor = errors.New("invalid cursor")
+
+// StateEdge is a state together with its position in a connection
+type StateEdge struct {
+	Node   *State `json:"node"`
+	Cursor string `json:"cursor"`
+}
+
+// PageInfo describes the page of a connection that was returned
+type PageInfo struct {
+	HasNextPage     bool   `json:"hasNextPage"`
+	HasPreviousPage bool   `json:"hasPreviousPage"`
+	StartCursor     string `json:"startCursor"`
+	EndCursor       string `json:"endCursor"`
+}
+
+// StateConnection is a Relay-style page of states
+type StateConnection struct {
+	Edges      []*StateEdge `json:"edges"`
+	PageInfo   *PageInfo    `json:"pageInfo"`
+	TotalCount int          `json:"totalCount"`
+}
+
+// encodeCursor encodes the name of a result as an opaque cursor. Cursors name the state rather
+// than its offset, so pages keep following on from the last returned state when frequency changes
+// reorder the results.
+func encodeCursor(name string) string {
+	return base64.StdEncoding.EncodeToString([]byte(name))
+}
+
+// decodeCursor decodes an opaque cursor back into the name of a result
+func decodeCursor(cursor string) (string, error) {
+	raw, err := base64.StdEncoding.DecodeString(cursor)
+	if err != nil || len(raw) == 0 {
+		return "", errInvalidCursor
+	}
+	return string(raw), nil
+}
+
+// newStateConnection slices the results into the page of the given size following the state named
+// by the after cursor
+func newStateConnection(results []*State, first int, after string) (*StateConnection, error) {
+	start := 0
+	if after != "" {
+		name, err := decodeCursor(after)
+		if err != nil {
+			return nil, err
+		}
+		start = -1
+		for i, state := range results {
+			if state.Name == name {
+				start = i + 1
+				break
+			}
+		}
+		if start < 0 {
+			return nil, errInvalidCursor
+		}
+	}
+	end := start + first
+	if end > len(results) {
+		end = len(results)
+	}
+
+	connection := &StateConnection{
+		Edges:      make([]*StateEdge, 0, end-start),
+		PageInfo:   &PageInfo{HasNextPage: end < len(results), HasPreviousPage: start > 0},
+		TotalCount: len(results),
+	}
+	for i := start; i < end; i++ {
+		connection.Edges = append(connection.Edges, &StateEdge{Node: results[i], Cursor: encodeCursor(results[i].Name)})
+	}
+	if len(connection.Edges) > 0 {
+		connection.PageInfo.StartCursor = connection.Edges[0].Cursor
+		connection.PageInfo.EndCursor = connection.Edges[len(connection.Edges)-1].Cursor
+	}
+	return connection, nil
+}
+
+// Define the GraphQL page info type
+var pageInfoType = graphql.NewObject(graphql.ObjectConfig{
+	Name: "PageInfo",
+	Fields: graphql.Fields{
+		"hasNextPage": &graphql.Field{
+			Type: graphql.NewNonNull(graphql.Boolean),
+		},
+		"hasPreviousPage": &graphql.Field{
+			Type: graphql.NewNonNull(graphql.Boolean),
+		},
+		"startCursor": &graphql.Field{
+			Type: graphql.String,
+		},
+		"endCursor": &graphql.Field{
+			Type: graphql.String,
+		},
+	},
+})
+
+// Define the GraphQL state edge type
+var stateEdgeType = graphql.NewObject(graphql.ObjectConfig{
+	Name: "StateEdge",
+	Fields: graphql.Fields{
+		"node": &graphql.Field{
+			Type: stateType,
+		},
+		"cursor": &graphql.Field{
+			Type: graphql.NewNonNull(graphql.String),
+		},
+	},
+})
+
+// Define the GraphQL state connection type
+var stateConnectionType = graphql.NewObject(graphql.ObjectConfig{
+	Name: "StateConnection",
+	Fields: graphql.Fields{
+		"edges": &graphql.Field{
+			Type: graphql.NewList(stateEdgeType),
+		},
+		"pageInfo": &graphql.Field{
+			Type: graphql.NewNonNull(pageInfoType),
+		},
+		"totalCount": &graphql.Field{
+			Type: graphql.Int,
+		},
+	},
+})
+
+// statesConnectionField pages through the states matching a prefix as a Relay connection,
+// updating the frequency of the states on the returned page only
+var statesConnectionField = &graphql.Field{
+	Type: stateConnectionType,
+	Args: graphql.FieldConfigArgument{
+		"search": &graphql.ArgumentConfig{
+			Type: graphql.String,
+		},
+		"first": &graphql.ArgumentConfig{
+			Type: graphql.Int,
+		},
+		"after": &graphql.ArgumentConfig{
+			Type: graphql.String,
+		},
+		"minPrefixLength": &graphql.ArgumentConfig{
+			Type:        graphql.Int,
+			Description: "Overrides the configured minimum search length for this request",
+		},
+	},
+	Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+		search, _ := p.Args["search"].(string)
+		if err := checkPrefixLength(search, minPrefixLengthArg(p.Args["minPrefixLength"])); err != nil {
+			return nil, err
+		}
+		first, ok := p.Args["first"].(int)
+		if !ok {
+			first = defaultConnectionFirst
+		}
+		if first < 0 {
+			return nil, fmt.Errorf("first must not be negative, got %d", first)
+		}
+		after, _ := p.Args["after"].(string)
+		if !checkSearchInput(p.Context, search) {
+			return newStateConnection(nil, first, after)
+		}
+
+		tenantStore, err := storeFor(p.Context)
+		if err != nil {
+			return nil, err
+		}
+		connection, err := newStateConnection(searchStates(p.Context, tenantStore.Root(), collationKey(search)), first, after)
+		if err != nil {
+			return nil, err
+		}
+		for _, edge := range connection.Edges {
+			updateFrequency(p.Context, tenantStore, edge.Node.Name)
+		}
+		logf(p.Context, "Returned %d of %d states for: %s", len(connection.Edges), connection.TotalCount, search)
+		return connection, nil
+	},
+}
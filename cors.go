@@ -0,0 +1,98 @@
+package backend
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/rs/cors"
+)
+
+// defaultCORSOrigin is the frontend allowed when CORS_POLICIES is unset
+const defaultCORSOrigin = "http://localhost:8083"
+
+// corsAllowedHeaders are the request headers allowed for origins whose policy lists none
+var corsAllowedHeaders = []string{"Accept", "Content-Type", "X-Requested-With", clientIDHeader, requestIDHeader, tenantHeader}
+
+// corsExposedHeaders are the response headers every allowed origin can read
+var corsExposedHeaders = []string{suggestionCountHeader, requestIDHeader, queryCacheHeader}
+
+// CORSPolicy is what one origin is allowed to send. Methods default to GET and POST and headers to
+// the ones the API reads.
+type CORSPolicy struct {
+	Methods     []string `json:"methods"`
+	Headers     []string `json:"headers"`
+	Credentials bool     `json:"credentials"`
+}
+
+// corsPolicies are the CORS policies per origin, parsed from CORS_POLICIES by Init
+var corsPolicies map[string]CORSPolicy
+
+// ParseCORSPolicies parses a JSON object of CORS policies by origin, e.g.
+// {"https://app.example.com": {"methods": ["GET", "POST"], "credentials": true}}. An empty value
+// allows the local frontend only.
+func ParseCORSPolicies(value string) (map[string]CORSPolicy, error) {
+	if strings.TrimSpace(value) == "" {
+		return map[string]CORSPolicy{defaultCORSOrigin: {Credentials: true}}, nil
+	}
+	var policies map[string]CORSPolicy
+	if err := json.Unmarshal([]byte(value), &policies); err != nil {
+		return nil, fmt.Errorf("CORS policies must be a JSON object of policies by origin: %w", err)
+	}
+	for origin := range policies {
+		if strings.Contains(origin, "*") {
+			return nil, fmt.Errorf("CORS origin %q must not hold wildcards", origin)
+		}
+	}
+	return policies, nil
+}
+
+// corsRouter applies the CORS policy of the request's Origin. Origins without a policy get no CORS
+// headers, so browsers block their cross-origin requests.
+type corsRouter struct {
+	byOrigin map[string]*cors.Cors
+	denied   *cors.Cors
+}
+
+// newCORSRouter creates a router applying the policies
+func newCORSRouter(policies map[string]CORSPolicy) *corsRouter {
+	router := &corsRouter{
+		byOrigin: make(map[string]*cors.Cors, len(policies)),
+		denied:   cors.New(cors.Options{AllowOriginFunc: func(string) bool { return false }}),
+	}
+	for origin, policy := range policies {
+		methods := policy.Methods
+		if len(methods) == 0 {
+			methods = []string{http.MethodGet, http.MethodPost}
+		}
+		headers := policy.Headers
+		if len(headers) == 0 {
+			headers = corsAllowedHeaders
+		}
+		router.byOrigin[strings.ToLower(origin)] = cors.New(cors.Options{
+			AllowedOrigins:   []string{origin},
+			AllowedMethods:   methods,
+			AllowedHeaders:   headers,
+			AllowCredentials: policy.Credentials,
+			ExposedHeaders:   corsExposedHeaders,
+		})
+	}
+	return router
+}
+
+// Handler wraps the handler with the CORS policy of each request's origin
+func (c *corsRouter) Handler(next http.Handler) http.Handler {
+	byOrigin := make(map[string]http.Handler, len(c.byOrigin))
+	for origin, policy := range c.byOrigin {
+		byOrigin[origin] = policy.Handler(next)
+	}
+	denied := c.denied.Handler(next)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if handler, ok := byOrigin[strings.ToLower(r.Header.Get("Origin"))]; ok {
+			handler.ServeHTTP(w, r)
+			return
+		}
+		denied.ServeHTTP(w, r)
+	})
+}
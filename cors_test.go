@@ -0,0 +1,79 @@
+package backend
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestCORSPoliciesPerOrigin(t *testing.T) {
+	policies, err := ParseCORSPolicies(`{
+		"https://app.example.com": {"methods": ["GET", "POST"], "credentials": true},
+		"https://kiosk.example.com": {"methods": ["GET"], "headers": ["Content-Type"]}
+	}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	handler := newCORSRouter(policies).Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	// browsers list the requested headers in lowercase
+	preflight := func(origin, method, headers string) http.Header {
+		request := httptest.NewRequest(http.MethodOptions, "/graphql", nil)
+		request.Header.Set("Origin", origin)
+		request.Header.Set("Access-Control-Request-Method", method)
+		if headers != "" {
+			request.Header.Set("Access-Control-Request-Headers", headers)
+		}
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, request)
+		return recorder.Header()
+	}
+
+	tests := []struct {
+		origin      string
+		method      string
+		headers     string
+		allowed     bool
+		credentials bool
+	}{
+		{"https://app.example.com", http.MethodPost, "x-client-id", true, true},
+		// origins are matched in any case
+		{"https://APP.example.com", http.MethodPost, "", true, true},
+		{"https://kiosk.example.com", http.MethodGet, "content-type", true, false},
+		{"https://kiosk.example.com", http.MethodPost, "", false, false},
+		{"https://kiosk.example.com", http.MethodGet, "x-client-id", false, false},
+		// origins without a policy get no CORS headers at all
+		{"https://evil.example.com", http.MethodGet, "", false, false},
+	}
+	for _, test := range tests {
+		header := preflight(test.origin, test.method, test.headers)
+		if allowed := header.Get("Access-Control-Allow-Origin") != ""; allowed != test.allowed {
+			t.Errorf("preflight of %s %s from %s allowed %t, want %t: %v", test.method, test.headers, test.origin, allowed, test.allowed, header)
+		}
+		if credentials := header.Get("Access-Control-Allow-Credentials") == "true"; credentials != test.credentials {
+			t.Errorf("preflight of %s from %s allows credentials %t, want %t", test.method, test.origin, credentials, test.credentials)
+		}
+	}
+
+	// actual requests of allowed origins can read the headers the API sets
+	request := httptest.NewRequest(http.MethodGet, "/graphql", nil)
+	request.Header.Set("Origin", "https://kiosk.example.com")
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, request)
+	if exposed := recorder.Header().Get("Access-Control-Expose-Headers"); exposed == "" {
+		t.Error("GET from the kiosk exposes no headers")
+	}
+}
+
+func TestParseCORSPolicies(t *testing.T) {
+	policies, err := ParseCORSPolicies("")
+	if err != nil || len(policies) != 1 || !policies[defaultCORSOrigin].Credentials {
+		t.Errorf("default CORS policies = %v, %v, want the local frontend with credentials", policies, err)
+	}
+	for _, value := range []string{`["https://app.example.com"]`, `{"https://*.example.com": {}}`} {
+		if _, err := ParseCORSPolicies(value); err == nil {
+			t.Errorf("ParseCORSPolicies(%s) accepted invalid policies", value)
+		}
+	}
+}
@@ -0,0 +1,78 @@
+package backend
+
+import (
+	"github.com/graphql-go/graphql"
+)
+
+// countSubtree sets the subtree count of the node and every node below it, for tries built
+// without insertKey
+func countSubtree(node *TrieNode) int {
+	node.SubtreeCount = len(node.States)
+	for _, child := range node.Children {
+		node.SubtreeCount += countSubtree(child)
+	}
+	return node.SubtreeCount
+}
+
+// countStates returns the number of states in the store passing the filters, read from its flat
+// list of states
+func countStates(s *TrieStore, filters ...stateFilter) int {
+	count := 0
+	for _, state := range s.States() {
+		if acceptState(state, filters) {
+			count++
+		}
+	}
+	return count
+}
+
+// countMatches returns the number of states whose names start with the prefix, in time
+// proportional to the length of the prefix
+func countMatches(root *TrieNode, prefix string) int {
+	node := findNode(root, prefix)
+	if node == nil {
+		return 0
+	}
+	return node.SubtreeCount
+}
+
+// countStatesField counts the loaded states of the tenant, disabled ones included, without fetching
+// them
+var countStatesField = &graphql.Field{
+	Type: graphql.Int,
+	Args: graphql.FieldConfigArgument{
+		"kinds": &graphql.ArgumentConfig{
+			Type:        graphql.NewList(stateKindEnum),
+			Description: "Only count states of these kinds, counting every kind by default",
+		},
+	},
+	Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+		tenantStore, err := storeFor(p.Context)
+		if err != nil {
+			return nil, err
+		}
+		var filters []stateFilter
+		if kinds := stringListArg(p.Args["kinds"]); len(kinds) > 0 {
+			filters = append(filters, includeKinds(kinds))
+		}
+		return countStates(tenantStore, filters...), nil
+	},
+}
+
+// countMatchesField counts the loaded states of the tenant whose names start with a prefix,
+// disabled ones included, without searching them or updating their frequency
+var countMatchesField = &graphql.Field{
+	Type: graphql.Int,
+	Args: graphql.FieldConfigArgument{
+		"prefix": &graphql.ArgumentConfig{
+			Type: graphql.NewNonNull(graphql.String),
+		},
+	},
+	Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+		tenantStore, err := storeFor(p.Context)
+		if err != nil {
+			return nil, err
+		}
+		return countMatches(tenantStore.Root(), collationKey(p.Args["prefix"].(string))), nil
+	},
+}
@@ -0,0 +1,73 @@
+package backend
+
+import (
+	"context"
+	"testing"
+)
+
+func TestCountStatesAndMatches(t *testing.T) {
+	withUnreachableMongo(t)
+	s := newTestStore(t,
+		State{Name: "Texas", Code: "TX", Enabled: true, Kind: KindState},
+		State{Name: "Tennessee", Code: "TN", Enabled: true, Kind: KindState},
+		State{Name: "Tamaulipas", Code: "TM", Enabled: false, Kind: KindProvince},
+		State{Name: "Puerto Rico", Code: "PR", Enabled: true, Kind: KindTerritory},
+		State{Name: "Ontario", Code: "ON", Enabled: true, Kind: KindProvince},
+	)
+	counts := func(want string) {
+		t.Helper()
+		query := `{ all: countStates states: countStates(kinds: [STATE]) others: countStates(kinds: [PROVINCE, TERRITORY]) ` +
+			`t: countMatches(prefix: "T") te: countMatches(prefix: "Te") z: countMatches(prefix: "Z") }`
+		if got := resolveCodeQuery(t, query); got != want {
+			t.Errorf("counts = %s, want %s", got, want)
+		}
+	}
+
+	// disabled states are counted
+	counts(`{"all":5,"others":3,"states":2,"t":3,"te":2,"z":0}`)
+
+	if result := runGraphQL(t, asAdmin(context.Background(), "ops"), `mutation { addState(name: "Tlaxcala", code: "TL") { name } }`); len(result.Errors) > 0 {
+		t.Fatal(result.Errors)
+	}
+	counts(`{"all":6,"others":3,"states":3,"t":4,"te":2,"z":0}`)
+
+	if err := s.Repository().Delete(context.Background(), "Tennessee"); err != nil {
+		t.Fatal(err)
+	}
+	syncLoadedTries(context.Background())
+	counts(`{"all":5,"others":3,"states":2,"t":3,"te":1,"z":0}`)
+
+	// counting selects nothing
+	for _, state := range s.States() {
+		if state.loadFrequency() != 0 {
+			t.Errorf("frequency of %s after counting = %d, want 0", state.Name, state.loadFrequency())
+		}
+	}
+}
+
+func TestCountSubtree(t *testing.T) {
+	s := newTestStore(t,
+		State{Name: "Texas", Code: "TX", Enabled: true, Kind: KindState},
+		State{Name: "Tennessee", Code: "TN", Enabled: true, Kind: KindState},
+		State{Name: "Utah", Code: "UT", Enabled: true, Kind: KindState},
+	)
+	root := s.Root()
+	want := map[string]int{"": 3, "T": 2, "Tex": 1, "U": 1}
+	clearCounts(root)
+	if count := countSubtree(root); count != 3 {
+		t.Errorf("countSubtree = %d, want 3", count)
+	}
+	for prefix, count := range want {
+		if got := countMatches(root, prefix); got != count {
+			t.Errorf("countMatches(%q) after countSubtree = %d, want %d", prefix, got, count)
+		}
+	}
+}
+
+// clearCounts zeroes the subtree count of the node and every node below it
+func clearCounts(node *TrieNode) {
+	node.SubtreeCount = 0
+	for _, child := range node.Children {
+		clearCounts(child)
+	}
+}
@@ -0,0 +1,126 @@
+package backend
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/graphql-go/graphql"
+)
+
+// FileStateRepository serves the default tenant's states from a JSON or CSV dataset file in the
+// seed file format. Changes and frequency increments are kept in memory only; the file is never
+// written.
+type FileStateRepository struct {
+	*InMemoryStateRepository
+	path string
+}
+
+// readDataset reads and validates the dataset file, failing on any invalid record
+func readDataset(path string) ([]*State, error) {
+	states, invalid, err := ReadSeedFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading dataset %s: %w", path, err)
+	}
+	for _, e := range invalid {
+		log.Printf("Invalid record in dataset %s: %v", path, e)
+	}
+	if len(invalid) > 0 {
+		return nil, fmt.Errorf("dataset %s has %d invalid records, first %v", path, len(invalid), invalid[0])
+	}
+	if len(states) == 0 {
+		return nil, fmt.Errorf("dataset %s holds no states", path)
+	}
+	return states, nil
+}
+
+// NewFileStateRepository creates a repository holding the states of the dataset file
+func NewFileStateRepository(path string) (*FileStateRepository, error) {
+	states, err := readDataset(path)
+	if err != nil {
+		return nil, err
+	}
+	r := &FileStateRepository{InMemoryStateRepository: NewInMemoryStateRepository(), path: path}
+	r.replace(states)
+	return r, nil
+}
+
+// replace swaps in the states, keeping the frequency of the states already held so selections
+// counted since the file was last read survive
+func (r *FileStateRepository) replace(states []*State) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	frequencies := make(map[string]int64, len(r.states))
+	for _, state := range r.states {
+		frequencies[state.Name] = state.Frequency
+	}
+	replaced := make([]State, 0, len(states))
+	for _, state := range states {
+		if frequency, ok := frequencies[state.Name]; ok {
+			state.Frequency = frequency
+		}
+		replaced = append(replaced, *state)
+	}
+	r.states = replaced
+}
+
+// reloadDataset reads the dataset file of the store's repository again and swaps in a trie rebuilt
+// from it, returning the number of states. An invalid file leaves the current states serving.
+func reloadDataset(ctx context.Context, s *TrieStore) (int, error) {
+	repo, ok := s.Repository().(*FileStateRepository)
+	if !ok {
+		return 0, fmt.Errorf("states are not loaded from a dataset file")
+	}
+	states, err := readDataset(repo.path)
+	if err != nil {
+		return 0, err
+	}
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+	repo.replace(states)
+	if err := s.RebuildTrie(ctx); err != nil {
+		return 0, err
+	}
+	return len(states), nil
+}
+
+// watchDatasetReloads reloads the dataset file into the given store on every SIGHUP
+func watchDatasetReloads(s *TrieStore) {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGHUP)
+	go func() {
+		for range signals {
+			count, err := reloadDataset(context.Background(), s)
+			if err != nil {
+				log.Printf("Error reloading dataset %s, keeping the current states: %v", config.DatasetFile, err)
+				continue
+			}
+			log.Printf("Reloaded %d states from dataset %s", count, config.DatasetFile)
+		}
+	}()
+}
+
+// reloadDatasetField reloads the dataset file like SIGHUP does, for platforms without signals, and
+// returns the number of loaded states
+var reloadDatasetField = &graphql.Field{
+	Type: graphql.Int,
+	Resolve: audited("reloadDataset", stateCountSnapshot, func(p graphql.ResolveParams) (interface{}, error) {
+		actor, err := requireAdmin(p.Context)
+		if err != nil {
+			return nil, err
+		}
+		if tenantID := tenantFromContext(p.Context); tenantID != defaultTenantID {
+			return nil, fmt.Errorf("only the %q tenant is loaded from a dataset file, not %q", defaultTenantID, tenantID)
+		}
+		count, err := reloadDataset(p.Context, store)
+		if err != nil {
+			log.Printf("Error reloading dataset for %s: %v", actor, err)
+			return nil, err
+		}
+		log.Printf("Reloaded %d states from dataset %s for %s", count, config.DatasetFile, actor)
+		return count, nil
+	}),
+}
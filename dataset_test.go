@@ -0,0 +1,106 @@
+package backend
+
+import (
+	"context"
+	"io"
+	"log"
+	"os"
+	"testing"
+)
+
+// newDatasetStore loads the dataset file into a store
+func newDatasetStore(t *testing.T, path string) *TrieStore {
+	t.Helper()
+	log.SetOutput(io.Discard)
+	repo, err := NewFileStateRepository(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := NewTrieStore(repo)
+	if err := s.RebuildTrie(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+// rewriteDataset replaces the content of the dataset file
+func rewriteDataset(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestReloadDataset(t *testing.T) {
+	path := writeTestFile(t, "states.csv", "name,code\nTexas,TX\nUtah,UT\n")
+	s := newDatasetStore(t, path)
+
+	rewriteDataset(t, path, "name,code\nTexas,TX\nOhio,OH\nMaine,ME\n")
+	count, err := reloadDataset(context.Background(), s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count != 3 {
+		t.Errorf("reloaded %d states, want 3", count)
+	}
+	root := s.Root()
+	for _, name := range []string{"Texas", "Ohio", "Maine"} {
+		if findState(root, name) == nil {
+			t.Errorf("state %q is missing after the reload", name)
+		}
+	}
+	if findState(root, "Utah") != nil {
+		t.Error("state \"Utah\" is still served after it was removed from the dataset")
+	}
+}
+
+func TestReloadDatasetKeepsStatesOnInvalidFile(t *testing.T) {
+	path := writeTestFile(t, "states.csv", "name,code\nTexas,TX\nUtah,UT\n")
+	s := newDatasetStore(t, path)
+	root := s.Root()
+
+	for _, content := range []string{
+		"name,code\nTexas,TX\n\"Ohio\"x,OH\n",
+		"name,code\nTexas,TX\nOhio,OHIO\n",
+		"name\nTexas\n",
+		"name,code\n",
+	} {
+		rewriteDataset(t, path, content)
+		if _, err := reloadDataset(context.Background(), s); err == nil {
+			t.Errorf("reloading %q succeeded, want an error", content)
+		}
+		if s.Root() != root {
+			t.Errorf("reloading %q replaced the trie", content)
+		}
+	}
+	for _, name := range []string{"Texas", "Utah"} {
+		if findState(s.Root(), name) == nil {
+			t.Errorf("state %q is missing after a failed reload", name)
+		}
+	}
+}
+
+func TestReloadDatasetKeepsFrequencies(t *testing.T) {
+	path := writeTestFile(t, "states.csv", "name,code,frequency\nTexas,TX,1\nUtah,UT,0\n")
+	s := newDatasetStore(t, path)
+	ctx := context.Background()
+	for i := 0; i < 3; i++ {
+		updateFrequency(ctx, s, "Texas")
+	}
+
+	rewriteDataset(t, path, "name,code,frequency\nTexas,TX,0\nUtah,UT,7\nOhio,OH,2\n")
+	if _, err := reloadDataset(ctx, s); err != nil {
+		t.Fatal(err)
+	}
+	root := s.Root()
+	for name, want := range map[string]int64{"Texas": 4, "Utah": 0, "Ohio": 2} {
+		state := findState(root, name)
+		if state == nil {
+			t.Errorf("state %q is missing after the reload", name)
+			continue
+		}
+		if got := state.loadFrequency(); got != want {
+			t.Errorf("frequency of %q = %d, want %d", name, got, want)
+		}
+	}
+}
@@ -10,61 +10,67 @@ async function run() {
     const collection = database.collection('states');
 
     const states = [
-      { name: 'Alabama', code: 'AL' },
-      { name: 'Alaska', code: 'AK' },
-      { name: 'Arizona', code: 'AZ' },
-      { name: 'Arkansas', code: 'AR' },
-      { name: 'California', code: 'CA' },
-      { name: 'Colorado', code: 'CO' },
-      { name: 'Connecticut', code: 'CT' },
-      { name: 'Delaware', code: 'DE' },
-      { name: 'Florida', code: 'FL' },
-      { name: 'Georgia', code: 'GA' },
-      { name: 'Hawaii', code: 'HI' },
-      { name: 'Idaho', code: 'ID' },
-      { name: 'Illinois', code: 'IL' },
-      { name: 'Indiana', code: 'IN' },
-      { name: 'Iowa', code: 'IA' },
-      { name: 'Kansas', code: 'KS' },
-      { name: 'Kentucky', code: 'KY' },
-      { name: 'Louisiana', code: 'LA' },
-      { name: 'Maine', code: 'ME' },
-      { name: 'Maryland', code: 'MD' },
-      { name: 'Massachusetts', code: 'MA' },
-      { name: 'Michigan', code: 'MI' },
-      { name: 'Minnesota', code: 'MN' },
-      { name: 'Mississippi', code: 'MS' },
-      { name: 'Missouri', code: 'MO' },
-      { name: 'Montana', code: 'MT' },
-      { name: 'Nebraska', code: 'NE' },
-      { name: 'Nevada', code: 'NV' },
-      { name: 'New Hampshire', code: 'NH' },
-      { name: 'New Jersey', code: 'NJ' },
-      { name: 'New Mexico', code: 'NM' },
-      { name: 'New York', code: 'NY' },
-      { name: 'North Carolina', code: 'NC' },
-      { name: 'North Dakota', code: 'ND' },
-      { name: 'Ohio', code: 'OH' },
-      { name: 'Oklahoma', code: 'OK' },
-      { name: 'Oregon', code: 'OR' },
-      { name: 'Pennsylvania', code: 'PA' },
-      { name: 'Rhode Island', code: 'RI' },
-      { name: 'South Carolina', code: 'SC' },
-      { name: 'South Dakota', code: 'SD' },
-      { name: 'Tennessee', code: 'TN' },
-      { name: 'Texas', code: 'TX' },
-      { name: 'Utah', code: 'UT' },
-      { name: 'Vermont', code: 'VT' },
-      { name: 'Virginia', code: 'VA' },
-      { name: 'Washington', code: 'WA' },
-      { name: 'West Virginia', code: 'WV' },
-      { name: 'Wisconsin', code: 'WI' },
-      { name: 'Wyoming', code: 'WY' },
-      { name: 'Puerto Rico', code: 'PR' },
-      { name: 'Guam', code: 'GU' },
-      { name: 'American Samoa', code: 'AS' },
-      { name: 'U.S. Virgin Islands', code: 'VI' },
-      { name: 'Northern Mariana Islands', code: 'MP' },          
+      { name: 'Alabama', code: 'AL', kind: 'STATE' },
+      { name: 'Alaska', code: 'AK', kind: 'STATE' },
+      { name: 'Arizona', code: 'AZ', kind: 'STATE' },
+      { name: 'Arkansas', code: 'AR', kind: 'STATE' },
+      { name: 'California', code: 'CA', kind: 'STATE' },
+      { name: 'Colorado', code: 'CO', kind: 'STATE' },
+      { name: 'Connecticut', code: 'CT', kind: 'STATE' },
+      { name: 'Delaware', code: 'DE', kind: 'STATE' },
+      { name: 'Florida', code: 'FL', kind: 'STATE' },
+      { name: 'Georgia', code: 'GA', kind: 'STATE' },
+      { name: 'Hawaii', code: 'HI', kind: 'STATE' },
+      { name: 'Idaho', code: 'ID', kind: 'STATE' },
+      { name: 'Illinois', code: 'IL', kind: 'STATE' },
+      { name: 'Indiana', code: 'IN', kind: 'STATE' },
+      { name: 'Iowa', code: 'IA', kind: 'STATE' },
+      { name: 'Kansas', code: 'KS', kind: 'STATE' },
+      { name: 'Kentucky', code: 'KY', kind: 'STATE' },
+      { name: 'Louisiana', code: 'LA', kind: 'STATE' },
+      { name: 'Maine', code: 'ME', kind: 'STATE' },
+      { name: 'Maryland', code: 'MD', kind: 'STATE' },
+      { name: 'Massachusetts', code: 'MA', kind: 'STATE' },
+      { name: 'Michigan', code: 'MI', kind: 'STATE' },
+      { name: 'Minnesota', code: 'MN', kind: 'STATE' },
+      { name: 'Mississippi', code: 'MS', kind: 'STATE' },
+      { name: 'Missouri', code: 'MO', kind: 'STATE' },
+      { name: 'Montana', code: 'MT', kind: 'STATE' },
+      { name: 'Nebraska', code: 'NE', kind: 'STATE' },
+      { name: 'Nevada', code: 'NV', kind: 'STATE' },
+      { name: 'New Hampshire', code: 'NH', kind: 'STATE' },
+      { name: 'New Jersey', code: 'NJ', kind: 'STATE' },
+      { name: 'New Mexico', code: 'NM', kind: 'STATE' },
+      { name: 'New York', code: 'NY', kind: 'STATE' },
+      { name: 'North Carolina', code: 'NC', kind: 'STATE' },
+      { name: 'North Dakota', code: 'ND', kind: 'STATE' },
+      { name: 'Ohio', code: 'OH', kind: 'STATE' },
+      { name: 'Oklahoma', code: 'OK', kind: 'STATE' },
+      { name: 'Oregon', code: 'OR', kind: 'STATE' },
+      { name: 'Pennsylvania', code: 'PA', kind: 'STATE' },
+      { name: 'Rhode Island', code: 'RI', kind: 'STATE' },
+      { name: 'South Carolina', code: 'SC', kind: 'STATE' },
+      { name: 'South Dakota', code: 'SD', kind: 'STATE' },
+      { name: 'Tennessee', code: 'TN', kind: 'STATE' },
+      { name: 'Texas', code: 'TX', kind: 'STATE' },
+      { name: 'Utah', code: 'UT', kind: 'STATE' },
+      { name: 'Vermont', code: 'VT', kind: 'STATE' },
+      { name: 'Virginia', code: 'VA', kind: 'STATE' },
+      { name: 'Washington', code: 'WA', kind: 'STATE' },
+      { name: 'West Virginia', code: 'WV', kind: 'STATE' },
+      { name: 'District of Columbia', code: 'DC', kind: 'DISTRICT' },
+      { name: 'Puerto Rico', code: 'PR', kind: 'TERRITORY' },
+      { name: 'Guam', code: 'GU', kind: 'TERRITORY' },
+      { name: 'U.S. Virgin Islands', code: 'VI', kind: 'TERRITORY' },
+      { name: 'American Samoa', code: 'AS', kind: 'TERRITORY' },
+      { name: 'Northern Mariana Islands', code: 'MP', kind: 'TERRITORY' },
+      { name: 'Wisconsin', code: 'WI', kind: 'STATE' },
+      { name: 'Wyoming', code: 'WY', kind: 'STATE' },
+      { name: 'Puerto Rico', code: 'PR', kind: 'STATE' },
+      { name: 'Guam', code: 'GU', kind: 'STATE' },
+      { name: 'American Samoa', code: 'AS', kind: 'STATE' },
+      { name: 'U.S. Virgin Islands', code: 'VI', kind: 'STATE' },
+      { name: 'Northern Mariana Islands', code: 'MP', kind: 'STATE' },          
     ];
 
     await collection.insertMany(states);
@@ -0,0 +1,161 @@
+package backend
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/graphql-go/graphql"
+)
+
+type debugContextKey struct{}
+
+// SearchDebug describes how the server handled a search
+type SearchDebug struct {
+	NormalizedPrefix string `json:"normalizedPrefix"`
+	NodesVisited     int    `json:"nodesVisited"`
+	ElapsedMicros    int    `json:"elapsedMicros"`
+}
+
+// SearchResult wraps the states matching a search with the normalized query it was matched with,
+// did-you-mean suggestions when nothing matched and optional debug information
+type SearchResult struct {
+	Items           interface{}  `json:"items"`
+	NormalizedQuery string       `json:"normalizedQuery"`
+	Suggestions     []Suggestion `json:"suggestions"`
+	Debug           *SearchDebug `json:"_debug"`
+}
+
+// withDebug enables debug information for requests with a debug=1 query parameter
+func withDebug(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("debug") == "1" {
+			r = r.WithContext(context.WithValue(r.Context(), debugContextKey{}, true))
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// debugEnabled reports whether debug information was requested
+func debugEnabled(ctx context.Context) bool {
+	enabled, _ := ctx.Value(debugContextKey{}).(bool)
+	return enabled
+}
+
+// countItems returns the number of states resolved by the states query
+func countItems(items interface{}) int {
+	switch items := items.(type) {
+	case []*State:
+		return len(items)
+	case []*StateResult:
+		return len(items)
+	case []State:
+		return len(items)
+	}
+	return 0
+}
+
+// countNodes counts the trie nodes in the subtree rooted at the node
+func countNodes(node *TrieNode) int {
+	count := 1
+	for _, child := range node.Children {
+		count += countNodes(child)
+	}
+	return count
+}
+
+// countVisitedNodes counts the trie nodes a search for the prefix visits
+func countVisitedNodes(root *TrieNode, prefix string) int {
+	if hasWildcard(prefix) {
+		m := &wildcardMatcher{
+			pattern: []rune(prefix),
+			seen:    make(map[wildcardStep]bool),
+		}
+		m.match(root, 0)
+		return len(m.seen)
+	}
+
+	visited := 0
+	node := root
+	for _, char := range prefix {
+		node = node.Children[char]
+		if node == nil {
+			return visited
+		}
+		visited++
+	}
+	return visited + countNodes(node)
+}
+
+// Define the GraphQL search debug type
+var searchDebugType = graphql.NewObject(graphql.ObjectConfig{
+	Name: "SearchDebug",
+	Fields: graphql.Fields{
+		"normalizedPrefix": &graphql.Field{
+			Type: graphql.String,
+		},
+		"nodesVisited": &graphql.Field{
+			Type: graphql.Int,
+		},
+		"elapsedMicros": &graphql.Field{
+			Type: graphql.Int,
+		},
+	},
+})
+
+// Define the GraphQL search result type
+var searchResultType = graphql.NewObject(graphql.ObjectConfig{
+	Name: "SearchResult",
+	Fields: graphql.Fields{
+		"items": &graphql.Field{
+			Type: graphql.NewList(stateType),
+		},
+		"normalizedQuery": &graphql.Field{
+			Type:        graphql.String,
+			Description: "The search after the server normalized it, e.g. lowercased and stripped of accents",
+		},
+		"suggestions": &graphql.Field{
+			Type:        graphql.NewList(suggestionType),
+			Description: "Names close to the search, only given when no state matched it",
+		},
+		"_debug": &graphql.Field{
+			Type: searchDebugType,
+		},
+	},
+})
+
+// resolveSearch resolves the search query, wrapping the states query results with the normalized
+// query and adding debug information when the request enabled it
+func resolveSearch(p graphql.ResolveParams) (interface{}, error) {
+	start := time.Now()
+	items, err := resolveStates(p)
+	if err != nil {
+		return nil, err
+	}
+	search, _ := p.Args["search"].(string)
+	tokenize, _ := p.Args["tokenize"].(bool)
+	locale, _ := p.Args["locale"].(string)
+	tenantStore, err := storeFor(p.Context)
+	if err != nil {
+		return nil, err
+	}
+	ignoreCase, _ := p.Args["ignoreCase"].(bool)
+	locale = resolveLocale(p.Context, tenantStore, locale)
+	root, key := searchRoot(tenantStore, search, searchOptions{
+		Tokenize:   tokenize,
+		IgnoreCase: ignoreCase,
+		Locale:     locale,
+	})
+	result := &SearchResult{Items: items, NormalizedQuery: key}
+	if countItems(items) == 0 && inputPolicy.ValidateSearch(search) == nil {
+		result.Suggestions = suggestNames(tenantStore.States(), search, locale)
+	}
+	if debugEnabled(p.Context) {
+		result.Debug = &SearchDebug{
+			NormalizedPrefix: key,
+			NodesVisited:     countVisitedNodes(root, key),
+			ElapsedMicros:    int(time.Since(start) / time.Microsecond),
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,22 @@
+package backend
+
+import "testing"
+
+func TestSearchNormalizedQuery(t *testing.T) {
+	tests := []struct {
+		policy CollationPolicy
+		query  string
+		want   string
+	}{
+		{CollationExact, `search(search: "QuÉ") { normalizedQuery items { name } }`, `{"search":{"items":[],"normalizedQuery":"QuÉ"}}`},
+		{CollationExact, `search(search: "QuÉ", ignoreCase: true) { normalizedQuery items { name } }`, `{"search":{"items":[{"name":"Québec"}],"normalizedQuery":"qué"}}`},
+		{CollationCaseAndAccentFold, `search(search: "QuÉ") { normalizedQuery items { name } }`, `{"search":{"items":[{"name":"Québec"}],"normalizedQuery":"que"}}`},
+	}
+	for _, test := range tests {
+		withCollationPolicy(t, test.policy)
+		newTestStore(t, State{Name: "Québec", Code: "QC", Enabled: true, Kind: KindProvince})
+		if got := resolveCodeQuery(t, "{ "+test.query+" }"); got != test.want {
+			t.Errorf("%s under %s = %s, want %s", test.query, test.policy, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,234 @@
+package backend
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/elastic/go-elasticsearch/v8"
+	"github.com/elastic/go-elasticsearch/v8/esapi"
+)
+
+// elasticsearchMaxResults caps the number of states a single Elasticsearch search returns
+const elasticsearchMaxResults = 1000
+
+// elasticsearchIndexMapping maps state names and codes as exact keywords for prefix and term queries
+const elasticsearchIndexMapping = `{
+  "mappings": {
+    "properties": {
+      "name": {"type": "keyword"},
+      "code": {"type": "keyword"},
+      "frequency": {"type": "integer"},
+      "enabled": {"type": "boolean"},
+      "kind": {"type": "keyword"},
+      "translations": {"type": "object", "enabled": false}
+    }
+  }
+}`
+
+// ElasticsearchSearchProvider searches an Elasticsearch index per tenant, named like the tenant's
+// MongoDB collection. The indexes are rebuilt from MongoDB on a schedule; frequency updates are only
+// written to MongoDB.
+type ElasticsearchSearchProvider struct {
+	client *elasticsearch.Client
+}
+
+// elasticsearchSearchResponse is the part of a search response holding the matched states
+type elasticsearchSearchResponse struct {
+	Hits struct {
+		Hits []struct {
+			Source State `json:"_source"`
+		} `json:"hits"`
+	} `json:"hits"`
+}
+
+// NewElasticsearchSearchProvider creates a provider searching the Elasticsearch nodes at the addresses
+func NewElasticsearchSearchProvider(addresses []string) (*ElasticsearchSearchProvider, error) {
+	client, err := elasticsearch.NewClient(elasticsearch.Config{Addresses: addresses})
+	if err != nil {
+		return nil, err
+	}
+	return &ElasticsearchSearchProvider{client: client}, nil
+}
+
+// elasticsearchError returns an error describing a failed Elasticsearch response
+func elasticsearchError(action string, res *esapi.Response) error {
+	body, _ := io.ReadAll(res.Body)
+	return fmt.Errorf("elasticsearch %s failed: %s: %s", action, res.Status(), body)
+}
+
+// Search runs a prefix query on the name and a term query on the code in the tenant's index
+func (p *ElasticsearchSearchProvider) Search(ctx context.Context, search string, opts searchOptions, filters ...stateFilter) ([]*State, error) {
+	query := map[string]interface{}{
+		"size": elasticsearchMaxResults,
+		"query": map[string]interface{}{
+			"bool": map[string]interface{}{
+				"should": []interface{}{
+					map[string]interface{}{"prefix": map[string]interface{}{
+						"name": map[string]interface{}{"value": search, "case_insensitive": opts.IgnoreCase},
+					}},
+					map[string]interface{}{"term": map[string]interface{}{
+						"code": map[string]interface{}{"value": search, "case_insensitive": true},
+					}},
+				},
+				"minimum_should_match": 1,
+			},
+		},
+		"sort": []interface{}{
+			map[string]interface{}{"frequency": "desc"},
+			map[string]interface{}{"name": "asc"},
+		},
+	}
+	body, err := json.Marshal(query)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := p.client.Search(
+		p.client.Search.WithContext(ctx),
+		p.client.Search.WithIndex(tenantCollectionName(tenantFromContext(ctx))),
+		p.client.Search.WithBody(bytes.NewReader(body)),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+	if res.IsError() {
+		return nil, elasticsearchError("search", res)
+	}
+
+	var response elasticsearchSearchResponse
+	if err := json.NewDecoder(res.Body).Decode(&response); err != nil {
+		return nil, err
+	}
+	states := make([]*State, 0, len(response.Hits.Hits))
+	for i := range response.Hits.Hits {
+		states = append(states, &response.Hits.Hits[i].Source)
+	}
+	return filterStates(states, append([]stateFilter{isEnabled}, filters...)), nil
+}
+
+// Reindex replaces the documents of the index with the states, creating the index if needed
+func (p *ElasticsearchSearchProvider) Reindex(ctx context.Context, index string, states []*State) error {
+	if err := p.ensureIndex(ctx, index); err != nil {
+		return err
+	}
+
+	var bulk bytes.Buffer
+	ids := make([]string, 0, len(states))
+	for _, state := range states {
+		action, err := json.Marshal(map[string]interface{}{"index": map[string]string{"_id": state.Name}})
+		if err != nil {
+			return err
+		}
+		doc, err := json.Marshal(state)
+		if err != nil {
+			return err
+		}
+		bulk.Write(action)
+		bulk.WriteByte('\n')
+		bulk.Write(doc)
+		bulk.WriteByte('\n')
+		ids = append(ids, state.Name)
+	}
+	if bulk.Len() > 0 {
+		res, err := p.client.Bulk(&bulk, p.client.Bulk.WithContext(ctx), p.client.Bulk.WithIndex(index))
+		if err != nil {
+			return err
+		}
+		defer res.Body.Close()
+		if res.IsError() {
+			return elasticsearchError("bulk index", res)
+		}
+		var response struct {
+			Errors bool `json:"errors"`
+		}
+		if err := json.NewDecoder(res.Body).Decode(&response); err != nil {
+			return err
+		}
+		if response.Errors {
+			return fmt.Errorf("elasticsearch bulk index of %s reported item errors", index)
+		}
+	}
+
+	stale, err := json.Marshal(map[string]interface{}{
+		"query": map[string]interface{}{
+			"bool": map[string]interface{}{
+				"must_not": map[string]interface{}{"ids": map[string]interface{}{"values": ids}},
+			},
+		},
+	})
+	if err != nil {
+		return err
+	}
+	res, err := p.client.DeleteByQuery([]string{index}, bytes.NewReader(stale), p.client.DeleteByQuery.WithContext(ctx))
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if res.IsError() {
+		return elasticsearchError("delete stale documents", res)
+	}
+	return nil
+}
+
+// ensureIndex creates the index with the state mapping unless it exists
+func (p *ElasticsearchSearchProvider) ensureIndex(ctx context.Context, index string) error {
+	res, err := p.client.Indices.Exists([]string{index}, p.client.Indices.Exists.WithContext(ctx))
+	if err != nil {
+		return err
+	}
+	res.Body.Close()
+	if res.StatusCode == http.StatusOK {
+		return nil
+	}
+
+	res, err = p.client.Indices.Create(index,
+		p.client.Indices.Create.WithContext(ctx),
+		p.client.Indices.Create.WithBody(bytes.NewReader([]byte(elasticsearchIndexMapping))),
+	)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if res.IsError() {
+		return elasticsearchError("create index", res)
+	}
+	return nil
+}
+
+// reindexLoadedTenants rebuilds the index of every loaded tenant from its repository
+func reindexLoadedTenants(ctx context.Context, p *ElasticsearchSearchProvider) {
+	for tenantID, tenantStore := range tenants.Loaded() {
+		states, err := tenantStore.Repository().FindAll(ctx)
+		if err != nil {
+			log.Printf("Error reading states to index for tenant %s: %v", tenantID, err)
+			continue
+		}
+		if err := p.Reindex(ctx, tenantCollectionName(tenantID), states); err != nil {
+			log.Printf("Error indexing states for tenant %s: %v", tenantID, err)
+			continue
+		}
+		log.Printf("Indexed %d states for tenant %s", len(states), tenantID)
+	}
+}
+
+// startSearchIndexRebuild rebuilds the Elasticsearch indexes right away and then periodically in the background
+func startSearchIndexRebuild(p *ElasticsearchSearchProvider, interval time.Duration) {
+	go func() {
+		reindexLoadedTenants(context.Background(), p)
+		if interval <= 0 {
+			return
+		}
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for range ticker.C {
+			reindexLoadedTenants(context.Background(), p)
+		}
+	}()
+}
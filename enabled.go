@@ -0,0 +1,90 @@
+package backend
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/graphql-go/graphql"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// UnmarshalBSON decodes a state, treating documents without an enabled field as enabled and
+// documents without a kind as states
+func (s *State) UnmarshalBSON(data []byte) error {
+	type rawState State
+	raw := rawState{Enabled: true, Kind: KindState}
+	if err := bson.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	*s = State(raw)
+	return nil
+}
+
+// isEnabled keeps only enabled states in search results
+func isEnabled(state *State) bool {
+	return state.Enabled
+}
+
+// setStateEnabled enables or disables the named state of the tenant in MongoDB and the trie
+func setStateEnabled(ctx context.Context, name string, enabled bool) (*State, error) {
+	tenantStore, err := storeFor(ctx)
+	if err != nil {
+		return nil, err
+	}
+	state := findState(tenantStore.Root(), name)
+	if state == nil {
+		return nil, fmt.Errorf("state %q not found", name)
+	}
+
+	if err := tenantStore.Repository().SetEnabled(ctx, state.Name, enabled); err != nil {
+		return nil, err
+	}
+	tenantStore.writeMu.Lock()
+	updated := copyState(state)
+	updated.Enabled = enabled
+	applyStateChanges(ctx, tenantStore, stateChanges{Updated: []*State{updated}})
+	tenantStore.writeMu.Unlock()
+	log.Printf("Set enabled for state: %s, Enabled: %t", state.Name, enabled)
+	return findState(tenantStore.Root(), state.Name), nil
+}
+
+// stateSnapshot captures the named state for the audit log
+func stateSnapshot(p graphql.ResolveParams) bson.M {
+	name, _ := p.Args["name"].(string)
+	tenantStore, err := storeFor(p.Context)
+	if err != nil {
+		return nil
+	}
+	state := findState(tenantStore.Root(), name)
+	if state == nil {
+		return nil
+	}
+	return bson.M{
+		"name":         state.Name,
+		"code":         state.Code,
+		"frequency":    state.loadFrequency(),
+		"enabled":      state.Enabled,
+		"kind":         state.Kind,
+		"translations": state.Translations,
+	}
+}
+
+// setStateEnabledField hides or shows a state in search results without deleting it
+var setStateEnabledField = &graphql.Field{
+	Type: stateType,
+	Args: graphql.FieldConfigArgument{
+		"name": &graphql.ArgumentConfig{
+			Type: graphql.NewNonNull(graphql.String),
+		},
+		"enabled": &graphql.ArgumentConfig{
+			Type: graphql.NewNonNull(graphql.Boolean),
+		},
+	},
+	Resolve: audited("setStateEnabled", stateSnapshot, func(p graphql.ResolveParams) (interface{}, error) {
+		if _, err := requireAdmin(p.Context); err != nil {
+			return nil, err
+		}
+		return setStateEnabled(p.Context, p.Args["name"].(string), p.Args["enabled"].(bool))
+	}),
+}
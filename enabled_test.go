@@ -0,0 +1,80 @@
+package backend
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// searchNames returns the names of the states found for the prefix
+func searchNames(root *TrieNode, prefix string) []string {
+	var names []string
+	for _, state := range SearchStates(root, prefix) {
+		names = append(names, state.Name)
+	}
+	return names
+}
+
+func TestSetStateEnabledHidesAndShowsState(t *testing.T) {
+	withUnreachableMongo(t)
+	s := newTestStore(t,
+		State{Name: "Texas", Code: "TX", Enabled: true, Kind: KindState, Frequency: 2},
+		State{Name: "Tennessee", Code: "TN", Enabled: true, Kind: KindState, Frequency: 1},
+	)
+	ctx := asAdmin(context.Background(), "ops")
+
+	if result := runGraphQL(t, context.Background(), `mutation { setStateEnabled(name: "Texas", enabled: false) { name } }`); len(result.Errors) != 1 || !strings.Contains(result.Errors[0].Message, errUnauthorized.Error()) {
+		t.Errorf("disabling without an admin = %v, want %v", result.Errors, errUnauthorized)
+	}
+	if result := runGraphQL(t, ctx, `mutation { setStateEnabled(name: "Atlantis", enabled: false) { name } }`); len(result.Errors) != 1 {
+		t.Errorf("disabling an unknown state = %v, want an error", result.Errors)
+	}
+
+	for _, test := range []struct {
+		enabled string
+		want    []string
+	}{
+		{"false", []string{"Tennessee"}},
+		{"true", []string{"Texas", "Tennessee"}},
+	} {
+		result := runGraphQL(t, ctx, `mutation { setStateEnabled(name: "Texas", enabled: `+test.enabled+`) { name enabled } }`)
+		if len(result.Errors) > 0 {
+			t.Fatal(result.Errors)
+		}
+		if names := searchNames(s.Root(), "Te"); strings.Join(names, ",") != strings.Join(test.want, ",") {
+			t.Errorf("searching Te with Texas enabled %s = %v, want %v", test.enabled, names, test.want)
+		}
+		// disabled states stay in the trie and the repository
+		if state := findState(s.Root(), "Texas"); state == nil || state.Enabled != (test.enabled == "true") || state.loadFrequency() != 2 {
+			t.Errorf("Texas in the trie = %+v, want enabled %s with its frequency of 2", state, test.enabled)
+		}
+		if state, err := s.Repository().FindByName(context.Background(), "Texas"); err != nil || state.Enabled != (test.enabled == "true") {
+			t.Errorf("Texas in the repository = %+v, %v, want enabled %s", state, err, test.enabled)
+		}
+	}
+}
+
+func TestUnmarshalStateDefaults(t *testing.T) {
+	for _, test := range []struct {
+		document bson.M
+		enabled  bool
+		kind     string
+	}{
+		{bson.M{"name": "Texas"}, true, KindState},
+		{bson.M{"name": "Texas", "enabled": false, "kind": KindTerritory}, false, KindTerritory},
+	} {
+		data, err := bson.Marshal(test.document)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var state State
+		if err := bson.Unmarshal(data, &state); err != nil {
+			t.Fatal(err)
+		}
+		if state.Name != "Texas" || state.Enabled != test.enabled || state.Kind != test.kind {
+			t.Errorf("decoding %v = %+v, want enabled %t and kind %s", test.document, state, test.enabled, test.kind)
+		}
+	}
+}
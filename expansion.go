@@ -0,0 +1,187 @@
+package backend
+
+import (
+	"context"
+	_ "embed"
+	"encoding/json"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+const (
+	// expansionPenalty scales the frequency of states only matched through an expanded search,
+	// ranking them slightly below direct matches
+	expansionPenalty = 0.9
+	// maxExpansionVariants caps the number of expanded searches run for a single search
+	maxExpansionVariants = 8
+)
+
+//go:embed abbreviations.json
+var defaultAbbreviationsFile []byte
+
+// Abbreviation is a user-typed abbreviation and the words it stands for
+type Abbreviation struct {
+	Abbreviation string   `bson:"abbreviation" json:"abbreviation"`
+	Expansions   []string `bson:"expansions" json:"expansions"`
+}
+
+// ExpansionDictionary maps abbreviations such as "N" or "W" to the words they stand for.
+// Abbreviations are matched against whole words of a search, ignoring case.
+type ExpansionDictionary struct {
+	mu      sync.RWMutex
+	entries map[string][]string
+}
+
+var expansions = newDefaultExpansions()
+
+// NewExpansionDictionary creates an empty dictionary
+func NewExpansionDictionary() *ExpansionDictionary {
+	return &ExpansionDictionary{entries: make(map[string][]string)}
+}
+
+// newDefaultExpansions creates a dictionary holding the embedded default abbreviations
+func newDefaultExpansions() *ExpansionDictionary {
+	d := NewExpansionDictionary()
+	var defaults map[string][]string
+	if err := json.Unmarshal(defaultAbbreviationsFile, &defaults); err != nil {
+		log.Printf("Error decoding default abbreviations: %v", err)
+		return d
+	}
+	for abbreviation, words := range defaults {
+		d.Add(abbreviation, words...)
+	}
+	return d
+}
+
+// Add registers the expansions of the abbreviation, keeping ones already registered
+func (d *ExpansionDictionary) Add(abbreviation string, words ...string) {
+	key := strings.ToLower(abbreviation)
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	for _, word := range words {
+		if word != "" && !containsString(d.entries[key], word) {
+			d.entries[key] = append(d.entries[key], word)
+		}
+	}
+}
+
+// Variants returns the searches obtained by replacing whole words of the search with their
+// expansions, without the search itself. Words are never expanded inside other words, so "N"
+// expands in "N Dak" but not in "Nevada".
+func (d *ExpansionDictionary) Variants(search string) []string {
+	words := strings.Fields(search)
+	if len(words) == 0 {
+		return nil
+	}
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	variants := [][]string{{}}
+	for _, word := range words {
+		choices := append([]string{word}, d.entries[strings.ToLower(word)]...)
+		next := make([][]string, 0, len(variants)*len(choices))
+		for _, variant := range variants {
+			for _, choice := range choices {
+				if len(next) == maxExpansionVariants+1 {
+					break
+				}
+				next = append(next, append(append([]string(nil), variant...), choice))
+			}
+		}
+		variants = next
+	}
+
+	searches := make([]string, 0, len(variants)-1)
+	for _, variant := range variants[1:] {
+		searches = append(searches, strings.Join(variant, " "))
+	}
+	return searches
+}
+
+// containsString reports whether the slice contains the string
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// loadAbbreviations adds the abbreviations stored in MongoDB to the default ones
+func loadAbbreviations() {
+	collection := client.Database(config.MongoDB).Collection("abbreviations")
+	cursor, err := collection.Find(context.Background(), bson.M{})
+	if err != nil {
+		log.Printf("Error loading abbreviations: %v", err)
+		return
+	}
+	defer cursor.Close(context.Background())
+
+	var abbreviations []Abbreviation
+	if err := cursor.All(context.Background(), &abbreviations); err != nil {
+		log.Printf("Error decoding abbreviations: %v", err)
+		return
+	}
+	for _, abbreviation := range abbreviations {
+		expansions.Add(abbreviation.Abbreviation, abbreviation.Expansions...)
+	}
+	log.Printf("Loaded %d abbreviations", len(abbreviations))
+}
+
+// scoredState is a search result ranked by its possibly penalized frequency
+type scoredState struct {
+	state    *State
+	score    float64
+	expanded bool
+}
+
+// searchWithExpansions searches the store for the prefix and for every expanded variant of it,
+// merging the results. States only matched by a variant rank as if their frequency was scaled by
+// expansionPenalty.
+func searchWithExpansions(ctx context.Context, s *TrieStore, search string, opts searchOptions, filters ...stateFilter) []*State {
+	root, key := searchRoot(s, search, opts)
+	direct := searchStates(ctx, root, key, filters...)
+	if hasWildcard(search) {
+		return direct
+	}
+	variants := expansions.Variants(search)
+	if len(variants) == 0 {
+		return direct
+	}
+
+	scored := make([]scoredState, 0, len(direct))
+	seen := make(map[*State]bool, len(direct))
+	for _, state := range direct {
+		scored = append(scored, scoredState{state: state, score: float64(state.loadFrequency())})
+		seen[state] = true
+	}
+	for _, variant := range variants {
+		root, key := searchRoot(s, variant, opts)
+		for _, state := range searchStates(ctx, root, key, filters...) {
+			if !seen[state] {
+				scored = append(scored, scoredState{state: state, score: float64(state.loadFrequency()) * expansionPenalty, expanded: true})
+				seen[state] = true
+			}
+		}
+	}
+
+	sort.SliceStable(scored, func(i, j int) bool {
+		if scored[i].score != scored[j].score {
+			return scored[i].score > scored[j].score
+		}
+		if scored[i].expanded != scored[j].expanded {
+			return !scored[i].expanded
+		}
+		return scored[i].state.Name < scored[j].state.Name
+	})
+	results := make([]*State, len(scored))
+	for i, entry := range scored {
+		results[i] = entry.state
+	}
+	return results
+}
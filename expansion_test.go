@@ -0,0 +1,82 @@
+package backend
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+func TestSearchWithExpansions(t *testing.T) {
+	s := newTestStore(t,
+		State{Name: "North Dakota", Code: "ND", Enabled: true, Kind: KindState, Frequency: 11},
+		State{Name: "North Carolina", Code: "NC", Enabled: true, Kind: KindState, Frequency: 40},
+		State{Name: "Nevada", Code: "NV", Enabled: true, Kind: KindState, Frequency: 20},
+		State{Name: "West Virginia", Code: "WV", Enabled: true, Kind: KindState},
+		State{Name: "Virginia", Code: "VA", Enabled: true, Kind: KindState},
+		State{Name: "N Dak Junction", Code: "NJ", Enabled: true, Kind: KindTerritory, Frequency: 10},
+	)
+	tests := []struct {
+		search string
+		want   []string
+	}{
+		{"North Dak", []string{"North Dakota"}},
+		{"W Virg", []string{"West Virginia"}},
+		{"W Va", []string{"West Virginia"}},
+		{"N Car", []string{"North Carolina"}},
+		// abbreviations do not expand inside words
+		{"Nevada", []string{"Nevada"}},
+		{"Ne", []string{"Nevada"}},
+		{"Virg", []string{"Virginia"}},
+		// direct matches outrank expanded ones of about the same frequency: North Dakota is
+		// selected 11 times, but only matches after expansion
+		{"N Dak", []string{"N Dak Junction", "North Dakota"}},
+	}
+	for _, test := range tests {
+		var names []string
+		for _, state := range searchWithExpansions(context.Background(), s, test.search, searchOptions{}) {
+			names = append(names, state.Name)
+		}
+		if !reflect.DeepEqual(names, test.want) {
+			t.Errorf("searching %q with expansions = %v, want %v", test.search, names, test.want)
+		}
+	}
+}
+
+func TestExpansionVariants(t *testing.T) {
+	d := NewExpansionDictionary()
+	d.Add("N", "North", "New")
+	d.Add("dak", "Dakota")
+	d.Add("N", "North", "")
+
+	tests := []struct {
+		search string
+		want   []string
+	}{
+		{"n dak", []string{"n Dakota", "North dak", "North Dakota", "New dak", "New Dakota"}},
+		{"Nevada", []string{}},
+		{"  ", nil},
+		// the number of variants is capped
+		{"N N N N", []string{"N N N North", "N N N New", "N N North N", "N N North North", "N N North New", "N N New N", "N N New North", "N N New New"}},
+	}
+	for _, test := range tests {
+		if variants := d.Variants(test.search); !reflect.DeepEqual(variants, test.want) {
+			t.Errorf("variants of %q = %q, want %q", test.search, variants, test.want)
+		}
+	}
+}
+
+func TestDefaultExpansions(t *testing.T) {
+	for _, test := range []struct {
+		abbreviation string
+		want         string
+	}{
+		{"n", "North"},
+		{"W", "West"},
+		{"dak", "Dakota"},
+		{"st", "Saint"},
+	} {
+		if variants := expansions.Variants(test.abbreviation); len(variants) == 0 || variants[0] != test.want {
+			t.Errorf("default expansions of %q = %q, want %q first", test.abbreviation, variants, test.want)
+		}
+	}
+}
@@ -0,0 +1,95 @@
+package backend
+
+import (
+	"sort"
+
+	"github.com/graphql-go/graphql"
+)
+
+// SearchExplanation describes how a single search result was found
+type SearchExplanation struct {
+	State         *State  `json:"-"`
+	MatchType     string  `json:"matchType"`
+	TrieDepth     int     `json:"trieDepth"`
+	FrequencyRank int     `json:"frequencyRank"`
+	Score         float64 `json:"score"`
+}
+
+// ExplainSearch searches the trie for states with the given key prefix and explains each match
+// without updating any frequencies. Frequency ranks follow the order of the returned explanations:
+// most frequently selected first, ties broken by name.
+func ExplainSearch(root *TrieNode, prefix string) []SearchExplanation {
+	node := root
+	depth := 0
+	for _, char := range prefix {
+		if node.Children[char] == nil {
+			return nil
+		}
+		node = node.Children[char]
+		depth++
+	}
+
+	explanations := []SearchExplanation{}
+	collectExplanations(node, depth, true, &explanations)
+	sort.Slice(explanations, func(i, j int) bool {
+		if explanations[i].Score != explanations[j].Score {
+			return explanations[i].Score > explanations[j].Score
+		}
+		return explanations[i].State.Name < explanations[j].State.Name
+	})
+	for i := range explanations {
+		explanations[i].FrequencyRank = i
+	}
+	return explanations
+}
+
+// collectExplanations collects an explanation for every state below the given trie node. The
+// states of the node the search ended on are stored under exactly the searched key, whichever
+// collation, case folding or tokenizing produced it, so they are exact matches.
+func collectExplanations(node *TrieNode, depth int, exact bool, results *[]SearchExplanation) {
+	matchType := "prefix"
+	if exact {
+		matchType = "exact"
+	}
+	for _, state := range node.States {
+		*results = append(*results, SearchExplanation{
+			State:     state,
+			MatchType: matchType,
+			TrieDepth: depth,
+			Score:     float64(state.loadFrequency()),
+		})
+	}
+	for _, child := range node.Children {
+		collectExplanations(child, depth+1, false, results)
+	}
+}
+
+// attachExplanations attaches to each result the explanation of its state
+func attachExplanations(results []*StateResult, explanations []SearchExplanation) {
+	byState := make(map[*State]*SearchExplanation, len(explanations))
+	for i := range explanations {
+		byState[explanations[i].State] = &explanations[i]
+	}
+	for _, result := range results {
+		result.Explanation = byState[result.State]
+	}
+}
+
+// Define the GraphQL search explanation type
+var explanationType = graphql.NewObject(graphql.ObjectConfig{
+	Name: "SearchExplanation",
+	Fields: graphql.Fields{
+		"matchType": &graphql.Field{
+			Type: graphql.String,
+		},
+		"trieDepth": &graphql.Field{
+			Type: graphql.Int,
+		},
+		"frequencyRank": &graphql.Field{
+			Type: graphql.Int,
+		},
+		"score": &graphql.Field{
+			Type: graphql.Float,
+		},
+	},
+})
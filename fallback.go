@@ -0,0 +1,39 @@
+package backend
+
+import "context"
+
+// maxFallbackStates caps the states read from the repository for a search the trie missed
+const maxFallbackStates = 50
+
+// fallbackToRepository looks the search up in the store's repository when the trie has no state
+// for it, e.g. for a state inserted into MongoDB but not synced yet, and inserts the states found
+// into the trie. It reports whether any were inserted. Pruned states are left to restorePruned.
+func fallbackToRepository(ctx context.Context, s *TrieStore, search string) (bool, error) {
+	if search == "" || hasWildcard(search) {
+		return false, nil
+	}
+	found, err := s.Repository().FindByPrefix(ctx, search, maxFallbackStates)
+	if err != nil {
+		return false, err
+	}
+	if err := addFrequencies(ctx, s.Frequencies(), found); err != nil {
+		return false, err
+	}
+
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+	root := s.Root()
+	var missing []*State
+	for _, state := range found {
+		normalizeState(state)
+		if findState(root, state.Name) == nil && !s.pruned.contains(state.Name) {
+			missing = append(missing, state)
+		}
+	}
+	if len(missing) == 0 {
+		return false, nil
+	}
+	applyStateChanges(ctx, s, stateChanges{Inserted: missing})
+	logf(ctx, "Inserted %d states missing from the trie for: %s", len(missing), search)
+	return true, nil
+}
@@ -0,0 +1,67 @@
+package backend
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+func TestSearchFallsBackToRepository(t *testing.T) {
+	s := newTestStore(t, State{Name: "Texas", Code: "TX", Enabled: true, Kind: KindState, Frequency: 2})
+	previous := config.MongoFallback
+	t.Cleanup(func() { config.MongoFallback = previous })
+	// a state inserted into the repository after the trie was built, like one not synced yet
+	if err := s.Repository().Insert(context.Background(), &State{Name: "Tennessee", Code: "TN", Enabled: true, Kind: KindState, Frequency: 1}); err != nil {
+		t.Fatal(err)
+	}
+	search := func() []string {
+		states, err := TrieSearchProvider{}.Search(context.Background(), "Tenn", searchOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		var names []string
+		for _, state := range states {
+			names = append(names, state.Name)
+		}
+		return names
+	}
+
+	config.MongoFallback = false
+	if names := search(); len(names) != 0 {
+		t.Errorf("search of Tenn without the fallback = %v, want nothing", names)
+	}
+	config.MongoFallback = true
+	if names := search(); !reflect.DeepEqual(names, []string{"Tennessee"}) {
+		t.Errorf("search of Tenn with the fallback = %v, want Tennessee", names)
+	}
+	// the state found is inserted into the trie, so later searches no longer need the repository
+	if names := searchNames(s.Root(), "T"); !reflect.DeepEqual(names, []string{"Texas", "Tennessee"}) {
+		t.Errorf("searching T after the fallback = %v, want Texas and Tennessee", names)
+	}
+	config.MongoFallback = false
+	if names := search(); !reflect.DeepEqual(names, []string{"Tennessee"}) {
+		t.Errorf("search of Tenn after the fallback = %v, want Tennessee from the trie", names)
+	}
+}
+
+func TestFallbackToRepository(t *testing.T) {
+	s := newTestStore(t, State{Name: "Texas", Code: "TX", Enabled: true, Kind: KindState, Frequency: 2})
+	if err := s.Repository().Insert(context.Background(), &State{Name: "Tennessee", Code: "TN", Enabled: true, Kind: KindState}); err != nil {
+		t.Fatal(err)
+	}
+	// states already in the trie, wildcards and empty searches insert nothing
+	for _, search := range []string{"Tex", "T*", "", "Atl"} {
+		if inserted, err := fallbackToRepository(context.Background(), s, search); inserted || err != nil {
+			t.Errorf("fallback for %q = %t, %v, want nothing inserted", search, inserted, err)
+		}
+	}
+	if findState(s.Root(), "Tennessee") != nil {
+		t.Fatal("Tennessee was inserted by a search not matching it")
+	}
+	if inserted, err := fallbackToRepository(context.Background(), s, "T"); !inserted || err != nil {
+		t.Errorf("fallback for T = %t, %v, want Tennessee inserted", inserted, err)
+	}
+	if findState(s.Root(), "Tennessee") == nil {
+		t.Error("Tennessee missing from the trie after the fallback")
+	}
+}
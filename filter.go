@@ -0,0 +1,78 @@
+package backend
+
+import (
+	"strings"
+)
+
+// stateFilter reports whether a matched state should be kept in the search results
+type stateFilter func(state *State) bool
+
+// filterStates keeps the states accepted by every filter, reusing the slice
+func filterStates(states []*State, filters []stateFilter) []*State {
+	if len(filters) == 0 {
+		return states
+	}
+	kept := states[:0]
+	for _, state := range states {
+		if acceptState(state, filters) {
+			kept = append(kept, state)
+		}
+	}
+	return kept
+}
+
+// acceptState reports whether every filter accepts the state
+func acceptState(state *State, filters []stateFilter) bool {
+	for _, filter := range filters {
+		if !filter(state) {
+			return false
+		}
+	}
+	return true
+}
+
+// excludeStates returns a filter dropping the states whose name or code is any of the values,
+// ignoring case. Values matching no state drop nothing.
+func excludeStates(values []string) stateFilter {
+	excluded := make(map[string]bool, len(values))
+	for _, value := range values {
+		excluded[strings.ToLower(strings.TrimSpace(value))] = true
+	}
+	return func(state *State) bool {
+		return !excluded[strings.ToLower(state.Name)] && (state.Code == "" || !excluded[strings.ToLower(state.Code)])
+	}
+}
+
+// frequencyRange returns a filter keeping the states selected at least minArg and at most maxArg
+// times, both inclusive and optional, or nil when neither is given
+func frequencyRange(minArg, maxArg interface{}) (stateFilter, error) {
+	min, hasMin := minArg.(int)
+	max, hasMax := maxArg.(int)
+	if hasMin && hasMax && min > max {
+		return nil, invalidInput("minFrequency %d must not be greater than maxFrequency %d", min, max)
+	}
+	switch {
+	case hasMin && hasMax:
+		return func(state *State) bool {
+			frequency := state.loadFrequency()
+			return frequency >= int64(min) && frequency <= int64(max)
+		}, nil
+	case hasMin:
+		return func(state *State) bool { return state.loadFrequency() >= int64(min) }, nil
+	case hasMax:
+		return func(state *State) bool { return state.loadFrequency() <= int64(max) }, nil
+	}
+	return nil, nil
+}
+
+// stringListArg converts a GraphQL list argument into a string slice
+func stringListArg(arg interface{}) []string {
+	values, _ := arg.([]interface{})
+	strs := make([]string, 0, len(values))
+	for _, value := range values {
+		if str, ok := value.(string); ok {
+			strs = append(strs, str)
+		}
+	}
+	return strs
+}
@@ -0,0 +1,133 @@
+package backend
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+func TestStatesExclude(t *testing.T) {
+	withUnreachableMongo(t)
+	newTestStore(t,
+		State{Name: "New York", Code: "NY", Enabled: true, Kind: KindState, Frequency: 30},
+		State{Name: "New Jersey", Code: "NJ", Enabled: true, Kind: KindState, Frequency: 20},
+		State{Name: "New Mexico", Code: "NM", Enabled: true, Kind: KindState, Frequency: 10},
+	)
+	tests := []struct {
+		exclude string
+		want    []string
+	}{
+		{`["new jersey"]`, []string{"New York", "New Mexico"}},
+		{`[" NEW YORK "]`, []string{"New Jersey", "New Mexico"}},
+		// codes are excluded like names
+		{`["nm", "NY"]`, []string{"New Jersey"}},
+		// values matching no state drop nothing
+		{`["Texas", ""]`, []string{"New York", "New Jersey", "New Mexico"}},
+		{`[]`, []string{"New York", "New Jersey", "New Mexico"}},
+	}
+	for _, test := range tests {
+		result := runGraphQL(t, context.Background(), `{ states(search: "New", exclude: `+test.exclude+`) { name } }`)
+		if len(result.Errors) > 0 {
+			t.Fatal(result.Errors)
+		}
+		var names []string
+		for _, state := range result.Data.(map[string]interface{})["states"].([]interface{}) {
+			names = append(names, state.(map[string]interface{})["name"].(string))
+		}
+		if !reflect.DeepEqual(names, test.want) {
+			t.Errorf("searching New excluding %s = %v, want %v", test.exclude, names, test.want)
+		}
+	}
+}
+
+func TestExcludedStatesLeaveLimitSlots(t *testing.T) {
+	newTestStore(t,
+		State{Name: "New York", Code: "NY", Enabled: true, Kind: KindState, Frequency: 300},
+		State{Name: "New Jersey", Code: "NJ", Enabled: true, Kind: KindState, Frequency: 200},
+		State{Name: "New Mexico", Code: "NM", Enabled: true, Kind: KindState, Frequency: 100},
+		State{Name: "New Hampshire", Code: "NH", Enabled: true, Kind: KindState, Frequency: 50},
+	)
+	tests := []struct {
+		query string
+		want  string
+	}{
+		// the excluded states are dropped before the limit, so a full page of others remains
+		{`{ states(search: "New", limit: 2, exclude: ["ny", "New Jersey"]) { name } }`, `{"states":[{"name":"New Mexico"},{"name":"New Hampshire"}]}`},
+		{`{ states(search: "New", limit: 3, exclude: ["NEW YORK"]) { name } }`, `{"states":[{"name":"New Jersey"},{"name":"New Mexico"},{"name":"New Hampshire"}]}`},
+		{`{ suggest(q: "New", limit: 2, exclude: ["nY", "nj"]) { state { name } } }`, `{"suggest":[{"state":{"name":"New Mexico"}},{"state":{"name":"New Hampshire"}}]}`},
+	}
+	for _, test := range tests {
+		if got := resolveCodeQuery(t, test.query); got != test.want {
+			t.Errorf("%s = %s, want %s", test.query, got, test.want)
+		}
+	}
+}
+
+func TestFrequencyRange(t *testing.T) {
+	states := []*State{{Name: "Cold", Frequency: 0}, {Name: "Warm", Frequency: 5}, {Name: "Hot", Frequency: 10}}
+	tests := []struct {
+		min, max interface{}
+		want     []string
+	}{
+		// both bounds are inclusive
+		{0, 5, []string{"Cold", "Warm"}},
+		{5, 10, []string{"Warm", "Hot"}},
+		{5, 5, []string{"Warm"}},
+		{nil, 0, []string{"Cold"}},
+		{6, nil, []string{"Hot"}},
+		{11, nil, []string{}},
+	}
+	for _, test := range tests {
+		filter, err := frequencyRange(test.min, test.max)
+		if err != nil {
+			t.Fatal(err)
+		}
+		names := []string{}
+		for _, state := range filterStates(append([]*State(nil), states...), []stateFilter{filter}) {
+			names = append(names, state.Name)
+		}
+		if !reflect.DeepEqual(names, test.want) {
+			t.Errorf("frequencies from %v to %v = %v, want %v", test.min, test.max, names, test.want)
+		}
+	}
+	if filter, err := frequencyRange(nil, nil); filter != nil || err != nil {
+		t.Errorf("frequencyRange without bounds = %v, %v, want no filter", filter, err)
+	}
+}
+
+func TestFrequencyFilterArguments(t *testing.T) {
+	tests := []struct {
+		query string
+		want  string
+	}{
+		{`{ states(search: "T", minFrequency: 5) { name } }`, `{"states":[{"name":"Texas"},{"name":"Tamaulipas"}]}`},
+		{`{ states(search: "T", maxFrequency: 0) { name } }`, `{"states":[{"name":"Tennessee"}]}`},
+		{`{ states(search: "T", minFrequency: 5, kinds: [STATE]) { name } }`, `{"states":[{"name":"Texas"}]}`},
+		// a band bounded by the frequencies of Tamaulipas and Texas keeps both
+		{`{ states(search: "T", minFrequency: 5, maxFrequency: 10) { name } }`, `{"states":[{"name":"Texas"},{"name":"Tamaulipas"}]}`},
+		{`{ states(search: "Te", minFrequency: 5, maxFrequency: 10) { name } }`, `{"states":[{"name":"Texas"}]}`},
+		{`{ allStates(minFrequency: 5, maxFrequency: 9) { name } }`, `{"allStates":[{"name":"Tamaulipas"},{"name":"Utah"}]}`},
+		{`{ allStates(maxFrequency: 0) { name } }`, `{"allStates":[{"name":"Tennessee"}]}`},
+	}
+	for _, test := range tests {
+		newTestStore(t,
+			State{Name: "Texas", Code: "TX", Enabled: true, Kind: KindState, Frequency: 10},
+			State{Name: "Tamaulipas", Code: "TM", Enabled: true, Kind: KindProvince, Frequency: 5},
+			State{Name: "Tennessee", Code: "TN", Enabled: true, Kind: KindState},
+			State{Name: "Utah", Code: "UT", Enabled: true, Kind: KindState, Frequency: 9},
+		)
+		if got := resolveCodeQuery(t, test.query); got != test.want {
+			t.Errorf("%s = %s, want %s", test.query, got, test.want)
+		}
+	}
+
+	for _, query := range []string{
+		`{ states(search: "T", minFrequency: 6, maxFrequency: 5) { name } }`,
+		`{ allStates(minFrequency: 1, maxFrequency: 0) { name } }`,
+		`{ states(search: "Te", minFrequency: 10, maxFrequency: 9) { name } }`,
+	} {
+		if result := runGraphQL(t, context.Background(), query); len(result.Errors) != 1 || result.Errors[0].Extensions["code"] != invalidInputCode {
+			t.Errorf("%s = %v, want an %s error", query, result.Errors, invalidInputCode)
+		}
+	}
+}
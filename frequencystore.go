@@ -0,0 +1,271 @@
+package backend
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strconv"
+
+	"github.com/go-redis/redis/v8"
+	_ "github.com/mattn/go-sqlite3"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	// FrequencyStoreMongo persists frequencies in the state documents themselves
+	FrequencyStoreMongo = "mongo"
+	// FrequencyStoreRedis persists frequency increments in a Redis hash per tenant
+	FrequencyStoreRedis = "redis"
+	// FrequencyStoreSQLite persists frequency increments in a SQLite database
+	FrequencyStoreSQLite = "sqlite"
+	// redisFrequencyKeyPrefix prefixes the Redis hash holding the increments of a tenant
+	redisFrequencyKeyPrefix = "stateFrequencies:"
+)
+
+// FrequencyStore persists the frequency increments of a tenant's states. The trie stays in
+// memory; only where its frequencies are written to is swappable.
+type FrequencyStore interface {
+	Increment(ctx context.Context, name string, delta int) error
+	BulkIncrement(ctx context.Context, deltas map[string]int) error
+	// Frequencies returns the increments to add to the frequencies the state repository holds,
+	// or nil when increments are written to the repository itself
+	Frequencies(ctx context.Context) (map[string]int, error)
+}
+
+// newFrequencyStore creates the frequency store of a tenant as configured by FREQUENCY_STORE, or is
+// nil when frequencies are persisted by the tenant's state repository
+var newFrequencyStore func(tenantID string) FrequencyStore
+
+// initFrequencyStore selects the frequency store configured by FREQUENCY_STORE
+func initFrequencyStore() error {
+	switch config.FrequencyStore {
+	case FrequencyStoreMongo:
+		newFrequencyStore = nil
+	case FrequencyStoreRedis:
+		client := redis.NewClient(&redis.Options{Addr: config.RedisAddr})
+		newFrequencyStore = func(tenantID string) FrequencyStore {
+			return NewRedisFrequencyStore(client, tenantID)
+		}
+	case FrequencyStoreSQLite:
+		db, err := OpenSQLiteFrequencyDB(config.FrequencySQLitePath)
+		if err != nil {
+			return err
+		}
+		newFrequencyStore = func(tenantID string) FrequencyStore {
+			return NewSQLiteFrequencyStore(db, tenantID)
+		}
+	default:
+		return fmt.Errorf("unknown frequency store %q, must be %q, %q or %q",
+			config.FrequencyStore, FrequencyStoreMongo, FrequencyStoreRedis, FrequencyStoreSQLite)
+	}
+	return nil
+}
+
+// repositoryFrequencyStore returns the frequency store writing to the repository itself, or nil when
+// the repository cannot store increments
+func repositoryFrequencyStore(repo StateRepository) FrequencyStore {
+	switch r := repo.(type) {
+	case *MongoStateRepository:
+		return NewMongoFrequencyStore(r.collection)
+	case FrequencyStore:
+		return r
+	}
+	return nil
+}
+
+// newTenantTrieStore creates the trie store of the tenant loaded from the repository, persisting
+// frequencies in the configured frequency store
+func newTenantTrieStore(tenantID string, repo StateRepository) *TrieStore {
+	s := NewTrieStore(repo)
+	if newFrequencyStore != nil {
+		s.frequencies = newFrequencyStore(tenantID)
+	}
+	return s
+}
+
+// addFrequencies adds the increments persisted in the frequency store to the frequencies of the
+// states, which were read from the state repository
+func addFrequencies(ctx context.Context, frequencies FrequencyStore, states []*State) error {
+	if frequencies == nil || len(states) == 0 {
+		return nil
+	}
+	deltas, err := frequencies.Frequencies(ctx)
+	if err != nil {
+		return err
+	}
+	for _, state := range states {
+		state.Frequency += int64(deltas[state.Name])
+	}
+	return nil
+}
+
+// MongoFrequencyStore increments the frequencies of the state documents of a collection
+type MongoFrequencyStore struct {
+	collection *mongo.Collection
+}
+
+// NewMongoFrequencyStore creates a store incrementing frequencies in the collection
+func NewMongoFrequencyStore(collection *mongo.Collection) *MongoFrequencyStore {
+	return &MongoFrequencyStore{collection: collection}
+}
+
+// Increment adds the delta to the frequency of the named state
+func (f *MongoFrequencyStore) Increment(ctx context.Context, name string, delta int) error {
+	_, err := f.collection.UpdateOne(ctx, bson.M{"name": name}, bson.M{"$inc": bson.M{"frequency": delta}})
+	return err
+}
+
+// BulkIncrement adds the deltas to the frequencies of the named states in a single unordered bulk
+// write
+func (f *MongoFrequencyStore) BulkIncrement(ctx context.Context, deltas map[string]int) error {
+	if len(deltas) == 0 {
+		return nil
+	}
+	models := make([]mongo.WriteModel, 0, len(deltas))
+	for name, delta := range deltas {
+		models = append(models, mongo.NewUpdateOneModel().
+			SetFilter(bson.M{"name": name}).
+			SetUpdate(bson.M{"$inc": bson.M{"frequency": delta}}))
+	}
+	_, err := f.collection.BulkWrite(ctx, models, options.BulkWrite().SetOrdered(false))
+	return err
+}
+
+// Frequencies returns nil, since the increments are already part of the state documents
+func (f *MongoFrequencyStore) Frequencies(ctx context.Context) (map[string]int, error) {
+	return nil, nil
+}
+
+// RedisFrequencyStore keeps the frequency increments of a tenant in a Redis hash keyed by state name
+type RedisFrequencyStore struct {
+	client *redis.Client
+	key    string
+}
+
+// NewRedisFrequencyStore creates a store keeping the increments of the tenant in the Redis server
+func NewRedisFrequencyStore(client *redis.Client, tenantID string) *RedisFrequencyStore {
+	return &RedisFrequencyStore{client: client, key: redisFrequencyKeyPrefix + tenantID}
+}
+
+// Increment adds the delta to the increment of the named state with HINCRBY
+func (f *RedisFrequencyStore) Increment(ctx context.Context, name string, delta int) error {
+	return f.client.HIncrBy(ctx, f.key, name, int64(delta)).Err()
+}
+
+// BulkIncrement adds the deltas to the increments of the named states in a single transaction
+func (f *RedisFrequencyStore) BulkIncrement(ctx context.Context, deltas map[string]int) error {
+	if len(deltas) == 0 {
+		return nil
+	}
+	_, err := f.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		for name, delta := range deltas {
+			pipe.HIncrBy(ctx, f.key, name, int64(delta))
+		}
+		return nil
+	})
+	return err
+}
+
+// Frequencies returns the increments of every state in the hash
+func (f *RedisFrequencyStore) Frequencies(ctx context.Context) (map[string]int, error) {
+	values, err := f.client.HGetAll(ctx, f.key).Result()
+	if err != nil {
+		return nil, err
+	}
+	deltas := make(map[string]int, len(values))
+	for name, value := range values {
+		delta, err := strconv.Atoi(value)
+		if err != nil {
+			return nil, fmt.Errorf("frequency of state %q in %s is not an integer: %w", name, f.key, err)
+		}
+		deltas[name] = delta
+	}
+	return deltas, nil
+}
+
+// OpenSQLiteFrequencyDB opens the SQLite database at the path, creating it and its table of
+// frequency increments when missing
+func OpenSQLiteFrequencyDB(path string) (*sql.DB, error) {
+	db, err := sql.Open("sqlite3", path)
+	if err != nil {
+		return nil, err
+	}
+	// SQLite allows one writer at a time
+	db.SetMaxOpenConns(1)
+	if _, err := db.Exec(`CREATE TABLE IF NOT EXISTS state_frequencies (
+		tenant TEXT NOT NULL,
+		name TEXT NOT NULL,
+		delta INTEGER NOT NULL,
+		PRIMARY KEY (tenant, name)
+	)`); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return db, nil
+}
+
+// SQLiteFrequencyStore keeps the frequency increments of a tenant in a SQLite table shared by all
+// tenants
+type SQLiteFrequencyStore struct {
+	db       *sql.DB
+	tenantID string
+}
+
+// sqliteIncrement upserts the increment of a state
+const sqliteIncrement = `INSERT INTO state_frequencies (tenant, name, delta) VALUES (?, ?, ?)
+	ON CONFLICT (tenant, name) DO UPDATE SET delta = delta + excluded.delta`
+
+// NewSQLiteFrequencyStore creates a store keeping the increments of the tenant in the database
+func NewSQLiteFrequencyStore(db *sql.DB, tenantID string) *SQLiteFrequencyStore {
+	return &SQLiteFrequencyStore{db: db, tenantID: tenantID}
+}
+
+// Increment adds the delta to the increment of the named state
+func (f *SQLiteFrequencyStore) Increment(ctx context.Context, name string, delta int) error {
+	_, err := f.db.ExecContext(ctx, sqliteIncrement, f.tenantID, name, delta)
+	return err
+}
+
+// BulkIncrement adds the deltas to the increments of the named states in a single transaction
+func (f *SQLiteFrequencyStore) BulkIncrement(ctx context.Context, deltas map[string]int) error {
+	if len(deltas) == 0 {
+		return nil
+	}
+	tx, err := f.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	stmt, err := tx.PrepareContext(ctx, sqliteIncrement)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+	for name, delta := range deltas {
+		if _, err := stmt.ExecContext(ctx, f.tenantID, name, delta); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// Frequencies returns the increments of every state of the tenant
+func (f *SQLiteFrequencyStore) Frequencies(ctx context.Context) (map[string]int, error) {
+	rows, err := f.db.QueryContext(ctx, `SELECT name, delta FROM state_frequencies WHERE tenant = ?`, f.tenantID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	deltas := make(map[string]int)
+	for rows.Next() {
+		var name string
+		var delta int
+		if err := rows.Scan(&name, &delta); err != nil {
+			return nil, err
+		}
+		deltas[name] = delta
+	}
+	return deltas, rows.Err()
+}
@@ -0,0 +1,36 @@
+package backend
+
+import (
+	"net/http"
+	"strings"
+)
+
+// defaultGraphiQLQuery is preloaded into the playground when no query is given
+const defaultGraphiQLQuery = `# Fetch state suggestions for a search prefix.
+# Set the "search" variable below, e.g. {"search": "New"}
+query States($search: String!) {
+  states(search: $search) {
+    name
+    code
+    frequency
+  }
+}
+
+# List the letters that can follow a prefix
+query Completions {
+  completions(prefix: "New") {
+    isComplete
+    next {
+      char
+      count
+    }
+  }
+}
+`
+
+// isGraphiQLRequest reports whether the request comes from a browser asking for GraphiQL
+func isGraphiQLRequest(r *http.Request) bool {
+	accept := r.Header.Get("Accept")
+	_, raw := r.URL.Query()["raw"]
+	return !raw && !strings.Contains(accept, "application/json") && strings.Contains(accept, "text/html")
+}
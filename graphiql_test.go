@@ -0,0 +1,67 @@
+package backend
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestGraphiQLDefaultQueryConfig(t *testing.T) {
+	// restored once the test ends
+	t.Setenv("GRAPHIQL_DEFAULT_QUERY", "")
+	os.Unsetenv("GRAPHIQL_DEFAULT_QUERY")
+	if query := loadConfig().GraphiQLDefaultQuery; query != defaultGraphiQLQuery {
+		t.Errorf("default query without GRAPHIQL_DEFAULT_QUERY = %q, want the built-in one", query)
+	}
+	t.Setenv("GRAPHIQL_DEFAULT_QUERY", "query Mine { states(search: \"Tex\") { name } }")
+	if query := loadConfig().GraphiQLDefaultQuery; query != "query Mine { states(search: \"Tex\") { name } }" {
+		t.Errorf("default query = %q, want the configured one", query)
+	}
+	t.Setenv("GRAPHIQL_DEFAULT_QUERY", "")
+	if query := loadConfig().GraphiQLDefaultQuery; query != "" {
+		t.Errorf("default query set to an empty string = %q, want none", query)
+	}
+}
+
+func TestPlaygroundPreloadsDefaultQuery(t *testing.T) {
+	for _, test := range []struct {
+		query     string
+		contains  string
+		variables bool
+	}{
+		{defaultGraphiQLQuery, "query States($search: String!)", true},
+		{"query Mine { states(search: $prefix) { name } }", "query Mine { states(search: $prefix) { name } }", false},
+	} {
+		recorder := httptest.NewRecorder()
+		playgroundHandler("/graphql", test.query).ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, playgroundPath, nil))
+		body := recorder.Body.String()
+		if recorder.Code != http.StatusOK || !strings.Contains(body, test.contains) {
+			t.Errorf("playground for %q answered %d without the query:\n%s", test.query, recorder.Code, body)
+		}
+		if variables := strings.Contains(body, `|| "{\"search\": \"New\"}"`); variables != test.variables {
+			t.Errorf("playground for %q preloads the search variable: %t, want %t", test.query, variables, test.variables)
+		}
+	}
+}
+
+func TestIsGraphiQLRequest(t *testing.T) {
+	for _, test := range []struct {
+		path   string
+		accept string
+		want   bool
+	}{
+		{"/graphql", "text/html,application/xhtml+xml,*/*;q=0.8", true},
+		{"/graphql?raw", "text/html", false},
+		{"/graphql", "application/json", false},
+		{"/graphql", "application/json, text/html", false},
+		{"/graphql", "", false},
+	} {
+		r := httptest.NewRequest(http.MethodGet, test.path, nil)
+		r.Header.Set("Accept", test.accept)
+		if got := isGraphiQLRequest(r); got != test.want {
+			t.Errorf("isGraphiQLRequest(%s, Accept: %q) = %t, want %t", test.path, test.accept, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,23 @@
+package backend
+
+import (
+	"fmt"
+	"strings"
+)
+
+// reservedPaths are the paths served besides GraphQL; paths ending in a slash cover their subtree
+var reservedPaths = []string{"/states/", "/admin/reload", "/admin/reload/", "/metrics", playgroundPath, readyPath, promotePath, trieDumpPath}
+
+// validateGraphQLPath checks that the GraphQL API can be mounted at the path without shadowing or
+// being shadowed by the other endpoints
+func validateGraphQLPath(path string) error {
+	if !strings.HasPrefix(path, "/") {
+		return fmt.Errorf("GraphQL path %q must start with a slash", path)
+	}
+	for _, reserved := range reservedPaths {
+		if path == reserved || strings.HasSuffix(reserved, "/") && strings.HasPrefix(path, reserved) {
+			return fmt.Errorf("GraphQL path %q collides with %s", path, reserved)
+		}
+	}
+	return nil
+}
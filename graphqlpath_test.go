@@ -0,0 +1,47 @@
+package backend
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestValidateGraphQLPath(t *testing.T) {
+	for path, valid := range map[string]bool{
+		"/graphql":       true,
+		"/api/graphql":   true,
+		"api/graphql":    false,
+		"/metrics":       false,
+		"/states/":       false,
+		"/states/search": false,
+		"/admin/reload":  false,
+		playgroundPath:   false,
+	} {
+		if err := validateGraphQLPath(path); (err == nil) != valid {
+			t.Errorf("validateGraphQLPath(%q) = %v, want valid %t", path, err, valid)
+		}
+	}
+}
+
+func TestGraphQLAtCustomPath(t *testing.T) {
+	newTestStore(t, State{Name: "Texas", Code: "TX", Enabled: true, Kind: KindState})
+	previous := config.GraphQLPath
+	t.Cleanup(func() { config.GraphQLPath = previous })
+	config.GraphQLPath = "/api/graphql"
+	mux := http.NewServeMux()
+	routeAPI(mux)
+
+	for path, status := range map[string]int{"/api/graphql": http.StatusOK, "/graphql": http.StatusNotFound} {
+		request := httptest.NewRequest(http.MethodPost, path, strings.NewReader(`{"query": "{ states(search: \"T\") { name } }"}`))
+		request.Header.Set("Content-Type", "application/json")
+		recorder := httptest.NewRecorder()
+		mux.ServeHTTP(recorder, request)
+		if recorder.Code != status {
+			t.Errorf("POST %s = %d, want %d", path, recorder.Code, status)
+		}
+		if status == http.StatusOK && !strings.Contains(recorder.Body.String(), `"Texas"`) {
+			t.Errorf("POST %s answered %s, want Texas", path, recorder.Body)
+		}
+	}
+}
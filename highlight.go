@@ -0,0 +1,82 @@
+package backend
+
+import (
+	"strings"
+
+	"github.com/graphql-go/graphql"
+)
+
+const (
+	// defaultHighlightPre is inserted before the matched prefix when no delimiter is given
+	defaultHighlightPre = "<b>"
+	// defaultHighlightPost is inserted after the matched prefix when no delimiter is given
+	defaultHighlightPost = "</b>"
+)
+
+// HighlightOptions holds the delimiters wrapped around the matched prefix
+type HighlightOptions struct {
+	Pre  string
+	Post string
+}
+
+// parseHighlightOptions reads the highlight argument, returning nil when highlighting was not requested
+func parseHighlightOptions(arg interface{}) *HighlightOptions {
+	values, ok := arg.(map[string]interface{})
+	if !ok {
+		return nil
+	}
+	opts := &HighlightOptions{Pre: defaultHighlightPre, Post: defaultHighlightPost}
+	if pre, ok := values["pre"].(string); ok {
+		opts.Pre = pre
+	}
+	if post, ok := values["post"].(string); ok {
+		opts.Post = post
+	}
+	return opts
+}
+
+// highlightName wraps the matched prefix of the name with the delimiters. Backslashes and
+// delimiters already present in the name are escaped with a backslash.
+func highlightName(name, prefix string, opts *HighlightOptions) string {
+	runes := []rune(name)
+	n := len([]rune(prefix))
+	if n > len(runes) {
+		n = len(runes)
+	}
+
+	oldnew := []string{`\`, `\\`}
+	for _, delimiter := range []string{opts.Pre, opts.Post} {
+		if delimiter != "" {
+			oldnew = append(oldnew, delimiter, `\`+delimiter)
+		}
+	}
+	escaper := strings.NewReplacer(oldnew...)
+
+	return opts.Pre + escaper.Replace(string(runes[:n])) + opts.Post + escaper.Replace(string(runes[n:]))
+}
+
+// attachHighlights attaches the highlighted name to each result, highlighting the display name when set
+func attachHighlights(results []*StateResult, prefix string, opts *HighlightOptions) {
+	for _, result := range results {
+		name := result.Name
+		if result.DisplayName != "" {
+			name = result.DisplayName
+		}
+		result.HighlightedName = highlightName(name, prefix, opts)
+	}
+}
+
+// Define the GraphQL highlight input type
+var highlightInputType = graphql.NewInputObject(graphql.InputObjectConfig{
+	Name: "HighlightInput",
+	Fields: graphql.InputObjectConfigFieldMap{
+		"pre": &graphql.InputObjectFieldConfig{
+			Type:         graphql.String,
+			DefaultValue: defaultHighlightPre,
+		},
+		"post": &graphql.InputObjectFieldConfig{
+			Type:         graphql.String,
+			DefaultValue: defaultHighlightPost,
+		},
+	},
+})
@@ -0,0 +1,228 @@
+package backend
+
+import (
+	"math"
+
+	"github.com/graphql-go/graphql"
+)
+
+const (
+	// histogramLinear splits the frequency range into buckets of equal width
+	histogramLinear = "LINEAR"
+	// histogramLog splits the frequency range into buckets of equal width on a log scale, for
+	// frequencies skewed towards a few popular states
+	histogramLog = "LOG"
+	// defaultHistogramBuckets is the number of buckets of a histogram when none is requested
+	defaultHistogramBuckets = 10
+	// maxHistogramBuckets caps the number of buckets of a histogram
+	maxHistogramBuckets = 100
+)
+
+// HistogramBucket counts the states whose frequency lies in [Lower, Upper), the last bucket
+// including its upper bound
+type HistogramBucket struct {
+	Lower float64 `json:"lower"`
+	Upper float64 `json:"upper"`
+	Count int     `json:"count"`
+}
+
+// FrequencyHistogram is the distribution of the frequencies of a store's states
+type FrequencyHistogram struct {
+	Scale   string            `json:"scale"`
+	Buckets []HistogramBucket `json:"buckets"`
+	Count   int               `json:"count"`
+	Min     int               `json:"min"`
+	Max     int               `json:"max"`
+	Mean    float64           `json:"mean"`
+	Median  float64           `json:"median"`
+}
+
+// selectKth returns the k-th smallest of the values, reordering them, in linear time on average
+func selectKth(values []int, k int) int {
+	low, high := 0, len(values)-1
+	for low < high {
+		pivot := values[(low+high)/2]
+		i, j := low, high
+		for i <= j {
+			for values[i] < pivot {
+				i++
+			}
+			for values[j] > pivot {
+				j--
+			}
+			if i <= j {
+				values[i], values[j] = values[j], values[i]
+				i++
+				j--
+			}
+		}
+		switch {
+		case k <= j:
+			high = j
+		case k >= i:
+			low = i
+		default:
+			return values[k]
+		}
+	}
+	return values[k]
+}
+
+// histogramScale maps frequencies onto the axis the buckets are of equal width on, and back
+func histogramScale(scale string) (func(float64) float64, func(float64) float64) {
+	if scale == histogramLog {
+		return math.Log1p, math.Expm1
+	}
+	identity := func(value float64) float64 { return value }
+	return identity, identity
+}
+
+// frequencyHistogram counts the frequencies of the store's states, disabled ones included, in the
+// buckets of the scale. It reads the store's flat list of states under its write lock, so no
+// increment lands mid-scan, and never touches the repository.
+func frequencyHistogram(s *TrieStore, buckets int, scale string) *FrequencyHistogram {
+	s.writeMu.Lock()
+	states := s.States()
+	frequencies := make([]int, len(states))
+	for i, state := range states {
+		frequencies[i] = int(state.loadFrequency())
+	}
+	s.writeMu.Unlock()
+
+	histogram := &FrequencyHistogram{Scale: scale, Buckets: []HistogramBucket{}, Count: len(frequencies)}
+	if len(frequencies) == 0 {
+		return histogram
+	}
+	total := 0
+	histogram.Min, histogram.Max = frequencies[0], frequencies[0]
+	for _, frequency := range frequencies {
+		total += frequency
+		if frequency < histogram.Min {
+			histogram.Min = frequency
+		}
+		if frequency > histogram.Max {
+			histogram.Max = frequency
+		}
+	}
+	histogram.Mean = float64(total) / float64(len(frequencies))
+
+	toAxis, fromAxis := histogramScale(scale)
+	low, high := toAxis(float64(histogram.Min)), toAxis(float64(histogram.Max))
+	width := (high - low) / float64(buckets)
+	for i := 0; i < buckets; i++ {
+		histogram.Buckets = append(histogram.Buckets, HistogramBucket{
+			Lower: fromAxis(low + float64(i)*width),
+			Upper: fromAxis(low + float64(i+1)*width),
+		})
+	}
+	histogram.Buckets[buckets-1].Upper = float64(histogram.Max)
+	for _, frequency := range frequencies {
+		i := buckets - 1
+		if width > 0 {
+			i = int((toAxis(float64(frequency)) - low) / width)
+		}
+		if i >= buckets {
+			i = buckets - 1
+		}
+		histogram.Buckets[i].Count++
+	}
+
+	middle := len(frequencies) / 2
+	histogram.Median = float64(selectKth(frequencies, middle))
+	if len(frequencies)%2 == 0 {
+		histogram.Median = (histogram.Median + float64(selectKth(frequencies, middle-1))) / 2
+	}
+	return histogram
+}
+
+// Define the GraphQL histogram scale enum
+var histogramScaleEnum = graphql.NewEnum(graphql.EnumConfig{
+	Name: "HistogramScale",
+	Values: graphql.EnumValueConfigMap{
+		histogramLinear: &graphql.EnumValueConfig{
+			Value:       histogramLinear,
+			Description: "Buckets of equal width",
+		},
+		histogramLog: &graphql.EnumValueConfig{
+			Value:       histogramLog,
+			Description: "Buckets of equal width on a log scale, narrow for rare states and wide for popular ones",
+		},
+	},
+})
+
+// Define the GraphQL histogram bucket type
+var histogramBucketType = graphql.NewObject(graphql.ObjectConfig{
+	Name: "HistogramBucket",
+	Fields: graphql.Fields{
+		"lower": &graphql.Field{
+			Type:        graphql.Float,
+			Description: "Lowest frequency of the bucket, inclusive",
+		},
+		"upper": &graphql.Field{
+			Type:        graphql.Float,
+			Description: "Highest frequency of the bucket, exclusive except for the last bucket",
+		},
+		"count": &graphql.Field{
+			Type: graphql.Int,
+		},
+	},
+})
+
+// Define the GraphQL frequency histogram type
+var frequencyHistogramType = graphql.NewObject(graphql.ObjectConfig{
+	Name: "FrequencyHistogram",
+	Fields: graphql.Fields{
+		"scale": &graphql.Field{
+			Type: histogramScaleEnum,
+		},
+		"buckets": &graphql.Field{
+			Type: graphql.NewList(histogramBucketType),
+		},
+		"count": &graphql.Field{
+			Type: graphql.Int,
+		},
+		"min": &graphql.Field{
+			Type: graphql.Int,
+		},
+		"max": &graphql.Field{
+			Type: graphql.Int,
+		},
+		"mean": &graphql.Field{
+			Type: graphql.Float,
+		},
+		"median": &graphql.Field{
+			Type: graphql.Float,
+		},
+	},
+})
+
+// frequencyHistogramField shows admins how the tenant's frequencies are distributed before they
+// reset or rescale them
+var frequencyHistogramField = &graphql.Field{
+	Type: frequencyHistogramType,
+	Args: graphql.FieldConfigArgument{
+		"buckets": &graphql.ArgumentConfig{
+			Type:         graphql.Int,
+			DefaultValue: defaultHistogramBuckets,
+		},
+		"scale": &graphql.ArgumentConfig{
+			Type:         histogramScaleEnum,
+			DefaultValue: histogramLinear,
+		},
+	},
+	Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+		if _, err := requireAdmin(p.Context); err != nil {
+			return nil, err
+		}
+		buckets, _ := p.Args["buckets"].(int)
+		if buckets < 1 || buckets > maxHistogramBuckets {
+			return nil, invalidInput("buckets must be between 1 and %d", maxHistogramBuckets)
+		}
+		scale, _ := p.Args["scale"].(string)
+		tenantStore, err := storeFor(p.Context)
+		if err != nil {
+			return nil, err
+		}
+		return frequencyHistogram(tenantStore, buckets, scale), nil
+	},
+}
@@ -0,0 +1,83 @@
+package backend
+
+import (
+	"context"
+	"math"
+	"strings"
+	"testing"
+)
+
+// histogramStore loads states with the frequencies
+func histogramStore(t *testing.T, frequencies ...int64) *TrieStore {
+	t.Helper()
+	states := make([]State, len(frequencies))
+	for i, frequency := range frequencies {
+		states[i] = State{Name: "State " + string(rune('A'+i)), Enabled: i%2 == 0, Kind: KindState, Frequency: frequency}
+	}
+	return newTestStore(t, states...)
+}
+
+func TestFrequencyHistogram(t *testing.T) {
+	// disabled states are counted too
+	s := histogramStore(t, 7, 0, 99, 1, 3)
+	tests := []struct {
+		scale   string
+		buckets []HistogramBucket
+	}{
+		{histogramLinear, []HistogramBucket{{0, 24.75, 4}, {24.75, 49.5, 0}, {49.5, 74.25, 0}, {74.25, 99, 1}}},
+		// on a log scale the bucket bounds grow as powers of 100^(1/4), minus one
+		{histogramLog, []HistogramBucket{{0, math.Sqrt(10) - 1, 2}, {math.Sqrt(10) - 1, 9, 2}, {9, math.Pow(10, 1.5) - 1, 0}, {math.Pow(10, 1.5) - 1, 99, 1}}},
+	}
+	for _, test := range tests {
+		histogram := frequencyHistogram(s, 4, test.scale)
+		if histogram.Count != 5 || histogram.Min != 0 || histogram.Max != 99 || histogram.Mean != 22 || histogram.Median != 3 {
+			t.Errorf("%s histogram summary = %+v, want 5 states from 0 to 99 with a mean of 22 and a median of 3", test.scale, histogram)
+		}
+		if len(histogram.Buckets) != len(test.buckets) {
+			t.Fatalf("%s histogram has %d buckets, want %d", test.scale, len(histogram.Buckets), len(test.buckets))
+		}
+		for i, bucket := range histogram.Buckets {
+			want := test.buckets[i]
+			if bucket.Count != want.Count || math.Abs(bucket.Lower-want.Lower) > 1e-9 || math.Abs(bucket.Upper-want.Upper) > 1e-9 {
+				t.Errorf("%s bucket %d = %+v, want %+v", test.scale, i, bucket, want)
+			}
+		}
+	}
+}
+
+func TestFrequencyHistogramEdgeCases(t *testing.T) {
+	// the median of an even number of states is the mean of the middle two
+	if histogram := frequencyHistogram(histogramStore(t, 8, 2, 4, 100), 2, histogramLinear); histogram.Median != 6 {
+		t.Errorf("median of 2, 4, 8 and 100 = %v, want 6", histogram.Median)
+	}
+	// equal frequencies all land in the last bucket
+	histogram := frequencyHistogram(histogramStore(t, 5, 5, 5), 3, histogramLog)
+	if count := histogram.Buckets[2].Count; count != 3 {
+		t.Errorf("last bucket of equal frequencies counts %d states, want all 3", count)
+	}
+	if histogram := frequencyHistogram(histogramStore(t), 3, histogramLinear); histogram.Count != 0 || len(histogram.Buckets) != 0 {
+		t.Errorf("histogram without states = %+v, want no buckets", histogram)
+	}
+}
+
+func TestFrequencyHistogramQuery(t *testing.T) {
+	histogramStore(t, 1, 2, 3)
+	query := `{ frequencyHistogram(buckets: 2, scale: LOG) { scale count buckets { count } } }`
+	if result := runGraphQL(t, context.Background(), query); len(result.Errors) != 1 || !strings.Contains(result.Errors[0].Message, errUnauthorized.Error()) {
+		t.Errorf("frequencyHistogram without an admin = %v, want %v", result.Errors, errUnauthorized)
+	}
+	result := runGraphQL(t, asAdmin(context.Background(), "ops"), query)
+	if len(result.Errors) > 0 {
+		t.Fatal(result.Errors)
+	}
+	histogram := result.Data.(map[string]interface{})["frequencyHistogram"].(map[string]interface{})
+	if histogram["scale"] != histogramLog || histogram["count"] != 3 || len(histogram["buckets"].([]interface{})) != 2 {
+		t.Errorf("frequencyHistogram = %v, want 3 states in 2 log buckets", histogram)
+	}
+	for _, buckets := range []string{"0", "101"} {
+		result := runGraphQL(t, asAdmin(context.Background(), "ops"), `{ frequencyHistogram(buckets: `+buckets+`) { count } }`)
+		if len(result.Errors) != 1 || result.Errors[0].Extensions["code"] != invalidInputCode {
+			t.Errorf("frequencyHistogram with %s buckets = %v, want an %s error", buckets, result.Errors, invalidInputCode)
+		}
+	}
+}
@@ -0,0 +1,258 @@
+package backend
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+
+	"github.com/graphql-go/graphql"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	// namespaceNotFoundCode is the MongoDB error code for dropping the indexes of a missing collection
+	namespaceNotFoundCode = 26
+	// maxReportedDuplicates caps the duplicate keys reported for an index that cannot be created
+	maxReportedDuplicates = 20
+)
+
+// serviceIndex is an index the service relies on
+type serviceIndex struct {
+	Collection string
+	Model      mongo.IndexModel
+}
+
+// serviceIndexes are the indexes created on startup, by cmd/seed and by rebuildIndexes, in order
+var serviceIndexes = []serviceIndex{
+	{Collection: "frequencyRollups", Model: mongo.IndexModel{
+		Keys:    bson.D{{Key: "stateCode", Value: 1}, {Key: "date", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	}},
+	{Collection: "states", Model: stateNameIndex},
+}
+
+// stateNameIndex is the unique index on the names of a states collection, which state updates are
+// keyed by
+var stateNameIndex = mongo.IndexModel{
+	Keys:    bson.D{{Key: "name", Value: 1}},
+	Options: options.Index().SetUnique(true),
+}
+
+// ensureIndexes creates the MongoDB indexes the service relies on
+func ensureIndexes(ctx context.Context) error {
+	return EnsureIndexes(ctx, client.Database(config.MongoDB))
+}
+
+// EnsureIndexes creates the indexes of the database's rollups and default states collection
+func EnsureIndexes(ctx context.Context, db *mongo.Database) error {
+	for _, index := range serviceIndexes {
+		if _, err := db.Collection(index.Collection).Indexes().CreateOne(ctx, index.Model); err != nil {
+			return fmt.Errorf("creating index on %s: %w", index.Collection, err)
+		}
+	}
+	return nil
+}
+
+// EnsureStateIndexes creates the unique index on the names of a states collection
+func EnsureStateIndexes(ctx context.Context, collection *mongo.Collection) error {
+	_, err := collection.Indexes().CreateOne(ctx, stateNameIndex)
+	return err
+}
+
+// indexView is the part of mongo.IndexView used to rebuild indexes
+type indexView interface {
+	CreateOne(ctx context.Context, model mongo.IndexModel, opts ...*options.CreateIndexesOptions) (string, error)
+	DropAll(ctx context.Context, opts ...*options.DropIndexesOptions) (bson.Raw, error)
+}
+
+// indexedDatabase is the database whose indexes are rebuilt
+type indexedDatabase interface {
+	// Indexes returns the index view of the collection
+	Indexes(collection string) indexView
+	// Duplicates returns the values of the keys held by more than one document of the collection
+	Duplicates(ctx context.Context, collection string, keys bson.D) ([]string, error)
+}
+
+// mongoIndexedDatabase rebuilds the indexes of a MongoDB database
+type mongoIndexedDatabase struct {
+	db *mongo.Database
+}
+
+// Indexes returns the index view of the collection
+func (d mongoIndexedDatabase) Indexes(collection string) indexView {
+	return d.db.Collection(collection).Indexes()
+}
+
+// Duplicates groups the documents of the collection by the keys and returns the groups of more than
+// one document, at most maxReportedDuplicates
+func (d mongoIndexedDatabase) Duplicates(ctx context.Context, collection string, keys bson.D) ([]string, error) {
+	group := bson.M{}
+	for _, key := range keys {
+		group[key.Key] = "$" + key.Key
+	}
+	pipeline := mongo.Pipeline{
+		{{Key: "$group", Value: bson.M{"_id": group, "count": bson.M{"$sum": 1}}}},
+		{{Key: "$match", Value: bson.M{"count": bson.M{"$gt": 1}}}},
+		{{Key: "$limit", Value: maxReportedDuplicates}},
+	}
+	cursor, err := d.db.Collection(collection).Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var groups []struct {
+		ID    bson.M `bson:"_id"`
+		Count int    `bson:"count"`
+	}
+	if err := cursor.All(ctx, &groups); err != nil {
+		return nil, err
+	}
+	duplicates := make([]string, 0, len(groups))
+	for _, group := range groups {
+		values := make([]string, 0, len(keys))
+		for _, key := range keys {
+			values = append(values, fmt.Sprint(group.ID[key.Key]))
+		}
+		duplicates = append(duplicates, fmt.Sprintf("%s (%d documents)", strings.Join(values, "/"), group.Count))
+	}
+	return duplicates, nil
+}
+
+// IndexResult is the outcome of rebuilding one index
+type IndexResult struct {
+	Collection string   `json:"collection"`
+	Index      string   `json:"index"`
+	Dropped    bool     `json:"dropped"`
+	Created    bool     `json:"created"`
+	Error      string   `json:"error,omitempty"`
+	Duplicates []string `json:"duplicates,omitempty"`
+}
+
+// indexRebuildMu keeps two rebuilds from dropping each other's new indexes
+var indexRebuildMu sync.Mutex
+
+// indexKeysName names an index by its keys, the way MongoDB names it by default
+func indexKeysName(keys bson.D) string {
+	parts := make([]string, 0, len(keys))
+	for _, key := range keys {
+		parts = append(parts, fmt.Sprintf("%s_%v", key.Key, key.Value))
+	}
+	return strings.Join(parts, "_")
+}
+
+// rebuildIndexes creates the service indexes again, first dropping every index of their collections
+// but _id when drop is set. Unique indexes failing on duplicate keys are reported with the
+// duplicated values. Searches are served from the trie, so they are unaffected while indexes are
+// missing; MongoDB builds indexes without blocking writes.
+func rebuildIndexes(ctx context.Context, db indexedDatabase, drop bool) []IndexResult {
+	indexRebuildMu.Lock()
+	defer indexRebuildMu.Unlock()
+
+	dropped := make(map[string]error)
+	if drop {
+		for _, index := range serviceIndexes {
+			if _, ok := dropped[index.Collection]; ok {
+				continue
+			}
+			_, err := db.Indexes(index.Collection).DropAll(ctx)
+			var commandErr mongo.CommandError
+			if errors.As(err, &commandErr) && commandErr.Code == namespaceNotFoundCode {
+				err = nil
+			}
+			dropped[index.Collection] = err
+		}
+	}
+
+	results := make([]IndexResult, 0, len(serviceIndexes))
+	for _, index := range serviceIndexes {
+		keys := index.Model.Keys.(bson.D)
+		result := IndexResult{Collection: index.Collection, Index: indexKeysName(keys)}
+		if err, ok := dropped[index.Collection]; ok {
+			if err != nil {
+				result.Error = fmt.Sprintf("dropping indexes: %v", err)
+				results = append(results, result)
+				continue
+			}
+			result.Dropped = true
+		}
+		name, err := db.Indexes(index.Collection).CreateOne(ctx, index.Model)
+		if err != nil {
+			result.Error = err.Error()
+			if mongo.IsDuplicateKeyError(err) {
+				duplicates, dupErr := db.Duplicates(ctx, index.Collection, keys)
+				if dupErr != nil {
+					result.Error += fmt.Sprintf("; finding duplicates: %v", dupErr)
+				}
+				result.Duplicates = duplicates
+			}
+		} else {
+			result.Index = name
+			result.Created = true
+		}
+		results = append(results, result)
+	}
+	return results
+}
+
+// Define the GraphQL index result type
+var indexResultType = graphql.NewObject(graphql.ObjectConfig{
+	Name: "IndexResult",
+	Fields: graphql.Fields{
+		"collection": &graphql.Field{
+			Type: graphql.String,
+		},
+		"index": &graphql.Field{
+			Type: graphql.String,
+		},
+		"dropped": &graphql.Field{
+			Type:        graphql.Boolean,
+			Description: "Whether the indexes of the collection were dropped first",
+		},
+		"created": &graphql.Field{
+			Type: graphql.Boolean,
+		},
+		"error": &graphql.Field{
+			Type: graphql.String,
+		},
+		"duplicates": &graphql.Field{
+			Type:        graphql.NewList(graphql.String),
+			Description: "Values held by more than one document, when a unique index failed on them",
+		},
+	},
+})
+
+// rebuildIndexesField recreates the MongoDB indexes without a redeploy, e.g. after duplicate state
+// names were fixed
+var rebuildIndexesField = &graphql.Field{
+	Type: graphql.NewList(indexResultType),
+	Args: graphql.FieldConfigArgument{
+		"drop": &graphql.ArgumentConfig{
+			Type:         graphql.Boolean,
+			Description:  "Drop every index of the collections but _id before creating them",
+			DefaultValue: false,
+		},
+	},
+	Resolve: audited("rebuildIndexes", nil, func(p graphql.ResolveParams) (interface{}, error) {
+		actor, err := requireAdmin(p.Context)
+		if err != nil {
+			return nil, err
+		}
+		drop, _ := p.Args["drop"].(bool)
+		results := rebuildIndexes(p.Context, mongoIndexedDatabase{db: client.Database(config.MongoDB)}, drop)
+		failed := 0
+		for _, result := range results {
+			if result.Error != "" {
+				failed++
+				log.Printf("Error rebuilding index %s on %s for %s: %s", result.Index, result.Collection, actor, result.Error)
+			}
+		}
+		log.Printf("Rebuilt %d indexes, %d failed, for %s", len(results)-failed, failed, actor)
+		return results, nil
+	}),
+}
@@ -0,0 +1,96 @@
+package backend
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// fakeIndexedDatabase records the index operations of a rebuild, failing those it is told to
+type fakeIndexedDatabase struct {
+	calls      []string
+	dropErrs   map[string]error
+	createErrs map[string]error
+	duplicates []string
+}
+
+// fakeIndexView is the index view of one collection of a fakeIndexedDatabase
+type fakeIndexView struct {
+	db         *fakeIndexedDatabase
+	collection string
+}
+
+func (d *fakeIndexedDatabase) Indexes(collection string) indexView {
+	return fakeIndexView{db: d, collection: collection}
+}
+
+func (d *fakeIndexedDatabase) Duplicates(ctx context.Context, collection string, keys bson.D) ([]string, error) {
+	d.calls = append(d.calls, "duplicates "+collection+" "+indexKeysName(keys))
+	return d.duplicates, nil
+}
+
+func (v fakeIndexView) CreateOne(ctx context.Context, model mongo.IndexModel, opts ...*options.CreateIndexesOptions) (string, error) {
+	name := indexKeysName(model.Keys.(bson.D))
+	v.db.calls = append(v.db.calls, "create "+v.collection+" "+name)
+	return name, v.db.createErrs[v.collection]
+}
+
+func (v fakeIndexView) DropAll(ctx context.Context, opts ...*options.DropIndexesOptions) (bson.Raw, error) {
+	v.db.calls = append(v.db.calls, "drop "+v.collection)
+	return nil, v.db.dropErrs[v.collection]
+}
+
+func TestRebuildIndexes(t *testing.T) {
+	db := &fakeIndexedDatabase{}
+	results := rebuildIndexes(context.Background(), db, false)
+	if want := []string{"create frequencyRollups stateCode_1_date_1", "create states name_1"}; !reflect.DeepEqual(db.calls, want) {
+		t.Errorf("rebuilding without dropping called %q, want %q", db.calls, want)
+	}
+	for _, result := range results {
+		if !result.Created || result.Dropped || result.Error != "" {
+			t.Errorf("result of rebuilding %s without dropping = %+v", result.Index, result)
+		}
+	}
+
+	// collections are dropped once, before any index is created; missing collections are no error
+	db = &fakeIndexedDatabase{dropErrs: map[string]error{"frequencyRollups": mongo.CommandError{Code: namespaceNotFoundCode}}}
+	results = rebuildIndexes(context.Background(), db, true)
+	want := []string{"drop frequencyRollups", "drop states", "create frequencyRollups stateCode_1_date_1", "create states name_1"}
+	if !reflect.DeepEqual(db.calls, want) {
+		t.Errorf("rebuilding with drop called %q, want %q", db.calls, want)
+	}
+	for _, result := range results {
+		if !result.Created || !result.Dropped || result.Error != "" {
+			t.Errorf("result of rebuilding %s with drop = %+v", result.Index, result)
+		}
+	}
+}
+
+func TestRebuildIndexesErrors(t *testing.T) {
+	db := &fakeIndexedDatabase{
+		dropErrs:   map[string]error{"frequencyRollups": errors.New("not authorized")},
+		createErrs: map[string]error{"states": mongo.CommandError{Code: 11000, Message: "E11000 duplicate key error"}},
+		duplicates: []string{"Texas (2 documents)"},
+	}
+	results := rebuildIndexes(context.Background(), db, true)
+	// an index whose collection could not be dropped is not created again
+	want := []string{"drop frequencyRollups", "drop states", "create states name_1", "duplicates states name_1"}
+	if !reflect.DeepEqual(db.calls, want) {
+		t.Errorf("rebuilding called %q, want %q", db.calls, want)
+	}
+	if len(results) != 2 {
+		t.Fatalf("rebuilding reported %d results, want 2", len(results))
+	}
+	if rollups := results[0]; rollups.Created || rollups.Dropped || rollups.Error != "dropping indexes: not authorized" {
+		t.Errorf("result of the rollups index = %+v, want its drop error", rollups)
+	}
+	states := results[1]
+	if states.Created || !states.Dropped || states.Error != "E11000 duplicate key error" || !reflect.DeepEqual(states.Duplicates, []string{"Texas (2 documents)"}) {
+		t.Errorf("result of the states index = %+v, want the duplicate key error with Texas", states)
+	}
+}
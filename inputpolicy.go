@@ -0,0 +1,108 @@
+package backend
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"unicode"
+	"unicode/utf8"
+)
+
+// InputPolicy restricts the characters and length of inputs holding state names, such as search
+// prefixes and the names of added states
+type InputPolicy struct {
+	// Categories are the Unicode categories allowed, e.g. "L" for letters or "Zs" for spaces
+	Categories []*unicode.RangeTable
+	// Characters are allowed in addition to the categories
+	Characters string
+	// MaxLength is the maximum number of characters, or 0 for no limit
+	MaxLength int
+}
+
+// inputPolicy is the policy inputs are validated with, set on startup
+var inputPolicy = InputPolicy{
+	Categories: []*unicode.RangeTable{unicode.L, unicode.M, unicode.Zs},
+	Characters: "-'.",
+	MaxLength:  100,
+}
+
+// NewInputPolicy creates a policy allowing the named Unicode categories and the given characters
+func NewInputPolicy(categories []string, characters string, maxLength int) (InputPolicy, error) {
+	policy := InputPolicy{Characters: characters, MaxLength: maxLength}
+	for _, name := range categories {
+		table, ok := unicode.Categories[name]
+		if !ok {
+			return InputPolicy{}, fmt.Errorf("unknown Unicode category %q", name)
+		}
+		policy.Categories = append(policy.Categories, table)
+	}
+	return policy, nil
+}
+
+// allows reports whether the policy allows the character
+func (p InputPolicy) allows(r rune) bool {
+	return unicode.IsOneOf(p.Categories, r) || strings.ContainsRune(p.Characters, r)
+}
+
+// Validate returns an INVALID_INPUT error when the text is too long or holds a character the
+// policy does not allow
+func (p InputPolicy) Validate(text string) error {
+	if length := utf8.RuneCountInString(text); p.MaxLength > 0 && length > p.MaxLength {
+		return invalidInput("input must be at most %d characters long, got %d", p.MaxLength, length)
+	}
+	for _, r := range text {
+		if !p.allows(r) {
+			return invalidInput("input must not contain %q", r)
+		}
+	}
+	return nil
+}
+
+// ValidateSearch validates a search prefix, which may also hold wildcards
+func (p InputPolicy) ValidateSearch(prefix string) error {
+	return p.Validate(strings.ReplaceAll(prefix, string(wildcardRune), ""))
+}
+
+type warningsContextKey struct{}
+
+// responseWarnings collects the warnings reported while resolving a GraphQL request
+type responseWarnings struct {
+	mu       sync.Mutex
+	messages []string
+}
+
+// withWarnings returns a context collecting the warnings of a request
+func withWarnings(ctx context.Context) context.Context {
+	return context.WithValue(ctx, warningsContextKey{}, &responseWarnings{})
+}
+
+// addWarning reports a warning in the meta extension of the response
+func addWarning(ctx context.Context, format string, args ...interface{}) {
+	if warnings, ok := ctx.Value(warningsContextKey{}).(*responseWarnings); ok {
+		warnings.mu.Lock()
+		warnings.messages = append(warnings.messages, fmt.Sprintf(format, args...))
+		warnings.mu.Unlock()
+	}
+}
+
+// warningsFromContext returns the warnings reported for the request
+func warningsFromContext(ctx context.Context) []string {
+	warnings, ok := ctx.Value(warningsContextKey{}).(*responseWarnings)
+	if !ok {
+		return nil
+	}
+	warnings.mu.Lock()
+	defer warnings.mu.Unlock()
+	return append([]string(nil), warnings.messages...)
+}
+
+// checkSearchInput reports whether the search prefix passes the input policy. Searches that fail
+// it are answered with no states and a warning rather than an error, so clients can keep typing.
+func checkSearchInput(ctx context.Context, prefix string) bool {
+	if err := inputPolicy.ValidateSearch(prefix); err != nil {
+		addWarning(ctx, "ignored search %q: %v", prefix, err)
+		return false
+	}
+	return true
+}
@@ -0,0 +1,89 @@
+package backend
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+// rejectedInputs are names the default input policy rejects
+var rejectedInputs = []string{
+	"Texas 😀",
+	"'; DROP TABLE states; --",
+	"T*x?s!",
+	strings.Repeat("a", 101),
+}
+
+func TestInputPolicyValidate(t *testing.T) {
+	for _, text := range []string{"Texas", "Québec", "Hawaiʻi", "St. John's", "Baden-Württemberg", strings.Repeat("a", 100)} {
+		if err := inputPolicy.Validate(text); err != nil {
+			t.Errorf("Validate(%q) = %v, want it allowed", text, err)
+		}
+	}
+	for _, text := range rejectedInputs {
+		err := inputPolicy.Validate(text)
+		if inputErr, ok := err.(*inputError); !ok || inputErr.Extensions()["code"] != invalidInputCode {
+			t.Errorf("Validate(%q) = %v, want an %s error", text, err, invalidInputCode)
+		}
+	}
+	// wildcards are only allowed in searches
+	if err := inputPolicy.ValidateSearch("T" + string(wildcardRune) + "xas"); err != nil {
+		t.Errorf("ValidateSearch of a wildcard search = %v", err)
+	}
+}
+
+func TestNewInputPolicy(t *testing.T) {
+	policy, err := NewInputPolicy([]string{"Lu"}, "_", 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for text, allowed := range map[string]bool{"TX": true, "T_X": true, "Tx": false, "TEXA": false} {
+		if err := policy.Validate(text); (err == nil) != allowed {
+			t.Errorf("Validate(%q) under uppercase letters and underscores = %v, want allowed %t", text, err, allowed)
+		}
+	}
+	if _, err := NewInputPolicy([]string{"Letters"}, "", 0); err == nil {
+		t.Error("NewInputPolicy accepted an unknown Unicode category")
+	}
+}
+
+func TestSearchesFailingInputPolicyWarn(t *testing.T) {
+	newTestStore(t, State{Name: "Texas", Code: "TX", Enabled: true, Kind: KindState})
+	for _, search := range rejectedInputs {
+		result := runGraphQL(t, context.Background(), `{ states(search: "`+search+`") { name } }`)
+		if len(result.Errors) > 0 {
+			t.Errorf("searching %q failed: %v, want no states and a warning", search, result.Errors)
+			continue
+		}
+		if states := result.Data.(map[string]interface{})["states"].([]interface{}); len(states) != 0 {
+			t.Errorf("searching %q found %v, want no states", search, states)
+		}
+		meta, ok := result.Extensions[metaExtensionName].(*ResponseMeta)
+		if !ok || len(meta.Warnings) != 1 || !strings.Contains(meta.Warnings[0], "ignored search") {
+			t.Errorf("meta of searching %q = %+v, want the search reported as ignored", search, meta)
+		}
+	}
+}
+
+func TestMutationsFailingInputPolicyAreRejected(t *testing.T) {
+	withUnreachableMongo(t)
+	s := newTestStore(t, State{Name: "Texas", Code: "TX", Enabled: true, Kind: KindState})
+	ctx := asAdmin(context.Background(), "ops")
+	for _, name := range rejectedInputs {
+		for _, mutation := range []string{
+			`mutation { addState(name: "` + name + `", code: "ZZ") { name } }`,
+			`mutation { addTranslation(name: "Texas", locale: "es", translation: "` + name + `") { name } }`,
+		} {
+			result := runGraphQL(t, ctx, mutation)
+			if len(result.Errors) != 1 || result.Errors[0].Extensions["code"] != invalidInputCode {
+				t.Errorf("%s = %v, want an %s error", mutation, result.Errors, invalidInputCode)
+			}
+		}
+	}
+	if count, _ := s.Repository().Count(context.Background()); count != 1 {
+		t.Errorf("repository holds %d states after rejected names, want 1", count)
+	}
+	if state := findState(s.Root(), "Texas"); len(state.Translations) != 0 {
+		t.Errorf("Texas has the translations %q after rejected names", state.Translations)
+	}
+}
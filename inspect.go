@@ -0,0 +1,120 @@
+package backend
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// TrieStats summarizes the shape of a trie
+type TrieStats struct {
+	Nodes            int     `json:"nodes"`
+	States           int     `json:"states"`
+	MaxDepth         int     `json:"maxDepth"`
+	AverageDepth     float64 `json:"averageDepth"`
+	AverageBranching float64 `json:"averageBranching"`
+	TotalFrequency   int64   `json:"totalFrequency"`
+}
+
+// ConnectMongo connects to the MongoDB server configured by MONGO_URI, retrying while it is unreachable
+func ConnectMongo(ctx context.Context) (*mongo.Client, error) {
+	return connectMongo(ctx, config.MongoURI, mongoConnectAttempts, mongoConnectBaseDelay)
+}
+
+// StatesCollection returns the states collection of the database configured by MONGO_DB
+func StatesCollection(client *mongo.Client) *mongo.Collection {
+	return client.Database(config.MongoDB).Collection("states")
+}
+
+// LoadTrie builds a trie from the states of the repository
+func LoadTrie(ctx context.Context, repo StateRepository) (*TrieNode, error) {
+	root := newTrieRoot()
+	if err := loadStatesIntoTrie(ctx, root, repo, nil); err != nil {
+		return nil, err
+	}
+	return root, nil
+}
+
+// SearchStates returns the enabled states matching the prefix sorted by frequency, without
+// updating their frequency
+func SearchStates(root *TrieNode, prefix string) []*State {
+	return searchStates(context.Background(), root, collationKey(prefix))
+}
+
+// AllStates returns every state in the trie sorted by frequency
+func AllStates(root *TrieNode) []*State {
+	states := make([]*State, 0, root.SubtreeCount)
+	collectStates(context.Background(), root, &states)
+	sortStatesByFrequency(states)
+	return states
+}
+
+// ComputeStats walks the trie and summarizes its shape
+func ComputeStats(root *TrieNode) TrieStats {
+	var stats TrieStats
+	depthSum, internal, children := 0, 0, 0
+	var walk func(node *TrieNode, depth int)
+	walk = func(node *TrieNode, depth int) {
+		stats.Nodes++
+		if depth > stats.MaxDepth {
+			stats.MaxDepth = depth
+		}
+		for _, state := range node.States {
+			stats.States++
+			stats.TotalFrequency += state.loadFrequency()
+			depthSum += depth
+		}
+		if len(node.Children) > 0 {
+			internal++
+			children += len(node.Children)
+		}
+		for _, child := range node.Children {
+			walk(child, depth+1)
+		}
+	}
+	walk(root, 0)
+
+	if stats.States > 0 {
+		stats.AverageDepth = float64(depthSum) / float64(stats.States)
+	}
+	if internal > 0 {
+		stats.AverageBranching = float64(children) / float64(internal)
+	}
+	return stats
+}
+
+// WriteDOT writes a Graphviz DOT representation of the trie. Nodes ending a state name are drawn
+// as double circles labeled with their states and frequencies.
+func WriteDOT(w io.Writer, root *TrieNode) error {
+	out := bufio.NewWriter(w)
+	fmt.Fprintln(out, "digraph trie {")
+	fmt.Fprintln(out, "  node [shape=circle];")
+
+	ids := map[*TrieNode]int{root: 0}
+	queue := []*TrieNode{root}
+	fmt.Fprintln(out, `  n0 [label="root"];`)
+	for len(queue) > 0 {
+		node := queue[0]
+		queue = queue[1:]
+		for char, child := range node.Children {
+			id := len(ids)
+			ids[child] = id
+			queue = append(queue, child)
+			if child.IsEnd {
+				label := string(char)
+				for _, state := range child.States {
+					label += fmt.Sprintf("\n%s (%d)", state.Name, state.loadFrequency())
+				}
+				fmt.Fprintf(out, "  n%d [shape=doublecircle, label=%q];\n", id, label)
+			} else {
+				fmt.Fprintf(out, "  n%d [label=%q];\n", id, string(char))
+			}
+			fmt.Fprintf(out, "  n%d -> n%d;\n", ids[node], id)
+		}
+	}
+	fmt.Fprintln(out, "}")
+	return out.Flush()
+}
@@ -0,0 +1,117 @@
+package backend
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/graphql-go/graphql"
+)
+
+const (
+	// KindState is a state of the union
+	KindState = "STATE"
+	// KindTerritory is a territory such as Puerto Rico or Guam
+	KindTerritory = "TERRITORY"
+	// KindDistrict is a federal district such as the District of Columbia
+	KindDistrict = "DISTRICT"
+	// KindProvince is a province
+	KindProvince = "PROVINCE"
+)
+
+// stateKinds lists every kind in the order of their on-disk index
+var stateKinds = []string{KindState, KindTerritory, KindDistrict, KindProvince}
+
+// kindIndex returns the on-disk index of the kind, treating unknown kinds as states
+func kindIndex(kind string) uint32 {
+	for i, known := range stateKinds {
+		if known == kind {
+			return uint32(i)
+		}
+	}
+	return 0
+}
+
+// kindAt returns the kind with the on-disk index, or false if there is none
+func kindAt(index uint32) (string, bool) {
+	if index >= uint32(len(stateKinds)) {
+		return "", false
+	}
+	return stateKinds[index], true
+}
+
+// includeKinds returns a filter keeping only the states of the given kinds
+func includeKinds(kinds []string) stateFilter {
+	included := make(map[string]bool, len(kinds))
+	for _, kind := range kinds {
+		included[kind] = true
+	}
+	return func(state *State) bool {
+		return included[state.Kind]
+	}
+}
+
+// Define the GraphQL state kind enum
+var stateKindEnum = graphql.NewEnum(graphql.EnumConfig{
+	Name: "StateKind",
+	Values: graphql.EnumValueConfigMap{
+		KindState: &graphql.EnumValueConfig{
+			Value:       KindState,
+			Description: "A state",
+		},
+		KindTerritory: &graphql.EnumValueConfig{
+			Value:       KindTerritory,
+			Description: "A territory, such as Puerto Rico or Guam",
+		},
+		KindDistrict: &graphql.EnumValueConfig{
+			Value:       KindDistrict,
+			Description: "A federal district, such as the District of Columbia",
+		},
+		KindProvince: &graphql.EnumValueConfig{
+			Value:       KindProvince,
+			Description: "A province",
+		},
+	},
+})
+
+// setStateKind sets the kind of the named state of the tenant in its repository and trie
+func setStateKind(ctx context.Context, name, kind string) (*State, error) {
+	tenantStore, err := storeFor(ctx)
+	if err != nil {
+		return nil, err
+	}
+	state := findState(tenantStore.Root(), name)
+	if state == nil {
+		return nil, fmt.Errorf("state %q not found", name)
+	}
+
+	if err := tenantStore.Repository().SetKind(ctx, state.Name, kind); err != nil {
+		return nil, err
+	}
+	tenantStore.writeMu.Lock()
+	updated := copyState(state)
+	updated.Kind = kind
+	applyStateChanges(ctx, tenantStore, stateChanges{Updated: []*State{updated}})
+	tenantStore.writeMu.Unlock()
+	log.Printf("Set kind for state: %s, Kind: %s", state.Name, kind)
+	return findState(tenantStore.Root(), state.Name), nil
+}
+
+// setStateKindField changes whether a state is a state, territory, district or province
+var setStateKindField = &graphql.Field{
+	Type: stateType,
+	Args: graphql.FieldConfigArgument{
+		"name": &graphql.ArgumentConfig{
+			Type: graphql.NewNonNull(graphql.String),
+		},
+		"kind": &graphql.ArgumentConfig{
+			Type: graphql.NewNonNull(stateKindEnum),
+		},
+	},
+	Resolve: audited("setStateKind", stateSnapshot, func(p graphql.ResolveParams) (interface{}, error) {
+		if _, err := requireAdmin(p.Context); err != nil {
+			return nil, err
+		}
+		return setStateKind(p.Context, p.Args["name"].(string), p.Args["kind"].(string))
+	}),
+}
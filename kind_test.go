@@ -0,0 +1,37 @@
+package backend
+
+import (
+	"context"
+	"testing"
+)
+
+func TestStatesKindsFilter(t *testing.T) {
+	withUnreachableMongo(t)
+	newTestStore(t,
+		State{Name: "Pennsylvania", Code: "PA", Enabled: true, Kind: KindState, Frequency: 30},
+		State{Name: "Puerto Rico", Code: "PR", Enabled: true, Kind: KindTerritory, Frequency: 20},
+		State{Name: "Prince Edward Island", Code: "PE", Enabled: true, Kind: KindProvince, Frequency: 10},
+		State{Name: "District of Columbia", Code: "DC", Enabled: true, Kind: KindDistrict, Frequency: 20},
+		State{Name: "Delaware", Code: "DE", Enabled: true, Kind: KindState, Frequency: 10},
+	)
+	tests := []struct {
+		args string
+		want string
+	}{
+		// without a filter every kind matches, as before kinds existed
+		{`search: "P"`, `[{"kind":"STATE","name":"Pennsylvania"},{"kind":"TERRITORY","name":"Puerto Rico"},{"kind":"PROVINCE","name":"Prince Edward Island"}]`},
+		{`search: "P", kinds: [STATE]`, `[{"kind":"STATE","name":"Pennsylvania"}]`},
+		{`search: "P", kinds: [TERRITORY, PROVINCE]`, `[{"kind":"TERRITORY","name":"Puerto Rico"},{"kind":"PROVINCE","name":"Prince Edward Island"}]`},
+		{`search: "D", kinds: [STATE]`, `[{"kind":"STATE","name":"Delaware"}]`},
+		{`search: "D", kinds: [DISTRICT]`, `[{"kind":"DISTRICT","name":"District of Columbia"}]`},
+		{`search: "P", kinds: [DISTRICT]`, `[]`},
+	}
+	for _, test := range tests {
+		if got := resolveCodeQuery(t, `{ states(`+test.args+`) { name kind } }`); got != `{"states":`+test.want+`}` {
+			t.Errorf("states(%s) = %s, want %s", test.args, got, test.want)
+		}
+	}
+	if result := runGraphQL(t, context.Background(), `{ states(search: "P", kinds: [COUNTY]) { name } }`); len(result.Errors) == 0 {
+		t.Error("filtering on an unknown kind succeeded")
+	}
+}
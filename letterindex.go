@@ -0,0 +1,100 @@
+package backend
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/graphql-go/graphql"
+)
+
+// LetterCount is a first letter of state names with the number of enabled states starting with it
+type LetterCount struct {
+	Letter string `json:"letter"`
+	Count  int    `json:"count"`
+}
+
+// letterStates returns the enabled states under the node, sorted alphabetically
+func letterStates(node *TrieNode) []*State {
+	var states []*State
+	collectStates(context.Background(), node, &states)
+	states = filterStates(states, []stateFilter{isEnabled})
+	sort.Slice(states, func(i, j int) bool {
+		return states[i].Name < states[j].Name
+	})
+	return states
+}
+
+// statesByLetter returns the enabled states starting with the letter in any case, sorted
+// alphabetically. It reads the first-level children of the case-folded trie.
+func statesByLetter(folded *TrieNode, letter string) []*State {
+	char, _ := utf8.DecodeRuneInString(foldKey(letter))
+	child := folded.Children[char]
+	if child == nil {
+		return []*State{}
+	}
+	return letterStates(child)
+}
+
+// stateIndex returns the first letters with at least one enabled state, in alphabetical order,
+// read from the first-level children of the case-folded trie
+func stateIndex(folded *TrieNode) []LetterCount {
+	index := []LetterCount{}
+	for char, child := range folded.Children {
+		if count := len(letterStates(child)); count > 0 {
+			index = append(index, LetterCount{Letter: strings.ToUpper(string(char)), Count: count})
+		}
+	}
+	sort.Slice(index, func(i, j int) bool {
+		return index[i].Letter < index[j].Letter
+	})
+	return index
+}
+
+// Define the GraphQL letter count type
+var letterCountType = graphql.NewObject(graphql.ObjectConfig{
+	Name: "LetterCount",
+	Fields: graphql.Fields{
+		"letter": &graphql.Field{
+			Type: graphql.String,
+		},
+		"count": &graphql.Field{
+			Type: graphql.Int,
+		},
+	},
+})
+
+// statesByLetterField lists the states starting with a letter for A–Z browsing, without updating
+// their frequency
+var statesByLetterField = &graphql.Field{
+	Type: graphql.NewList(stateType),
+	Args: graphql.FieldConfigArgument{
+		"letter": &graphql.ArgumentConfig{
+			Type: graphql.NewNonNull(graphql.String),
+		},
+	},
+	Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+		letter := p.Args["letter"].(string)
+		if utf8.RuneCountInString(letter) != 1 {
+			return nil, invalidInput("letter %q must be a single character", letter)
+		}
+		tenantStore, err := storeFor(p.Context)
+		if err != nil {
+			return nil, err
+		}
+		return statesByLetter(tenantStore.Folded(), letter), nil
+	},
+}
+
+// stateIndexField lists the letters states start with and how many start with each
+var stateIndexField = &graphql.Field{
+	Type: graphql.NewList(letterCountType),
+	Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+		tenantStore, err := storeFor(p.Context)
+		if err != nil {
+			return nil, err
+		}
+		return stateIndex(tenantStore.Folded()), nil
+	},
+}
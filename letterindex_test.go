@@ -0,0 +1,42 @@
+package backend
+
+import (
+	"context"
+	"testing"
+)
+
+func TestStatesByLetter(t *testing.T) {
+	s := newTestStore(t,
+		State{Name: "Texas", Code: "TX", Enabled: true, Kind: KindState, Frequency: 9},
+		State{Name: "Tennessee", Code: "TN", Enabled: true, Kind: KindState},
+		State{Name: "tlaxcala", Code: "TL", Enabled: true, Kind: KindState},
+		State{Name: "Tabasco", Code: "TB", Enabled: false, Kind: KindState},
+		State{Name: "Utah", Code: "UT", Enabled: true, Kind: KindState},
+	)
+	tests := []struct {
+		query string
+		want  string
+	}{
+		// sorted by name rather than frequency, whatever the case of the argument and the names
+		{`{ statesByLetter(letter: "T") { name } }`, `{"statesByLetter":[{"name":"Tennessee"},{"name":"Texas"},{"name":"tlaxcala"}]}`},
+		{`{ statesByLetter(letter: "t") { name } }`, `{"statesByLetter":[{"name":"Tennessee"},{"name":"Texas"},{"name":"tlaxcala"}]}`},
+		{`{ statesByLetter(letter: "Q") { name } }`, `{"statesByLetter":[]}`},
+		// disabled states are neither listed nor counted
+		{`{ stateIndex { letter count } }`, `{"stateIndex":[{"count":3,"letter":"T"},{"count":1,"letter":"U"}]}`},
+	}
+	for _, test := range tests {
+		if got := resolveCodeQuery(t, test.query); got != test.want {
+			t.Errorf("%s = %s, want %s", test.query, got, test.want)
+		}
+	}
+	if state := findState(s.Root(), "Tennessee"); state.loadFrequency() != 0 {
+		t.Errorf("frequency of Tennessee after listing it = %d, want 0", state.loadFrequency())
+	}
+
+	for _, letter := range []string{"", "Te"} {
+		result := runGraphQL(t, context.Background(), `{ statesByLetter(letter: "`+letter+`") { name } }`)
+		if len(result.Errors) != 1 || result.Errors[0].Extensions["code"] != invalidInputCode {
+			t.Errorf("statesByLetter(%q) = %v, want an %s error", letter, result.Errors, invalidInputCode)
+		}
+	}
+}
@@ -0,0 +1,76 @@
+package backend
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// LimitStep caps the results of searches at least MinLength characters long
+type LimitStep struct {
+	MinLength int
+	Limit     int
+}
+
+// LimitPolicy scales the number of results returned with the length of the search, so short
+// searches matching many states return a small payload. Steps are sorted by MinLength; a policy
+// without steps does not limit results.
+type LimitPolicy struct {
+	Steps []LimitStep
+}
+
+// limitPolicy is the policy search results are limited with, set on startup
+var limitPolicy LimitPolicy
+
+// ParseLimitPolicy parses comma separated minLength:limit steps, e.g. "1:5,3:10,6:25" returns 5
+// results for searches of 1 or 2 characters, 10 for 3 to 5 and 25 from 6 on
+func ParseLimitPolicy(value string) (LimitPolicy, error) {
+	var policy LimitPolicy
+	for _, step := range parseList(value) {
+		parts := strings.SplitN(step, ":", 2)
+		if len(parts) != 2 {
+			return LimitPolicy{}, fmt.Errorf("limit step %q must be minLength:limit", step)
+		}
+		minLength, err := strconv.Atoi(strings.TrimSpace(parts[0]))
+		if err != nil || minLength < 0 {
+			return LimitPolicy{}, fmt.Errorf("limit step %q must have a non-negative minLength", step)
+		}
+		limit, err := strconv.Atoi(strings.TrimSpace(parts[1]))
+		if err != nil || limit <= 0 {
+			return LimitPolicy{}, fmt.Errorf("limit step %q must have a positive limit", step)
+		}
+		policy.Steps = append(policy.Steps, LimitStep{MinLength: minLength, Limit: limit})
+	}
+	sort.Slice(policy.Steps, func(i, j int) bool {
+		return policy.Steps[i].MinLength < policy.Steps[j].MinLength
+	})
+	return policy, nil
+}
+
+// Limit returns the number of results returned for the search, or 0 for no limit. Searches
+// shorter than every step are not limited.
+func (p LimitPolicy) Limit(search string) int {
+	length := utf8.RuneCountInString(search)
+	limit := 0
+	for _, step := range p.Steps {
+		if step.MinLength > length {
+			break
+		}
+		limit = step.Limit
+	}
+	return limit
+}
+
+// resultLimit returns the limit argument when given, falling back to the limit policy
+func resultLimit(arg interface{}, search string) (int, error) {
+	limit, ok := arg.(int)
+	if !ok {
+		return limitPolicy.Limit(search), nil
+	}
+	if limit <= 0 {
+		return 0, invalidInput("limit must be positive")
+	}
+	return limit, nil
+}
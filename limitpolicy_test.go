@@ -0,0 +1,77 @@
+package backend
+
+import "testing"
+
+func TestLimitPolicy(t *testing.T) {
+	policy, err := ParseLimitPolicy("6:25, 1:5,3:10")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for search, want := range map[string]int{
+		"":          0,
+		"T":         5,
+		"Te":        5,
+		"Tex":       10,
+		"Texas":     10,
+		"Texas ":    25,
+		"Québec":    25,
+		"Tennessee": 25,
+	} {
+		if got := policy.Limit(search); got != want {
+			t.Errorf("limit for %q = %d, want %d", search, got, want)
+		}
+	}
+	if limit := (LimitPolicy{}).Limit("T"); limit != 0 {
+		t.Errorf("limit of an empty policy = %d, want none", limit)
+	}
+
+	for _, value := range []string{"1", "1:0", "-1:5", "a:5", "1:b"} {
+		if _, err := ParseLimitPolicy(value); err == nil {
+			t.Errorf("ParseLimitPolicy(%q) accepted an invalid step", value)
+		}
+	}
+}
+
+func TestResultLimit(t *testing.T) {
+	previous := limitPolicy
+	t.Cleanup(func() { limitPolicy = previous })
+	limitPolicy = LimitPolicy{Steps: []LimitStep{{MinLength: 1, Limit: 2}, {MinLength: 3, Limit: 4}}}
+
+	newTestStore(t,
+		State{Name: "Texas", Code: "TX", Enabled: true, Kind: KindState, Frequency: 5},
+		State{Name: "Tennessee", Code: "TN", Enabled: true, Kind: KindState, Frequency: 4},
+		State{Name: "Tamaulipas", Code: "TM", Enabled: true, Kind: KindProvince, Frequency: 3},
+		State{Name: "Tlaxcala", Code: "TL", Enabled: true, Kind: KindProvince, Frequency: 2},
+	)
+	tests := []struct {
+		query string
+		want  string
+	}{
+		{`{ states(search: "T") { name } }`, `{"states":[{"name":"Texas"},{"name":"Tennessee"}]}`},
+		// an explicit limit overrides the policy
+		{`{ states(search: "T", limit: 3) { name } }`, `{"states":[{"name":"Texas"},{"name":"Tennessee"},{"name":"Tamaulipas"}]}`},
+	}
+	for _, test := range tests {
+		if got := resolveCodeQuery(t, test.query); got != test.want {
+			t.Errorf("%s = %s, want %s", test.query, got, test.want)
+		}
+	}
+
+	for _, test := range []struct {
+		arg    interface{}
+		search string
+		want   int
+	}{
+		{nil, "Te", 2},
+		{nil, "Tex", 4},
+		{1, "Tex", 1},
+		{10, "T", 10},
+	} {
+		if limit, err := resultLimit(test.arg, test.search); err != nil || limit != test.want {
+			t.Errorf("resultLimit(%v, %q) = %d, %v, want %d", test.arg, test.search, limit, err, test.want)
+		}
+	}
+	if _, err := resultLimit(0, "T"); err == nil {
+		t.Error("resultLimit accepted a limit of 0")
+	}
+}
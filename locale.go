@@ -0,0 +1,121 @@
+package backend
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"github.com/graphql-go/graphql"
+	"golang.org/x/text/language"
+)
+
+// defaultLocale is the locale of the state names themselves
+const defaultLocale = "en"
+
+type acceptLanguageContextKey struct{}
+
+// LocalizedName returns the name of the state in the locale, falling back to its English name
+func (s *State) LocalizedName(locale string) string {
+	if name, ok := s.Translations[locale]; ok && name != "" {
+		return name
+	}
+	return s.Name
+}
+
+// normalizeLocale lowercases the locale and reduces it to its language, so "es-MX" becomes "es"
+func normalizeLocale(locale string) string {
+	locale = strings.ToLower(strings.TrimSpace(locale))
+	if i := strings.IndexAny(locale, "-_"); i >= 0 {
+		locale = locale[:i]
+	}
+	return locale
+}
+
+// buildLocaleTries builds a trie per translated locale keyed by the localized state names.
+// States without a translation are indexed under their English name.
+func buildLocaleTries(root *TrieNode) map[string]*TrieNode {
+	var states []*State
+	collectStates(context.Background(), root, &states)
+
+	tries := make(map[string]*TrieNode)
+	for _, state := range states {
+		for locale := range state.Translations {
+			if _, ok := tries[locale]; !ok {
+				tries[locale] = newTrieRoot()
+			}
+		}
+	}
+	for locale, trie := range tries {
+		for _, state := range states {
+			insertKey(trie, collationKey(state.LocalizedName(locale)), state)
+		}
+	}
+	return tries
+}
+
+// withAcceptLanguage attaches the languages of the Accept-Language header to the request context
+func withAcceptLanguage(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		header := r.Header.Get("Accept-Language")
+		if header == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+		tags, _, err := language.ParseAcceptLanguage(header)
+		if err != nil || len(tags) == 0 {
+			next.ServeHTTP(w, r)
+			return
+		}
+		languages := make([]string, 0, len(tags))
+		for _, tag := range tags {
+			base, _ := tag.Base()
+			languages = append(languages, base.String())
+		}
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), acceptLanguageContextKey{}, languages)))
+	})
+}
+
+// resolveLocale returns the locale a search uses: the locale argument when given, otherwise the
+// most preferred Accept-Language with translations. The empty string stands for English.
+func resolveLocale(ctx context.Context, s *TrieStore, arg string) string {
+	if arg != "" {
+		if locale := normalizeLocale(arg); locale != defaultLocale && s.Locale(locale) != nil {
+			return locale
+		}
+		return ""
+	}
+	languages, _ := ctx.Value(acceptLanguageContextKey{}).([]string)
+	for _, language := range languages {
+		locale := normalizeLocale(language)
+		if locale == defaultLocale {
+			return ""
+		}
+		if s.Locale(locale) != nil {
+			return locale
+		}
+	}
+	return ""
+}
+
+// attachDisplayNames sets the display name of each result in the locale
+func attachDisplayNames(results []*StateResult, locale string) {
+	for _, result := range results {
+		result.DisplayName = result.LocalizedName(locale)
+	}
+}
+
+// resolveDisplayName resolves the displayName field, using the English name for unwrapped states
+func resolveDisplayName(p graphql.ResolveParams) (interface{}, error) {
+	switch source := p.Source.(type) {
+	case *StateResult:
+		if source.DisplayName != "" {
+			return source.DisplayName, nil
+		}
+		return source.Name, nil
+	case *State:
+		return source.Name, nil
+	case State:
+		return source.Name, nil
+	}
+	return nil, nil
+}
@@ -0,0 +1,92 @@
+package backend
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestLocalizedSearch(t *testing.T) {
+	withUnreachableMongo(t)
+	previousPolicy := collationPolicy
+	defer func() { collationPolicy = previousPolicy }()
+	collationPolicy = CollationCaseFold
+	s := newTestStore(t,
+		State{Name: "New York", Code: "NY", Enabled: true, Kind: KindState, Frequency: 10, Translations: map[string]string{"es": "Nueva York"}},
+		State{Name: "New Mexico", Code: "NM", Enabled: true, Kind: KindState, Translations: map[string]string{"es": "Nuevo México"}},
+		State{Name: "Texas", Code: "TX", Enabled: true, Kind: KindState},
+	)
+	spanish := context.WithValue(context.Background(), acceptLanguageContextKey{}, []string{"fr", "es", "en"})
+	tests := []struct {
+		ctx    context.Context
+		search string
+		locale string
+		want   []string
+	}{
+		{context.Background(), "nuev", "es", []string{"New York: Nueva York", "New Mexico: Nuevo México"}},
+		{context.Background(), "nueva", "es-MX", []string{"New York: Nueva York"}},
+		// English names do not match in Spanish once translated
+		{context.Background(), "new", "es", nil},
+		// states without a translation match and display their English name
+		{context.Background(), "tex", "es", []string{"Texas: Texas"}},
+		// locales without translations fall back to English
+		{context.Background(), "new y", "fr", []string{"New York: New York"}},
+		{context.Background(), "nuev", "en", nil},
+		// without a locale argument the preferred Accept-Language with translations is used
+		{spanish, "nueva", "", []string{"New York: Nueva York"}},
+		{spanish, "new y", "en", []string{"New York: New York"}},
+	}
+	for _, test := range tests {
+		query := fmt.Sprintf(`{ states(search: %q, locale: %q) { name displayName } }`, test.search, test.locale)
+		if test.locale == "" {
+			query = fmt.Sprintf(`{ states(search: %q) { name displayName } }`, test.search)
+		}
+		result := runGraphQL(t, test.ctx, query)
+		if len(result.Errors) > 0 {
+			t.Fatal(result.Errors)
+		}
+		var names []string
+		for _, state := range result.Data.(map[string]interface{})["states"].([]interface{}) {
+			state := state.(map[string]interface{})
+			names = append(names, fmt.Sprintf("%s: %s", state["name"], state["displayName"]))
+		}
+		if !reflect.DeepEqual(names, test.want) {
+			t.Errorf("searching %q in locale %q = %v, want %v", test.search, test.locale, names, test.want)
+		}
+	}
+	// the frequency is shared by all locales
+	if frequency := findState(s.Root(), "New York").loadFrequency(); frequency != 15 {
+		t.Errorf("New York has a frequency of %d after 5 selections in three locales, want 15", frequency)
+	}
+}
+
+func TestAcceptLanguage(t *testing.T) {
+	for header, want := range map[string][]string{
+		"es-MX,es;q=0.9,en;q=0.8": {"es", "es", "en"},
+		"fr":                      {"fr"},
+		"":                        nil,
+	} {
+		var languages []string
+		handler := withAcceptLanguage(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			languages, _ = r.Context().Value(acceptLanguageContextKey{}).([]string)
+		}))
+		r := httptest.NewRequest(http.MethodGet, "/graphql", nil)
+		r.Header.Set("Accept-Language", header)
+		handler.ServeHTTP(httptest.NewRecorder(), r)
+		if !reflect.DeepEqual(languages, want) {
+			t.Errorf("languages of Accept-Language %q = %v, want %v", header, languages, want)
+		}
+	}
+}
+
+func TestLocalizedName(t *testing.T) {
+	state := &State{Name: "New York", Translations: map[string]string{"es": "Nueva York", "fr": ""}}
+	for locale, want := range map[string]string{"es": "Nueva York", "fr": "New York", "de": "New York", "": "New York"} {
+		if got := state.LocalizedName(locale); got != want {
+			t.Errorf("name of New York in %q = %q, want %q", locale, got, want)
+		}
+	}
+}
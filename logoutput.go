@@ -0,0 +1,39 @@
+package backend
+
+import (
+	"io"
+	"log"
+	"os"
+	"strings"
+)
+
+const (
+	// logOutputStdout sends logs to standard output
+	logOutputStdout = "stdout"
+	// logOutputStderr sends logs to standard error
+	logOutputStderr = "stderr"
+)
+
+// openLogOutput returns the writer logs are sent to: standard output, standard error or the file at
+// the path, which is created if needed and appended to. Files are not rotated; external rotation must
+// truncate them in place, e.g. logrotate's copytruncate.
+func openLogOutput(destination string) (io.Writer, error) {
+	switch strings.ToLower(strings.TrimSpace(destination)) {
+	case "", logOutputStderr:
+		return os.Stderr, nil
+	case logOutputStdout:
+		return os.Stdout, nil
+	}
+	return os.OpenFile(destination, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+}
+
+// setLogOutput sends the logs of the standard logger, which the whole backend logs through, to the
+// destination
+func setLogOutput(destination string) error {
+	output, err := openLogOutput(destination)
+	if err != nil {
+		return err
+	}
+	log.SetOutput(output)
+	return nil
+}
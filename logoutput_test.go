@@ -0,0 +1,56 @@
+package backend
+
+import (
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestOpenLogOutput(t *testing.T) {
+	for destination, want := range map[string]*os.File{
+		"":         os.Stderr,
+		"stderr":   os.Stderr,
+		" STDOUT ": os.Stdout,
+		"stdout":   os.Stdout,
+		"Stderr\n": os.Stderr,
+	} {
+		output, err := openLogOutput(destination)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if output != want {
+			t.Errorf("output of %q = %v, want %v", destination, output, want.Name())
+		}
+	}
+}
+
+func TestSetLogOutputWritesToFile(t *testing.T) {
+	defer log.SetOutput(io.Discard)
+	path := filepath.Join(t.TempDir(), "backend.log")
+	if err := os.WriteFile(path, []byte("earlier line\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := setLogOutput(path); err != nil {
+		t.Fatal(err)
+	}
+	log.Printf("Loaded %d states", 3)
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	if len(lines) != 2 || lines[0] != "earlier line" || !strings.HasSuffix(lines[1], "Loaded 3 states") {
+		t.Errorf("log file holds %q, want the earlier line followed by the log", lines)
+	}
+}
+
+func TestSetLogOutputFailsForUnwritablePath(t *testing.T) {
+	if err := setLogOutput(filepath.Join(t.TempDir(), "missing", "backend.log")); err == nil {
+		t.Error("logging to a file in a missing directory succeeded")
+	}
+}
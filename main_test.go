@@ -0,0 +1,105 @@
+package backend
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"reflect"
+	"testing"
+
+	"github.com/graphql-go/graphql"
+)
+
+// runGraphQL executes the query or mutation against the schema with the context
+func runGraphQL(t *testing.T, ctx context.Context, query string) *graphql.Result {
+	t.Helper()
+	schema, err := NewSchema()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return graphql.Do(graphql.Params{Schema: schema, RequestString: query, Context: ctx})
+}
+
+// asAdmin returns a context authenticated as the admin actor
+func asAdmin(ctx context.Context, actor string) context.Context {
+	return context.WithValue(ctx, actorContextKey{}, actor)
+}
+
+// BenchmarkCollectStatesAlloc compares collecting a large subtree into a slice grown by append with
+// one sized from the node's subtree count
+func BenchmarkCollectStatesAlloc(b *testing.B) {
+	log.SetOutput(io.Discard)
+	root := newTrieRoot()
+	for i := 0; i < 10000; i++ {
+		insertKey(root, collationKey(fmt.Sprintf("State %05d", i)), &State{Name: fmt.Sprintf("State %05d", i), Enabled: true})
+	}
+	node := findNode(root, collationKey("State"))
+	ctx := context.Background()
+
+	b.Run("grown", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			results := []*State{}
+			collectStates(ctx, node, &results)
+		}
+	})
+	b.Run("presized", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			results := make([]*State, 0, node.SubtreeCount)
+			collectStates(ctx, node, &results)
+		}
+	})
+}
+
+func TestCollectStatesFillsPresizedSlice(t *testing.T) {
+	root := newTrieRoot()
+	for _, name := range []string{"Texas", "Tennessee", "Utah", "Te"} {
+		insertKey(root, collationKey(name), &State{Name: name, Enabled: true})
+	}
+	node := findNode(root, collationKey("Te"))
+	results := make([]*State, 0, node.SubtreeCount)
+	collectStates(context.Background(), node, &results)
+	if len(results) != 3 || cap(results) != 3 {
+		t.Errorf("collected %d states into a slice of capacity %d, want 3 and 3", len(results), cap(results))
+	}
+}
+
+func TestStatesSharingATrieKey(t *testing.T) {
+	withUnreachableMongo(t)
+	previousPolicy := collationPolicy
+	defer func() { collationPolicy = previousPolicy }()
+	collationPolicy = CollationCaseAndAccentFold
+	s := newTestStore(t,
+		State{Name: "Québec", Code: "QC", Enabled: true, Kind: KindProvince, Frequency: 5},
+		State{Name: "Quebec", Code: "QB", Enabled: true, Kind: KindProvince, Frequency: 3},
+	)
+	node := findNode(s.Root(), collationKey("Quebec"))
+	if node == nil || len(node.States) != 2 || node.SubtreeCount != 2 {
+		t.Fatalf("terminal node of quebec = %+v, want both states on it", node)
+	}
+	if node.loadFrequency() != 5 {
+		t.Errorf("terminal node has a frequency of %d, want the highest of its states, 5", node.loadFrequency())
+	}
+	if names := searchNames(s.Root(), "queb"); !reflect.DeepEqual(names, []string{"Québec", "Quebec"}) {
+		t.Errorf("searching queb = %v, want Québec and Quebec", names)
+	}
+
+	// selections count for the state with exactly the selected name
+	ctx := context.Background()
+	for i := 0; i < 3; i++ {
+		updateFrequency(ctx, s, "Quebec")
+	}
+	updateFrequency(ctx, s, "quebec")
+	if accented, plain := findState(s.Root(), "Québec"), findState(s.Root(), "Quebec"); accented.loadFrequency() != 5 || plain.loadFrequency() != 6 {
+		t.Errorf("frequencies after selecting Quebec 3 times = %d for Québec and %d for Quebec, want 5 and 6",
+			accented.loadFrequency(), plain.loadFrequency())
+	}
+	if node.loadFrequency() != 6 {
+		t.Errorf("terminal node has a frequency of %d after the selections, want 6", node.loadFrequency())
+	}
+	if names := searchNames(s.Root(), "QUEB"); !reflect.DeepEqual(names, []string{"Quebec", "Québec"}) {
+		t.Errorf("searching QUEB after the selections = %v, want Quebec first", names)
+	}
+}
@@ -0,0 +1,89 @@
+package backend
+
+import (
+	"context"
+	_ "embed"
+	"strings"
+	"time"
+
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/gqlerrors"
+)
+
+// metaExtensionName is the key of the meta data in the response extensions
+const metaExtensionName = "meta"
+
+//go:embed VERSION
+var versionFile string
+
+// version is the server version embedded at build time
+var version = strings.TrimSpace(versionFile)
+
+// ResponseMeta describes the server that produced a GraphQL response
+type ResponseMeta struct {
+	Version        string   `json:"version"`
+	RequestID      string   `json:"requestID"`
+	ServerTime     string   `json:"serverTime"`
+	TrieAge        string   `json:"trieAge,omitempty"`
+	Warnings       []string `json:"warnings,omitempty"`
+	UpdatesApplied *int     `json:"updatesApplied,omitempty"`
+}
+
+// metaExtension adds ResponseMeta to the extensions of every executed GraphQL response
+type metaExtension struct{}
+
+// newResponseMeta describes the server for the request of the context. The trie age is left out
+// while the tenant's trie is not loaded, rather than waiting for it.
+func newResponseMeta(ctx context.Context) *ResponseMeta {
+	meta := &ResponseMeta{
+		Version:        version,
+		RequestID:      requestIDFromContext(ctx),
+		ServerTime:     time.Now().UTC().Format(time.RFC3339),
+		Warnings:       warningsFromContext(ctx),
+		UpdatesApplied: updatesAppliedFromContext(ctx),
+	}
+	if tenantStore, ok := tenants.LoadedStore(tenantFromContext(ctx)); ok {
+		meta.TrieAge = tenantStore.Age().Round(time.Second).String()
+	}
+	return meta
+}
+
+// Init collects the warnings reported and frequency updates applied while resolving the request
+func (metaExtension) Init(ctx context.Context, p *graphql.Params) context.Context {
+	return withUpdateAcknowledgement(withWarnings(ctx))
+}
+
+// Name returns the extension name
+func (metaExtension) Name() string {
+	return metaExtensionName
+}
+
+// ParseDidStart does nothing
+func (metaExtension) ParseDidStart(ctx context.Context) (context.Context, graphql.ParseFinishFunc) {
+	return ctx, func(error) {}
+}
+
+// ValidationDidStart does nothing
+func (metaExtension) ValidationDidStart(ctx context.Context) (context.Context, graphql.ValidationFinishFunc) {
+	return ctx, func([]gqlerrors.FormattedError) {}
+}
+
+// ExecutionDidStart does nothing
+func (metaExtension) ExecutionDidStart(ctx context.Context) (context.Context, graphql.ExecutionFinishFunc) {
+	return ctx, func(*graphql.Result) {}
+}
+
+// ResolveFieldDidStart does nothing
+func (metaExtension) ResolveFieldDidStart(ctx context.Context, info *graphql.ResolveInfo) (context.Context, graphql.ResolveFieldFinishFunc) {
+	return ctx, func(interface{}, error) {}
+}
+
+// HasResult reports that the extension adds meta data to every response
+func (metaExtension) HasResult() bool {
+	return true
+}
+
+// GetResult returns the meta data of the response
+func (metaExtension) GetResult(ctx context.Context) interface{} {
+	return newResponseMeta(ctx)
+}
@@ -0,0 +1,37 @@
+package backend
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestResponseMetaDoesNotLoadTenants(t *testing.T) {
+	newTestStore(t, State{Name: "Texas", Code: "TX", Enabled: true, Kind: KindState})
+	created := 0
+	tenants = NewTenantRegistry(store, map[string]bool{"acme": true}, time.Hour, 10, func(string) StateRepository {
+		created++
+		return NewInMemoryStateRepository()
+	}, time.Now)
+
+	meta := newResponseMeta(context.Background())
+	if meta.TrieAge == "" || meta.Version != version {
+		t.Errorf("meta of the default tenant = %+v, want its version and trie age", meta)
+	}
+
+	ctx := context.WithValue(context.Background(), tenantContextKey{}, "acme")
+	start := time.Now()
+	meta = newResponseMeta(ctx)
+	if elapsed := time.Since(start); elapsed > tenantLoadWait/2 {
+		t.Errorf("meta of an unloaded tenant took %s", elapsed)
+	}
+	if meta.TrieAge != "" {
+		t.Errorf("trie age of an unloaded tenant = %q, want none", meta.TrieAge)
+	}
+	if created != 0 {
+		t.Error("describing the response started loading the tenant")
+	}
+	if _, ok := tenants.LoadedStore("acme"); ok {
+		t.Error("an unloaded tenant has a loaded store")
+	}
+}
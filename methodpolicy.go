@@ -0,0 +1,61 @@
+package backend
+
+import (
+	"net/http"
+
+	"github.com/graphql-go/graphql/language/ast"
+	"github.com/graphql-go/graphql/language/parser"
+	"github.com/graphql-go/graphql/language/source"
+)
+
+// operationType returns the type of the operation the request executes, "query" or "mutation",
+// or "" when the query cannot be parsed or names no operation it holds
+func operationType(query, operationName string) string {
+	document, err := parser.Parse(parser.ParseParams{Source: source.NewSource(&source.Source{Body: []byte(query)})})
+	if err != nil {
+		return ""
+	}
+	var operations []*ast.OperationDefinition
+	for _, definition := range document.Definitions {
+		if operation, ok := definition.(*ast.OperationDefinition); ok {
+			operations = append(operations, operation)
+		}
+	}
+	for _, operation := range operations {
+		if operationName == "" && len(operations) == 1 {
+			return operation.Operation
+		}
+		if operation.Name != nil && operation.Name.Value == operationName {
+			return operation.Operation
+		}
+	}
+	return ""
+}
+
+// withMethodPolicy only accepts GraphQL requests over GET or POST. GET requests, which CDNs can
+// cache, are limited to queries and rejected altogether unless allowGET is set.
+func withMethodPolicy(allowGET bool, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodPost:
+			next.ServeHTTP(w, r)
+			return
+		case http.MethodGet:
+			values := r.URL.Query()
+			if allowGET && operationType(values.Get("query"), values.Get("operationName")) != ast.OperationTypeMutation {
+				next.ServeHTTP(w, r)
+				return
+			}
+		}
+		message, allow := "GraphQL requests must be sent with POST", http.MethodPost
+		if allowGET && r.Method == http.MethodGet {
+			message = "mutations must be sent with POST"
+		} else if allowGET {
+			message, allow = "GraphQL requests must be sent with GET or POST", "GET, POST"
+		}
+		w.Header().Set("Allow", allow)
+		writeJSON(w, http.StatusMethodNotAllowed, map[string]interface{}{
+			"errors": []map[string]string{{"message": message}},
+		})
+	})
+}
@@ -0,0 +1,73 @@
+package backend
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/graphql-go/handler"
+)
+
+func TestMethodPolicy(t *testing.T) {
+	newTestStore(t, State{Name: "Texas", Code: "TX", Enabled: true, Kind: KindState})
+	schema, err := NewSchema()
+	if err != nil {
+		t.Fatal(err)
+	}
+	graphqlHandler := handler.New(&handler.Config{Schema: &schema})
+
+	get := func(query string) *http.Request {
+		return httptest.NewRequest(http.MethodGet, "/graphql?query="+url.QueryEscape(query), nil)
+	}
+	post := func(query string) *http.Request {
+		request := httptest.NewRequest(http.MethodPost, "/graphql", strings.NewReader(`{"query": "`+query+`"}`))
+		request.Header.Set("Content-Type", "application/json")
+		return request
+	}
+	tests := []struct {
+		allowGET bool
+		request  *http.Request
+		status   int
+		allow    string
+	}{
+		{true, get(`{ states(search: "T") { name } }`), http.StatusOK, ""},
+		{true, get(`mutation { setStateEnabled(name: "Texas", enabled: false) { name } }`), http.StatusMethodNotAllowed, "POST"},
+		{true, post(`{ states(search: \"T\") { name } }`), http.StatusOK, ""},
+		{true, httptest.NewRequest(http.MethodPut, "/graphql", nil), http.StatusMethodNotAllowed, "GET, POST"},
+		{false, get(`{ states(search: "T") { name } }`), http.StatusMethodNotAllowed, "POST"},
+		{false, post(`{ states(search: \"T\") { name } }`), http.StatusOK, ""},
+	}
+	for _, test := range tests {
+		recorder := httptest.NewRecorder()
+		withMethodPolicy(test.allowGET, graphqlHandler).ServeHTTP(recorder, test.request)
+		if recorder.Code != test.status || recorder.Header().Get("Allow") != test.allow {
+			t.Errorf("%s %s with GET allowed %t = %d, Allow %q, want %d, Allow %q", test.request.Method, test.request.URL,
+				test.allowGET, recorder.Code, recorder.Header().Get("Allow"), test.status, test.allow)
+		}
+		if test.status == http.StatusOK && !strings.Contains(recorder.Body.String(), `"Texas"`) {
+			t.Errorf("%s %s answered %s, want Texas", test.request.Method, test.request.URL, recorder.Body)
+		}
+	}
+}
+
+func TestOperationType(t *testing.T) {
+	tests := []struct {
+		query         string
+		operationName string
+		want          string
+	}{
+		{`{ states(search: "T") { name } }`, "", "query"},
+		{`mutation { setStateEnabled(name: "Texas", enabled: false) { name } }`, "", "mutation"},
+		{`query Find { states(search: "T") { name } } mutation Disable { setStateEnabled(name: "Texas", enabled: false) { name } }`, "Disable", "mutation"},
+		// the operation to run is ambiguous without a name
+		{`query Find { states(search: "T") { name } } mutation Disable { setStateEnabled(name: "Texas", enabled: false) { name } }`, "", ""},
+		{`{ states(`, "", ""},
+	}
+	for _, test := range tests {
+		if got := operationType(test.query, test.operationName); got != test.want {
+			t.Errorf("operationType(%q, %q) = %q, want %q", test.query, test.operationName, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,70 @@
+package backend
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	searchesTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "searches_total",
+		Help: "Total number of state searches per tenant and client.",
+	}, []string{"tenant", "client"})
+	zeroResultSearchesTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "zero_result_searches_total",
+		Help: "Total number of state searches that returned no results per tenant and client.",
+	}, []string{"tenant", "client"})
+	searchResultsReturned = promauto.NewHistogram(prometheus.HistogramOpts{
+		Name:    "search_results_returned",
+		Help:    "Number of states returned per search.",
+		Buckets: []float64{0, 1, 2, 3, 5, 8, 13, 21, 34, 55},
+	})
+	searchLatencySeconds = promauto.NewHistogram(prometheus.HistogramOpts{
+		Name:    "search_latency_seconds",
+		Help:    "Latency of state searches in seconds.",
+		Buckets: prometheus.ExponentialBuckets(0.00005, 2, 16),
+	})
+	stateSelectionsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "state_selections_total",
+		Help: "Total number of frequency updates per tenant and state code.",
+	}, []string{"tenant", "code"})
+	loadedTenants = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "tenants_loaded",
+		Help: "Number of tenants whose trie is held in memory, including the default tenant.",
+	})
+	tenantEvictionsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "tenant_evictions_total",
+		Help: "Total number of tenant tries evicted from memory per reason.",
+	}, []string{"reason"})
+	trieVerificationMismatchesTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "trie_verification_mismatches_total",
+		Help: "Total number of rebuilt tries found to differ from their repository per check.",
+	}, []string{"check"})
+	trieVerificationFailuresTotal = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "trie_verification_failures_total",
+		Help: "Total number of rebuilt tries that could not be checked against their repository.",
+	})
+)
+
+// otherStateCode is the label used for state codes outside knownStateCodes
+const otherStateCode = "other"
+
+// knownStateCodes bounds the code label values of the per-state metrics
+var knownStateCodes = map[string]bool{
+	"AL": true, "AK": true, "AZ": true, "AR": true, "CA": true, "CO": true, "CT": true,
+	"DE": true, "DC": true, "FL": true, "GA": true, "HI": true, "ID": true, "IL": true,
+	"IN": true, "IA": true, "KS": true, "KY": true, "LA": true, "ME": true, "MD": true,
+	"MA": true, "MI": true, "MN": true, "MS": true, "MO": true, "MT": true, "NE": true,
+	"NV": true, "NH": true, "NJ": true, "NM": true, "NY": true, "NC": true, "ND": true,
+	"OH": true, "OK": true, "OR": true, "PA": true, "RI": true, "SC": true, "SD": true,
+	"TN": true, "TX": true, "UT": true, "VT": true, "VA": true, "WA": true, "WV": true,
+	"WI": true, "WY": true, "PR": true, "GU": true, "AS": true, "VI": true, "MP": true,
+}
+
+// stateCodeLabel returns the metric label for the state code
+func stateCodeLabel(code string) string {
+	if knownStateCodes[code] {
+		return code
+	}
+	return otherStateCode
+}
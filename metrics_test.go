@@ -0,0 +1,95 @@
+package backend
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// scrapeMetric returns the value of the sample exposed at /metrics under the name with its labels,
+// e.g. state_selections_total{code="TX",tenant="default"}, or 0 when there is none
+func scrapeMetric(t *testing.T, sample string) float64 {
+	t.Helper()
+	recorder := httptest.NewRecorder()
+	promhttp.Handler().ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	for _, line := range strings.Split(recorder.Body.String(), "\n") {
+		if strings.HasPrefix(line, sample+" ") {
+			value, err := strconv.ParseFloat(strings.TrimPrefix(line, sample+" "), 64)
+			if err != nil {
+				t.Fatal(err)
+			}
+			return value
+		}
+	}
+	return 0
+}
+
+func TestStateSelectionMetrics(t *testing.T) {
+	s := newTestStore(t,
+		State{Name: "Texas", Code: "TX", Enabled: true, Kind: KindState},
+		State{Name: "Atlantis", Code: "ZZ", Enabled: true, Kind: KindState},
+	)
+	const (
+		texas = `state_selections_total{code="TX",tenant="default"}`
+		other = `state_selections_total{code="other",tenant="default"}`
+	)
+	texasBefore, otherBefore := scrapeMetric(t, texas), scrapeMetric(t, other)
+
+	ctx := context.Background()
+	for i := 0; i < 3; i++ {
+		updateFrequency(ctx, s, "Texas")
+	}
+	updateFrequency(ctx, s, "Atlantis")
+	updateFrequency(ctx, s, "Atlantis")
+	// replacing the store, as a reload does, keeps the counters registered
+	newTestStore(t, State{Name: "Texas", Code: "TX", Enabled: true, Kind: KindState})
+	updateFrequency(ctx, store, "Texas")
+
+	if got := scrapeMetric(t, texas) - texasBefore; got != 4 {
+		t.Errorf("TX selections went up by %v, want 4", got)
+	}
+	if got := scrapeMetric(t, other) - otherBefore; got != 2 {
+		t.Errorf("selections of unknown codes went up by %v, want 2", got)
+	}
+	if value := scrapeMetric(t, `state_selections_total{code="ZZ",tenant="default"}`); value != 0 {
+		t.Errorf("unknown code ZZ got its own label with %v selections", value)
+	}
+}
+
+func TestSearchResultMetrics(t *testing.T) {
+	const (
+		zero    = `zero_result_searches_total{client="metrics-test",tenant="default"}`
+		results = `search_results_returned_count`
+		empty   = `search_results_returned_bucket{le="0"}`
+	)
+	zeroBefore, resultsBefore, emptyBefore := scrapeMetric(t, zero), scrapeMetric(t, results), scrapeMetric(t, empty)
+
+	ctx := context.WithValue(context.Background(), clientIDContextKey{}, "metrics-test")
+	recordSearch(ctx, "tex", 3, time.Millisecond)
+	recordSearch(ctx, "xyz", 0, time.Millisecond)
+	recordSearch(ctx, "qq", 0, time.Millisecond)
+
+	if got := scrapeMetric(t, zero) - zeroBefore; got != 2 {
+		t.Errorf("zero result searches went up by %v, want 2", got)
+	}
+	if got := scrapeMetric(t, results) - resultsBefore; got != 3 {
+		t.Errorf("searches observed went up by %v, want 3", got)
+	}
+	if got := scrapeMetric(t, empty) - emptyBefore; got != 2 {
+		t.Errorf("searches returning nothing went up by %v, want 2", got)
+	}
+}
+
+func TestStateCodeLabel(t *testing.T) {
+	for code, want := range map[string]string{"TX": "TX", "PR": "PR", "ZZ": otherStateCode, "tx": otherStateCode, "": otherStateCode} {
+		if got := stateCodeLabel(code); got != want {
+			t.Errorf("stateCodeLabel(%q) = %q, want %q", code, got, want)
+		}
+	}
+}
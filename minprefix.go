@@ -0,0 +1,22 @@
+package backend
+
+import (
+	"fmt"
+	"unicode/utf8"
+)
+
+// minPrefixLengthArg returns the minimum prefix length requested by the argument, or the configured one
+func minPrefixLengthArg(arg interface{}) int {
+	if minLength, ok := arg.(int); ok {
+		return minLength
+	}
+	return config.MinPrefixLen
+}
+
+// checkPrefixLength returns an error when the prefix has fewer than minLength characters
+func checkPrefixLength(prefix string, minLength int) error {
+	if length := utf8.RuneCountInString(prefix); length < minLength {
+		return fmt.Errorf("search must be at least %d characters long, got %d", minLength, length)
+	}
+	return nil
+}
@@ -0,0 +1,49 @@
+package backend
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestMinPrefixLength(t *testing.T) {
+	withUnreachableMongo(t)
+	newTestStore(t,
+		State{Name: "Texas", Code: "TX", Enabled: true, Kind: KindState},
+		State{Name: "Québec", Code: "QC", Enabled: true, Kind: KindTerritory},
+	)
+	previous := config.MinPrefixLen
+	defer func() { config.MinPrefixLen = previous }()
+	config.MinPrefixLen = 3
+
+	tests := []struct {
+		args  string
+		found int
+		err   string
+	}{
+		// below, at and above the configured minimum
+		{`search: "Te"`, 0, "search must be at least 3 characters long, got 2"},
+		{`search: "Tex"`, 1, ""},
+		{`search: "Texa"`, 1, ""},
+		// characters are counted, not bytes
+		{`search: "Qué"`, 1, ""},
+		// the minimum can be overridden per request
+		{`search: "T", minPrefixLength: 1`, 1, ""},
+		{`search: "Texa", minPrefixLength: 5`, 0, "search must be at least 5 characters long, got 4"},
+	}
+	for _, test := range tests {
+		result := runGraphQL(t, context.Background(), `{ states(`+test.args+`) { name } }`)
+		if test.err != "" {
+			if len(result.Errors) != 1 || !strings.Contains(result.Errors[0].Message, test.err) {
+				t.Errorf("states(%s) = %v, want the error %q", test.args, result.Errors, test.err)
+			}
+			continue
+		}
+		if len(result.Errors) > 0 {
+			t.Fatalf("states(%s) failed: %v", test.args, result.Errors)
+		}
+		if found := len(result.Data.(map[string]interface{})["states"].([]interface{})); found != test.found {
+			t.Errorf("states(%s) found %d states, want %d", test.args, found, test.found)
+		}
+	}
+}
@@ -0,0 +1,150 @@
+package backend
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/graphql-go/graphql"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	// maxMissedPrefixLen caps the number of runes recorded per missed search
+	maxMissedPrefixLen = 32
+	// missedSearchTTL is how long a prefix seen only once is kept before being pruned
+	missedSearchTTL = 7 * 24 * time.Hour
+	// missedSearchPruneInterval is how often singleton prefixes are pruned
+	missedSearchPruneInterval = time.Hour
+	// defaultMissedSearchLimit is the number of missed searches returned when no limit is given
+	defaultMissedSearchLimit = 20
+)
+
+// MissedSearch represents a normalized prefix that returned no results
+type MissedSearch struct {
+	Prefix    string         `bson:"prefix"`
+	Count     int            `bson:"count"`
+	Clients   map[string]int `bson:"clients"`
+	FirstSeen time.Time      `bson:"firstSeen"`
+	LastSeen  time.Time      `bson:"lastSeen"`
+}
+
+// recordMissedSearch increments the miss count of the normalized prefix in MongoDB, overall and
+// for the client that made the search
+func recordMissedSearch(prefix, clientID string) {
+	normalized := truncateRunes(normalizePrefix(prefix), maxMissedPrefixLen)
+	if normalized == "" {
+		return
+	}
+
+	now := time.Now()
+	collection := client.Database(config.MongoDB).Collection("missedSearches")
+	_, err := collection.UpdateOne(
+		context.Background(),
+		bson.M{"prefix": normalized},
+		bson.M{
+			"$inc":         bson.M{"count": 1, "clients." + clientID: 1},
+			"$set":         bson.M{"lastSeen": now},
+			"$setOnInsert": bson.M{"firstSeen": now},
+		},
+		options.Update().SetUpsert(true),
+	)
+	if err != nil {
+		log.Printf("Error recording missed search for prefix %s: %v", normalized, err)
+	} else {
+		log.Printf("Recorded missed search for prefix: %s", normalized)
+	}
+}
+
+// pruneMissedSearches deletes prefixes seen only once whose last sighting is older than the TTL
+func pruneMissedSearches() {
+	collection := client.Database(config.MongoDB).Collection("missedSearches")
+	res, err := collection.DeleteMany(context.Background(), bson.M{
+		"count":    1,
+		"lastSeen": bson.M{"$lt": time.Now().Add(-missedSearchTTL)},
+	})
+	if err != nil {
+		log.Printf("Error pruning missed searches: %v", err)
+		return
+	}
+	log.Printf("Pruned %d missed searches", res.DeletedCount)
+}
+
+// startMissedSearchPruner periodically prunes singleton missed searches in the background
+func startMissedSearchPruner() {
+	go func() {
+		ticker := time.NewTicker(missedSearchPruneInterval)
+		defer ticker.Stop()
+		for range ticker.C {
+			pruneMissedSearches()
+		}
+	}()
+}
+
+// fetchMissedSearches returns the most frequent missed searches seen since the given time
+func fetchMissedSearches(limit int, since time.Time) ([]*MissedSearch, error) {
+	filter := bson.M{}
+	if !since.IsZero() {
+		filter["lastSeen"] = bson.M{"$gte": since}
+	}
+	opts := options.Find().
+		SetSort(bson.D{{Key: "count", Value: -1}, {Key: "prefix", Value: 1}}).
+		SetLimit(int64(limit))
+
+	collection := client.Database(config.MongoDB).Collection("missedSearches")
+	cursor, err := collection.Find(context.Background(), filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(context.Background())
+
+	results := []*MissedSearch{}
+	if err := cursor.All(context.Background(), &results); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// Define the GraphQL missed search type
+var missedSearchType = graphql.NewObject(graphql.ObjectConfig{
+	Name: "MissedSearch",
+	Fields: graphql.Fields{
+		"prefix": &graphql.Field{
+			Type: graphql.String,
+		},
+		"count": &graphql.Field{
+			Type: graphql.Int,
+		},
+		"clients": &graphql.Field{
+			Type: jsonScalar,
+		},
+		"firstSeen": &graphql.Field{
+			Type: graphql.DateTime,
+		},
+		"lastSeen": &graphql.Field{
+			Type: graphql.DateTime,
+		},
+	},
+})
+
+// missedSearchesField lists the prefixes that returned no results, most frequent first
+var missedSearchesField = &graphql.Field{
+	Type: graphql.NewList(missedSearchType),
+	Args: graphql.FieldConfigArgument{
+		"limit": &graphql.ArgumentConfig{
+			Type: graphql.Int,
+		},
+		"since": &graphql.ArgumentConfig{
+			Type: graphql.DateTime,
+		},
+	},
+	Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+		limit, ok := p.Args["limit"].(int)
+		if !ok || limit <= 0 {
+			limit = defaultMissedSearchLimit
+		}
+		since, _ := p.Args["since"].(time.Time)
+		return fetchMissedSearches(limit, since)
+	},
+}
@@ -0,0 +1,84 @@
+package backend
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func TestRecordMissedSearchAggregatesRepeats(t *testing.T) {
+	useTestMongo(t)
+	recordMissedSearch("  Texs ", "web")
+	recordMissedSearch("TEXS", "web")
+	recordMissedSearch("texs", "ios")
+	recordMissedSearch("zzz", "web")
+	recordMissedSearch("   ", "web")
+	recordMissedSearch(strings.Repeat("á", maxMissedPrefixLen+8), "web")
+
+	missed, err := fetchMissedSearches(10, time.Time{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(missed) != 3 {
+		t.Fatalf("got %d missed searches, want 3: %+v", len(missed), missed)
+	}
+	first := missed[0]
+	if first.Prefix != "texs" || first.Count != 3 || first.Clients["web"] != 2 || first.Clients["ios"] != 1 {
+		t.Errorf("most missed = %+v, want texs missed 3 times, twice by web and once by ios", first)
+	}
+	if first.FirstSeen.IsZero() || first.LastSeen.Before(first.FirstSeen) {
+		t.Errorf("texs seen from %s to %s", first.FirstSeen, first.LastSeen)
+	}
+	// ties are ordered by prefix, compared byte by byte
+	if missed[1].Prefix != "zzz" || missed[2].Prefix != strings.Repeat("á", maxMissedPrefixLen) {
+		t.Errorf("missed searches ordered %q, %q after texs, want zzz, then the truncated prefix", missed[1].Prefix, missed[2].Prefix)
+	}
+}
+
+func TestFetchMissedSearchesLimitAndSince(t *testing.T) {
+	useTestMongo(t)
+	for _, prefix := range []string{"a", "b", "b", "c", "c", "c"} {
+		recordMissedSearch(prefix, "web")
+	}
+
+	missed, err := fetchMissedSearches(2, time.Time{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(missed) != 2 || missed[0].Prefix != "c" || missed[1].Prefix != "b" {
+		t.Errorf("top 2 missed searches = %+v, want c then b", missed)
+	}
+	missed, err = fetchMissedSearches(10, time.Now().Add(time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(missed) != 0 {
+		t.Errorf("missed searches seen since an hour from now = %+v, want none", missed)
+	}
+}
+
+func TestPruneMissedSearchesDropsStaleSingletons(t *testing.T) {
+	db := useTestMongo(t)
+	stale := time.Now().Add(-missedSearchTTL - time.Hour)
+	_, err := db.Collection("missedSearches").InsertMany(context.Background(), []interface{}{
+		bson.M{"prefix": "stale once", "count": 1, "lastSeen": stale},
+		bson.M{"prefix": "stale twice", "count": 2, "lastSeen": stale},
+		bson.M{"prefix": "recent once", "count": 1, "lastSeen": time.Now()},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pruneMissedSearches()
+
+	missed, err := fetchMissedSearches(10, time.Time{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(missed) != 2 || missed[0].Prefix != "stale twice" || missed[1].Prefix != "recent once" {
+		t.Errorf("kept %+v, want the repeated and the recent prefix", missed)
+	}
+}
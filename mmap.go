@@ -0,0 +1,384 @@
+package backend
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"time"
+
+	"golang.org/x/sys/unix"
+)
+
+const (
+	// mmapMagic identifies a trie snapshot file
+	mmapMagic = "STRI"
+	// mmapVersion is the version of the snapshot format
+	mmapVersion = 6
+	// mmapHeaderSize is the size of the snapshot header in bytes
+	mmapHeaderSize = 28
+	// diskNodeSize is the size of a DiskNode in bytes
+	diskNodeSize = 32
+	// diskStateSize is the size of a DiskState in bytes
+	diskStateSize = 40
+	// diskTranslationSize is the size of a DiskTranslation in bytes
+	diskTranslationSize = 16
+	// diskStateEnabled flags an enabled DiskState
+	diskStateEnabled = 1 << 0
+)
+
+var (
+	// errInvalidSnapshot is returned when a snapshot file is truncated or has an unknown format
+	errInvalidSnapshot = errors.New("invalid trie snapshot")
+	// errSnapshotCollation is returned when a snapshot was keyed with another collation policy
+	errSnapshotCollation = errors.New("trie snapshot was built with another collation policy")
+)
+
+// DiskNode is the fixed-size on-disk form of a TrieNode. Children and states of a node are stored
+// contiguously, so they are referenced by the index of the first one and their count.
+type DiskNode struct {
+	Char       int32
+	IsEnd      uint32
+	Frequency  int64
+	FirstChild uint32
+	ChildCount uint32
+	FirstState uint32
+	StateCount uint32
+}
+
+// DiskState is the fixed-size on-disk form of a State referencing the string table.
+// Translations of a state are stored contiguously, like the children of a node.
+type DiskState struct {
+	NameOffset       uint32
+	NameLength       uint32
+	CodeOffset       uint32
+	CodeLength       uint32
+	Frequency        int64
+	Flags            uint32
+	FirstTranslation uint32
+	TranslationCount uint32
+	Kind             uint32
+}
+
+// DiskTranslation is the fixed-size on-disk form of a localized state name
+type DiskTranslation struct {
+	LocaleOffset uint32
+	LocaleLength uint32
+	NameOffset   uint32
+	NameLength   uint32
+}
+
+// SaveTrieToMmap writes the trie to the file at path as a flat array of DiskNodes. The layout is
+// a header, the nodes in breadth-first order, the states, their translations and the string table.
+func SaveTrieToMmap(root *TrieNode, path string) error {
+	nodes := []*TrieNode{root}
+	nodeChars := []rune{0}
+	diskNodes := []DiskNode{}
+	diskStates := []DiskState{}
+	diskTranslations := []DiskTranslation{}
+	strs := []byte{}
+
+	for i := 0; i < len(nodes); i++ {
+		node := nodes[i]
+		chars := make([]rune, 0, len(node.Children))
+		for char := range node.Children {
+			chars = append(chars, char)
+		}
+		sort.Slice(chars, func(a, b int) bool { return chars[a] < chars[b] })
+
+		diskNode := DiskNode{
+			Char:       int32(nodeChars[i]),
+			FirstChild: uint32(len(nodes)),
+			ChildCount: uint32(len(chars)),
+			Frequency:  node.loadFrequency(),
+			FirstState: uint32(len(diskStates)),
+			StateCount: uint32(len(node.States)),
+		}
+		if node.IsEnd {
+			diskNode.IsEnd = 1
+		}
+		for _, state := range node.States {
+			diskStates = append(diskStates, DiskState{
+				NameOffset:       uint32(len(strs)),
+				NameLength:       uint32(len(state.Name)),
+				CodeOffset:       uint32(len(strs) + len(state.Name)),
+				CodeLength:       uint32(len(state.Code)),
+				Frequency:        state.loadFrequency(),
+				Flags:            diskStateFlags(state),
+				FirstTranslation: uint32(len(diskTranslations)),
+				TranslationCount: uint32(len(state.Translations)),
+				Kind:             kindIndex(state.Kind),
+			})
+			strs = append(strs, state.Name...)
+			strs = append(strs, state.Code...)
+
+			locales := make([]string, 0, len(state.Translations))
+			for locale := range state.Translations {
+				locales = append(locales, locale)
+			}
+			sort.Strings(locales)
+			for _, locale := range locales {
+				name := state.Translations[locale]
+				diskTranslations = append(diskTranslations, DiskTranslation{
+					LocaleOffset: uint32(len(strs)),
+					LocaleLength: uint32(len(locale)),
+					NameOffset:   uint32(len(strs) + len(locale)),
+					NameLength:   uint32(len(name)),
+				})
+				strs = append(strs, locale...)
+				strs = append(strs, name...)
+			}
+		}
+		diskNodes = append(diskNodes, diskNode)
+		for _, char := range chars {
+			nodes = append(nodes, node.Children[char])
+			nodeChars = append(nodeChars, char)
+		}
+	}
+	size := mmapHeaderSize + len(diskNodes)*diskNodeSize + len(diskStates)*diskStateSize +
+		len(diskTranslations)*diskTranslationSize + len(strs)
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	if err := file.Truncate(int64(size)); err != nil {
+		return err
+	}
+	data, err := unix.Mmap(int(file.Fd()), 0, size, unix.PROT_READ|unix.PROT_WRITE, unix.MAP_SHARED)
+	if err != nil {
+		return err
+	}
+	defer unix.Munmap(data)
+
+	copy(data[0:4], mmapMagic)
+	binary.LittleEndian.PutUint32(data[4:], mmapVersion)
+	binary.LittleEndian.PutUint32(data[8:], uint32(len(diskNodes)))
+	binary.LittleEndian.PutUint32(data[12:], uint32(len(diskStates)))
+	binary.LittleEndian.PutUint32(data[16:], uint32(len(strs)))
+	binary.LittleEndian.PutUint32(data[20:], uint32(len(diskTranslations)))
+	binary.LittleEndian.PutUint32(data[24:], collationPolicy.index())
+
+	offset := mmapHeaderSize
+	for _, node := range diskNodes {
+		putDiskNode(data[offset:], node)
+		offset += diskNodeSize
+	}
+	for _, state := range diskStates {
+		putDiskState(data[offset:], state)
+		offset += diskStateSize
+	}
+	for _, translation := range diskTranslations {
+		putDiskTranslation(data[offset:], translation)
+		offset += diskTranslationSize
+	}
+	copy(data[offset:], strs)
+
+	return unix.Msync(data, unix.MS_SYNC)
+}
+
+// LoadTrieFromMmap maps the snapshot file at path into memory and rebuilds the trie from it
+func LoadTrieFromMmap(path string) (*TrieNode, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	info, err := file.Stat()
+	if err != nil {
+		return nil, err
+	}
+	if info.Size() < mmapHeaderSize {
+		return nil, errInvalidSnapshot
+	}
+	data, err := unix.Mmap(int(file.Fd()), 0, int(info.Size()), unix.PROT_READ, unix.MAP_SHARED)
+	if err != nil {
+		return nil, err
+	}
+	defer unix.Munmap(data)
+
+	if string(data[0:4]) != mmapMagic || binary.LittleEndian.Uint32(data[4:]) != mmapVersion {
+		return nil, errInvalidSnapshot
+	}
+	nodeCount := int(binary.LittleEndian.Uint32(data[8:]))
+	stateCount := int(binary.LittleEndian.Uint32(data[12:]))
+	strsLength := int(binary.LittleEndian.Uint32(data[16:]))
+	translationCount := int(binary.LittleEndian.Uint32(data[20:]))
+	if binary.LittleEndian.Uint32(data[24:]) != collationPolicy.index() {
+		return nil, errSnapshotCollation
+	}
+	nodesOffset := mmapHeaderSize
+	statesOffset := nodesOffset + nodeCount*diskNodeSize
+	translationsOffset := statesOffset + stateCount*diskStateSize
+	strsOffset := translationsOffset + translationCount*diskTranslationSize
+	if nodeCount == 0 || len(data) != strsOffset+strsLength {
+		return nil, errInvalidSnapshot
+	}
+	strs := data[strsOffset:]
+
+	states := make([]*State, stateCount)
+	for i := range states {
+		diskState := getDiskState(data[statesOffset+i*diskStateSize:])
+		if !inStringTable(diskState.NameOffset, diskState.NameLength, strsLength) ||
+			!inStringTable(diskState.CodeOffset, diskState.CodeLength, strsLength) ||
+			uint64(diskState.FirstTranslation)+uint64(diskState.TranslationCount) > uint64(translationCount) {
+			return nil, errInvalidSnapshot
+		}
+		kind, ok := kindAt(diskState.Kind)
+		if !ok {
+			return nil, errInvalidSnapshot
+		}
+		states[i] = &State{
+			Name:      string(strs[diskState.NameOffset : diskState.NameOffset+diskState.NameLength]),
+			Code:      string(strs[diskState.CodeOffset : diskState.CodeOffset+diskState.CodeLength]),
+			Frequency: diskState.Frequency,
+			Enabled:   diskState.Flags&diskStateEnabled != 0,
+			Kind:      kind,
+		}
+		if diskState.TranslationCount > 0 {
+			states[i].Translations = make(map[string]string, diskState.TranslationCount)
+		}
+		for j := uint32(0); j < diskState.TranslationCount; j++ {
+			index := int(diskState.FirstTranslation + j)
+			translation := getDiskTranslation(data[translationsOffset+index*diskTranslationSize:])
+			if !inStringTable(translation.LocaleOffset, translation.LocaleLength, strsLength) ||
+				!inStringTable(translation.NameOffset, translation.NameLength, strsLength) {
+				return nil, errInvalidSnapshot
+			}
+			locale := string(strs[translation.LocaleOffset : translation.LocaleOffset+translation.LocaleLength])
+			states[i].Translations[locale] = string(strs[translation.NameOffset : translation.NameOffset+translation.NameLength])
+		}
+	}
+
+	nodes := make([]*TrieNode, nodeCount)
+	for i := range nodes {
+		nodes[i] = &TrieNode{}
+	}
+	for i, node := range nodes {
+		diskNode := getDiskNode(data[nodesOffset+i*diskNodeSize:])
+		if uint64(diskNode.FirstChild)+uint64(diskNode.ChildCount) > uint64(nodeCount) ||
+			uint64(diskNode.FirstState)+uint64(diskNode.StateCount) > uint64(stateCount) {
+			return nil, errInvalidSnapshot
+		}
+		node.IsEnd = diskNode.IsEnd == 1
+		node.Frequency = diskNode.Frequency
+		if diskNode.StateCount > 0 {
+			node.States = states[diskNode.FirstState : diskNode.FirstState+diskNode.StateCount : diskNode.FirstState+diskNode.StateCount]
+		}
+		node.Children = make(map[rune]*TrieNode, diskNode.ChildCount)
+		for j := uint32(0); j < diskNode.ChildCount; j++ {
+			child := diskNode.FirstChild + j
+			childNode := getDiskNode(data[nodesOffset+int(child)*diskNodeSize:])
+			node.Children[rune(childNode.Char)] = nodes[child]
+		}
+	}
+	countSubtree(nodes[0])
+	return nodes[0], nil
+}
+
+// putDiskNode encodes the node into the buffer
+func putDiskNode(b []byte, node DiskNode) {
+	binary.LittleEndian.PutUint32(b[0:], uint32(node.Char))
+	binary.LittleEndian.PutUint32(b[4:], node.IsEnd)
+	binary.LittleEndian.PutUint64(b[8:], uint64(node.Frequency))
+	binary.LittleEndian.PutUint32(b[16:], node.FirstChild)
+	binary.LittleEndian.PutUint32(b[20:], node.ChildCount)
+	binary.LittleEndian.PutUint32(b[24:], node.FirstState)
+	binary.LittleEndian.PutUint32(b[28:], node.StateCount)
+}
+
+// getDiskNode decodes a node from the buffer
+func getDiskNode(b []byte) DiskNode {
+	return DiskNode{
+		Char:       int32(binary.LittleEndian.Uint32(b[0:])),
+		IsEnd:      binary.LittleEndian.Uint32(b[4:]),
+		Frequency:  int64(binary.LittleEndian.Uint64(b[8:])),
+		FirstChild: binary.LittleEndian.Uint32(b[16:]),
+		ChildCount: binary.LittleEndian.Uint32(b[20:]),
+		FirstState: binary.LittleEndian.Uint32(b[24:]),
+		StateCount: binary.LittleEndian.Uint32(b[28:]),
+	}
+}
+
+// putDiskState encodes the state into the buffer
+func putDiskState(b []byte, state DiskState) {
+	binary.LittleEndian.PutUint32(b[0:], state.NameOffset)
+	binary.LittleEndian.PutUint32(b[4:], state.NameLength)
+	binary.LittleEndian.PutUint32(b[8:], state.CodeOffset)
+	binary.LittleEndian.PutUint32(b[12:], state.CodeLength)
+	binary.LittleEndian.PutUint64(b[16:], uint64(state.Frequency))
+	binary.LittleEndian.PutUint32(b[24:], state.Flags)
+	binary.LittleEndian.PutUint32(b[28:], state.FirstTranslation)
+	binary.LittleEndian.PutUint32(b[32:], state.TranslationCount)
+	binary.LittleEndian.PutUint32(b[36:], state.Kind)
+}
+
+// getDiskState decodes a state from the buffer
+func getDiskState(b []byte) DiskState {
+	return DiskState{
+		NameOffset:       binary.LittleEndian.Uint32(b[0:]),
+		NameLength:       binary.LittleEndian.Uint32(b[4:]),
+		CodeOffset:       binary.LittleEndian.Uint32(b[8:]),
+		CodeLength:       binary.LittleEndian.Uint32(b[12:]),
+		Frequency:        int64(binary.LittleEndian.Uint64(b[16:])),
+		Flags:            binary.LittleEndian.Uint32(b[24:]),
+		FirstTranslation: binary.LittleEndian.Uint32(b[28:]),
+		TranslationCount: binary.LittleEndian.Uint32(b[32:]),
+		Kind:             binary.LittleEndian.Uint32(b[36:]),
+	}
+}
+
+// putDiskTranslation encodes the translation into the buffer
+func putDiskTranslation(b []byte, translation DiskTranslation) {
+	binary.LittleEndian.PutUint32(b[0:], translation.LocaleOffset)
+	binary.LittleEndian.PutUint32(b[4:], translation.LocaleLength)
+	binary.LittleEndian.PutUint32(b[8:], translation.NameOffset)
+	binary.LittleEndian.PutUint32(b[12:], translation.NameLength)
+}
+
+// getDiskTranslation decodes a translation from the buffer
+func getDiskTranslation(b []byte) DiskTranslation {
+	return DiskTranslation{
+		LocaleOffset: binary.LittleEndian.Uint32(b[0:]),
+		LocaleLength: binary.LittleEndian.Uint32(b[4:]),
+		NameOffset:   binary.LittleEndian.Uint32(b[8:]),
+		NameLength:   binary.LittleEndian.Uint32(b[12:]),
+	}
+}
+
+// inStringTable reports whether the string at offset with the given length lies within the string table
+func inStringTable(offset, length uint32, strsLength int) bool {
+	return uint64(offset)+uint64(length) <= uint64(strsLength)
+}
+
+// diskStateFlags returns the DiskState flags of the state
+func diskStateFlags(state *State) uint32 {
+	var flags uint32
+	if state.Enabled {
+		flags |= diskStateEnabled
+	}
+	return flags
+}
+
+// loadTrieSnapshot loads the trie from the snapshot file if it is younger than maxAge
+func loadTrieSnapshot(path string, maxAge time.Duration) (*TrieNode, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if age := time.Since(info.ModTime()); age > maxAge {
+		return nil, fmt.Errorf("snapshot is %s old, older than %s", age.Round(time.Second), maxAge)
+	}
+	return LoadTrieFromMmap(path)
+}
+
+// saveTrieSnapshot writes the trie to the snapshot file, logging any failure
+func saveTrieSnapshot(root *TrieNode, path string) {
+	if err := SaveTrieToMmap(root, path); err != nil {
+		log.Printf("Error saving trie snapshot to %s: %v", path, err)
+		return
+	}
+	log.Printf("Saved trie snapshot to %s", path)
+}
@@ -0,0 +1,48 @@
+package backend
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// useTestMongo points the package clients at the MongoDB server of MONGO_TEST_URI and config.MongoDB
+// at a fresh database, dropped once the test ends. Tests needing MongoDB are skipped when
+// MONGO_TEST_URI is unset.
+func useTestMongo(t *testing.T) *mongo.Database {
+	t.Helper()
+	uri := os.Getenv("MONGO_TEST_URI")
+	if uri == "" {
+		t.Skip("MONGO_TEST_URI is not set")
+	}
+	c, err := connectMongo(context.Background(), uri, 1, time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	previousClient, previousReadClient, previousDB := client, readClient, config.MongoDB
+	client, readClient = c, c
+	config.MongoDB = fmt.Sprintf("test_%d", time.Now().UnixNano())
+	db := c.Database(config.MongoDB)
+	t.Cleanup(func() {
+		db.Drop(context.Background())
+		c.Disconnect(context.Background())
+		client, readClient, config.MongoDB = previousClient, previousReadClient, previousDB
+	})
+	return db
+}
+
+// withUnreachableMongo points the package clients at a closed port, so the audit entries and other
+// best-effort writes of the code under test fail quickly instead of needing a server
+func withUnreachableMongo(t *testing.T) {
+	t.Helper()
+	previousClient, previousReadClient := client, readClient
+	client = newUnreachableClient(t, testPrimaryAddr)
+	readClient = client
+	t.Cleanup(func() {
+		client, readClient = previousClient, previousReadClient
+	})
+}
@@ -0,0 +1,113 @@
+package backend
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/graphql-go/graphql"
+)
+
+// defaultMultiSearchLimit caps the states returned across all prefixes of a multi-prefix search
+const defaultMultiSearchLimit = 100
+
+// PrefixResults holds the states matching one prefix of a multi-prefix search
+type PrefixResults struct {
+	Prefix string   `json:"prefix"`
+	States []*State `json:"states"`
+}
+
+// dedupePrefixes returns the prefixes without repeats, keeping the first occurrence of each
+func dedupePrefixes(prefixes []string) []string {
+	seen := make(map[string]bool, len(prefixes))
+	unique := make([]string, 0, len(prefixes))
+	for _, prefix := range prefixes {
+		if !seen[prefix] {
+			seen[prefix] = true
+			unique = append(unique, prefix)
+		}
+	}
+	return unique
+}
+
+// multiSearch searches the trie for every prefix in order, returning at most limit states in total.
+// Prefixes shorter than minLength, failing the input policy or searched after the limit is reached
+// get empty results.
+func multiSearch(ctx context.Context, root *TrieNode, prefixes []string, limit, minLength int) []PrefixResults {
+	prefixes = dedupePrefixes(prefixes)
+	results := make([]PrefixResults, 0, len(prefixes))
+	remaining := limit
+	for _, prefix := range prefixes {
+		if checkPrefixLength(prefix, minLength) != nil || inputPolicy.ValidateSearch(prefix) != nil {
+			results = append(results, PrefixResults{Prefix: prefix, States: []*State{}})
+			continue
+		}
+		states := searchStates(ctx, root, collationKey(prefix))
+		if len(states) > remaining {
+			states = states[:remaining]
+		}
+		remaining -= len(states)
+		results = append(results, PrefixResults{Prefix: prefix, States: states})
+	}
+	return results
+}
+
+// Define the GraphQL prefix results type
+var prefixResultsType = graphql.NewObject(graphql.ObjectConfig{
+	Name: "PrefixResults",
+	Fields: graphql.Fields{
+		"prefix": &graphql.Field{
+			Type: graphql.String,
+		},
+		"states": &graphql.Field{
+			Type: graphql.NewList(stateType),
+		},
+	},
+})
+
+// multiSearchField searches several prefixes in one request, keyed by prefix, updating the
+// frequency of every returned state
+var multiSearchField = &graphql.Field{
+	Type: graphql.NewList(prefixResultsType),
+	Args: graphql.FieldConfigArgument{
+		"searches": &graphql.ArgumentConfig{
+			Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(graphql.String))),
+		},
+		"limit": &graphql.ArgumentConfig{
+			Type:         graphql.Int,
+			Description:  "Maximum number of states returned across all prefixes",
+			DefaultValue: defaultMultiSearchLimit,
+		},
+		"minPrefixLength": &graphql.ArgumentConfig{
+			Type:        graphql.Int,
+			Description: "Overrides the configured minimum search length for this request",
+		},
+	},
+	Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+		prefixes := stringListArg(p.Args["searches"])
+		limit, _ := p.Args["limit"].(int)
+		if limit < 0 {
+			return nil, fmt.Errorf("limit must not be negative, got %d", limit)
+		}
+
+		start := time.Now()
+		tenantStore, err := storeFor(p.Context)
+		if err != nil {
+			return nil, err
+		}
+		results := multiSearch(p.Context, tenantStore.Root(), prefixes, limit, minPrefixLengthArg(p.Args["minPrefixLength"]))
+		latency := time.Since(start)
+		for _, result := range results {
+			if !checkSearchInput(p.Context, result.Prefix) {
+				continue
+			}
+			for _, state := range result.States {
+				updateFrequency(p.Context, tenantStore, state.Name)
+			}
+			recordSearch(p.Context, result.Prefix, len(result.States), latency)
+			analytics.Record(p.Context, result.Prefix, len(result.States))
+		}
+		logf(p.Context, "Searched %d prefixes in one request", len(results))
+		return results, nil
+	},
+}
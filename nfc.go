@@ -0,0 +1,65 @@
+package backend
+
+import (
+	"log"
+	"strings"
+
+	"github.com/graphql-go/graphql"
+	"golang.org/x/text/unicode/norm"
+)
+
+// nfc returns the text in Unicode normalization form C, so composed and decomposed forms of
+// "Café" compare equal
+func nfc(text string) string {
+	return norm.NFC.String(text)
+}
+
+// normalizeStateNames rewrites the name and translations of the state in NFC and reports whether
+// any of them changed
+func normalizeStateNames(state *State) bool {
+	changed := false
+	if name := nfc(state.Name); name != state.Name {
+		state.Name = name
+		changed = true
+	}
+	for locale, translation := range state.Translations {
+		if normalized := nfc(translation); normalized != translation {
+			state.Translations[locale] = normalized
+			changed = true
+		}
+	}
+	return changed
+}
+
+// normalizeState rewrites the names of the state in NFC and its code in uppercase, so states read
+// with codes differing only in case, like "ca" and "CA", are stored and matched alike
+func normalizeState(state *State) {
+	normalizeStateNames(state)
+	state.Code = strings.ToUpper(state.Code)
+}
+
+// normalizeNamesField rewrites the tenant's stored states whose names are not in NFC and rebuilds
+// its trie, returning the number of rewritten states
+var normalizeNamesField = &graphql.Field{
+	Type: graphql.Int,
+	Resolve: audited("normalizeNames", stateCountSnapshot, func(p graphql.ResolveParams) (interface{}, error) {
+		actor, err := requireAdmin(p.Context)
+		if err != nil {
+			return nil, err
+		}
+		tenantStore, err := storeFor(p.Context)
+		if err != nil {
+			return nil, err
+		}
+		fixed, err := tenantStore.Repository().NormalizeNames(p.Context)
+		if err != nil {
+			log.Printf("Error normalizing state names for %s: %v", actor, err)
+			return nil, err
+		}
+		if err := tenantStore.RebuildTrie(withPrimaryReads(p.Context)); err != nil {
+			return nil, err
+		}
+		log.Printf("Normalized state names for %s, Fixed: %d", actor, fixed)
+		return fixed, nil
+	}),
+}
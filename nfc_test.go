@@ -0,0 +1,127 @@
+package backend
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+const (
+	// quebecNFD and quebecNFC are "Québec" with the accent decomposed and composed
+	quebecNFD = "Que\u0301bec"
+	quebecNFC = "Québec"
+)
+
+func TestNormalizeNamesMigration(t *testing.T) {
+	withUnreachableMongo(t)
+	s := newTestStore(t,
+		State{Name: quebecNFD, Code: "qc", Enabled: true, Kind: KindProvince},
+		State{Name: "Mexico", Code: "MX", Enabled: true, Kind: KindState, Translations: map[string]string{"es": "Me\u0301xico"}},
+		State{Name: "Texas", Code: "TX", Enabled: true, Kind: KindState},
+	)
+	ctx := context.Background()
+
+	// names are normalized as they are loaded, whatever the repository holds
+	if names := searchNames(s.Root(), "Qué"); !reflect.DeepEqual(names, []string{quebecNFC}) {
+		t.Errorf("searching the composed Qué before the migration = %q, want Québec in NFC", names)
+	}
+	if _, err := s.Repository().FindByName(ctx, quebecNFC); err == nil {
+		t.Fatal("the repository already holds Québec in NFC before the migration")
+	}
+
+	result := runGraphQL(t, asAdmin(ctx, "ops"), `mutation { normalizeNames }`)
+	if len(result.Errors) > 0 {
+		t.Fatal(result.Errors)
+	}
+	if fixed := result.Data.(map[string]interface{})["normalizeNames"]; fixed != 2 {
+		t.Errorf("normalizeNames fixed %v states, want Québec and the translation of Mexico", fixed)
+	}
+	if state, err := s.Repository().FindByName(ctx, quebecNFC); err != nil || state.Name != quebecNFC {
+		t.Errorf("looking up Québec in NFC after the migration = %+v, %v", state, err)
+	}
+	if state, _ := s.Repository().FindByName(ctx, "Mexico"); state.Translations["es"] != "México" {
+		t.Errorf("Spanish name of Mexico after the migration = %q, want it in NFC", state.Translations["es"])
+	}
+	for _, name := range []string{quebecNFC, quebecNFD} {
+		if state := findState(s.Root(), name); state == nil || state.Name != quebecNFC {
+			t.Errorf("findState(%q) after the migration = %+v, want Québec in NFC", name, state)
+		}
+	}
+
+	// the migration is a no-op once every name is in NFC
+	result = runGraphQL(t, asAdmin(ctx, "ops"), `mutation { normalizeNames }`)
+	if fixed := result.Data.(map[string]interface{})["normalizeNames"]; fixed != 0 {
+		t.Errorf("normalizeNames fixed %v states on the second run, want 0", fixed)
+	}
+}
+
+func TestMongoNormalizeNames(t *testing.T) {
+	db := useTestMongo(t)
+	collection := db.Collection("states")
+	ctx := context.Background()
+	_, err := collection.InsertMany(ctx, []interface{}{
+		bson.M{"name": quebecNFD, "code": "QC"},
+		bson.M{"name": "Mexico", "code": "MX", "translations": bson.M{"es": "Me\u0301xico"}},
+		bson.M{"name": "Texas", "code": "TX"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	repo := NewMongoStateRepository(collection)
+	fixed, err := repo.NormalizeNames(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fixed != 2 {
+		t.Errorf("NormalizeNames fixed %d documents, want 2", fixed)
+	}
+	if state, err := repo.FindByName(ctx, quebecNFC); err != nil || state.Code != "QC" {
+		t.Errorf("looking up Québec in NFC after the migration = %+v, %v", state, err)
+	}
+	if state, err := repo.FindByName(ctx, "Mexico"); err != nil || state.Translations["es"] != "México" {
+		t.Errorf("Mexico after the migration = %+v, %v, want its Spanish name in NFC", state, err)
+	}
+	if count, _ := collection.CountDocuments(ctx, bson.M{"name": quebecNFD}); count != 0 {
+		t.Errorf("%d documents still hold Québec decomposed", count)
+	}
+}
+
+func TestStateCodesAreUppercased(t *testing.T) {
+	withUnreachableMongo(t)
+	s := newTestStore(t, State{Name: "California", Code: "ca", Enabled: true, Kind: KindState, Frequency: 10})
+	ctx := asAdmin(context.Background(), "ops")
+	if result := runGraphQL(t, ctx, `mutation { addState(name: "Texas", code: "tx") { name } }`); len(result.Errors) > 0 {
+		t.Fatal(result.Errors)
+	}
+
+	// codes stored in either case are found by searches in either case
+	for _, test := range []struct {
+		query string
+		want  string
+	}{
+		{`{ states(search: "CA", searchField: ["code"]) { name code } }`, `{"states":[{"code":"CA","name":"California"}]}`},
+		{`{ states(search: "ca", searchField: ["code"]) { name code } }`, `{"states":[{"code":"CA","name":"California"}]}`},
+		{`{ states(search: "TX", searchField: ["code"]) { name code } }`, `{"states":[{"code":"TX","name":"Texas"}]}`},
+		{`{ states(search: "tx", searchField: ["code"]) { name code } }`, `{"states":[{"code":"TX","name":"Texas"}]}`},
+		{`{ stateByCode(code: "CA") { name } }`, `{"stateByCode":{"name":"California"}}`},
+	} {
+		if got := resolveCodeQuery(t, test.query); got != test.want {
+			t.Errorf("%s = %s, want %s", test.query, got, test.want)
+		}
+	}
+	if state, _ := s.Repository().FindByName(context.Background(), "Texas"); state.Code != "TX" {
+		t.Errorf("code of Texas in the repository = %q, want TX", state.Code)
+	}
+
+	// syncing with a repository still holding the lowercase code changes nothing
+	changes, err := syncTrie(context.Background(), s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(changes.Inserted)+len(changes.Updated)+len(changes.Deleted) != 0 {
+		t.Errorf("sync after loading the lowercase code = %+v, want no changes", changes)
+	}
+}
@@ -0,0 +1,22 @@
+package backend
+
+// NormalizeFrequencies sets the normalized frequency of each result to its frequency divided by the
+// highest frequency of the results, so the most selected result is 1. Every result is 0 when none
+// was selected.
+func NormalizeFrequencies(results []*StateResult) []*StateResult {
+	var max int64
+	frequencies := make([]int64, len(results))
+	for i, result := range results {
+		frequencies[i] = result.loadFrequency()
+		if frequencies[i] > max {
+			max = frequencies[i]
+		}
+	}
+	for i, result := range results {
+		result.NormalizedFrequency = 0
+		if max > 0 {
+			result.NormalizedFrequency = float64(frequencies[i]) / float64(max)
+		}
+	}
+	return results
+}
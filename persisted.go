@@ -0,0 +1,238 @@
+package backend
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/language/parser"
+	"github.com/graphql-go/graphql/language/source"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// PersistedQueriesCollection is the MongoDB collection holding the registered queries
+const PersistedQueriesCollection = "persistedQueries"
+
+var (
+	// errPersistedQueryNotFound is returned when a query hash is not registered
+	errPersistedQueryNotFound = errors.New("persisted query not found")
+	// errPersistedQueryHashMismatch is returned when the sent hash does not match the sent query
+	errPersistedQueryHashMismatch = errors.New("persisted query hash does not match the query")
+	// errUnsupportedContentType is returned for request bodies persisted queries cannot be read from
+	errUnsupportedContentType = errors.New("unsupported content type")
+)
+
+// PersistedQuery is a query clients may send while unpersisted queries are not allowed
+type PersistedQuery struct {
+	Hash         string    `bson:"_id" json:"hash"`
+	Query        string    `bson:"query" json:"query"`
+	RegisteredAt time.Time `bson:"registeredAt" json:"registeredAt"`
+}
+
+// PersistedQueryStore looks up registered queries by hash, caching the ones found
+type PersistedQueryStore struct {
+	collection *mongo.Collection
+
+	mu      sync.RWMutex
+	queries map[string]string
+}
+
+var persistedQueries *PersistedQueryStore
+
+// HashQuery returns the hex-encoded SHA-256 hash of the query text
+func HashQuery(query string) string {
+	sum := sha256.Sum256([]byte(query))
+	return hex.EncodeToString(sum[:])
+}
+
+// ValidateQuery parses the query and validates it against the schema
+func ValidateQuery(schema *graphql.Schema, query string) error {
+	document, err := parser.Parse(parser.ParseParams{Source: source.NewSource(&source.Source{Body: []byte(query)})})
+	if err != nil {
+		return err
+	}
+	result := graphql.ValidateDocument(schema, document, nil)
+	if !result.IsValid {
+		return result.Errors[0]
+	}
+	return nil
+}
+
+// RegisterPersistedQuery upserts the query into the collection and returns its hash
+func RegisterPersistedQuery(ctx context.Context, collection *mongo.Collection, query string) (string, error) {
+	hash := HashQuery(query)
+	_, err := collection.UpdateOne(
+		ctx,
+		bson.M{"_id": hash},
+		bson.M{
+			"$set":         bson.M{"query": query},
+			"$setOnInsert": bson.M{"registeredAt": time.Now()},
+		},
+		options.Update().SetUpsert(true),
+	)
+	return hash, err
+}
+
+// FetchPersistedQueries returns every registered query
+func FetchPersistedQueries(ctx context.Context, collection *mongo.Collection) ([]PersistedQuery, error) {
+	cursor, err := collection.Find(ctx, bson.M{})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	queries := []PersistedQuery{}
+	if err := cursor.All(ctx, &queries); err != nil {
+		return nil, err
+	}
+	return queries, nil
+}
+
+// NewPersistedQueryStore creates a store reading registered queries from the collection
+func NewPersistedQueryStore(collection *mongo.Collection) *PersistedQueryStore {
+	return &PersistedQueryStore{collection: collection, queries: make(map[string]string)}
+}
+
+// Lookup returns the registered query with the given hash
+func (s *PersistedQueryStore) Lookup(ctx context.Context, hash string) (string, error) {
+	s.mu.RLock()
+	query, ok := s.queries[hash]
+	s.mu.RUnlock()
+	if ok {
+		return query, nil
+	}
+
+	var persisted PersistedQuery
+	err := s.collection.FindOne(ctx, bson.M{"_id": hash}).Decode(&persisted)
+	if err == mongo.ErrNoDocuments {
+		return "", errPersistedQueryNotFound
+	}
+	if err != nil {
+		return "", err
+	}
+
+	s.mu.Lock()
+	s.queries[hash] = persisted.Query
+	s.mu.Unlock()
+	return persisted.Query, nil
+}
+
+// resolve returns the query to execute for a request sending the query text, its hash or both.
+// Requests sending neither are passed through unchanged.
+func (s *PersistedQueryStore) resolve(ctx context.Context, query, hash string) (string, error) {
+	if query == "" && hash == "" {
+		return "", nil
+	}
+	if query == "" {
+		return s.Lookup(ctx, hash)
+	}
+	computed := HashQuery(query)
+	if hash != "" && hash != computed {
+		return "", errPersistedQueryHashMismatch
+	}
+	if _, err := s.Lookup(ctx, computed); err != nil {
+		return "", err
+	}
+	return query, nil
+}
+
+// persistedQueryHash reads the hash from the persistedQuery entry of the request extensions
+func persistedQueryHash(extensions interface{}) string {
+	values, _ := extensions.(map[string]interface{})
+	persistedQuery, _ := values["persistedQuery"].(map[string]interface{})
+	hash, _ := persistedQuery["sha256Hash"].(string)
+	return hash
+}
+
+// withPersistedQueries only lets registered queries through unless unpersisted queries are allowed.
+// Requests may send the query text, its hash in extensions.persistedQuery.sha256Hash or both.
+func withPersistedQueries(allowUnpersisted bool, queries *PersistedQueryStore, next http.Handler) http.Handler {
+	if allowUnpersisted {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var err error
+		if r.Method == http.MethodGet {
+			err = resolveQueryParams(r, queries)
+		} else {
+			err = resolveQueryBody(r, queries)
+		}
+		if err != nil {
+			if err != errPersistedQueryNotFound && err != errPersistedQueryHashMismatch && err != errUnsupportedContentType {
+				log.Printf("Error looking up persisted query: %v", err)
+			}
+			writeJSON(w, http.StatusBadRequest, map[string]interface{}{
+				"errors": []map[string]string{{"message": err.Error()}},
+			})
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// resolveQueryParams resolves the query sent in the URL of a GET request
+func resolveQueryParams(r *http.Request, queries *PersistedQueryStore) error {
+	values := r.URL.Query()
+	var extensions interface{}
+	if raw := values.Get("extensions"); raw != "" {
+		if err := json.Unmarshal([]byte(raw), &extensions); err != nil {
+			return fmt.Errorf("invalid extensions: %w", err)
+		}
+	}
+	query, err := queries.resolve(r.Context(), values.Get("query"), persistedQueryHash(extensions))
+	if err != nil || query == "" {
+		return err
+	}
+	values.Set("query", query)
+	r.URL.RawQuery = values.Encode()
+	return nil
+}
+
+// resolveQueryBody resolves the query sent in the body of a POST request, rewriting the body
+// when the query was sent by hash only
+func resolveQueryBody(r *http.Request, queries *PersistedQueryStore) error {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		return err
+	}
+	r.Body = io.NopCloser(bytes.NewReader(body))
+
+	contentType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	switch contentType {
+	case "application/graphql":
+		_, err := queries.resolve(r.Context(), string(body), "")
+		return err
+	case "application/json":
+		var request map[string]interface{}
+		if err := json.Unmarshal(body, &request); err != nil {
+			return fmt.Errorf("invalid request body: %w", err)
+		}
+		sent, _ := request["query"].(string)
+		query, err := queries.resolve(r.Context(), sent, persistedQueryHash(request["extensions"]))
+		if err != nil || query == sent {
+			return err
+		}
+		request["query"] = query
+		if body, err = json.Marshal(request); err != nil {
+			return err
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+		r.ContentLength = int64(len(body))
+		return nil
+	default:
+		return errUnsupportedContentType
+	}
+}
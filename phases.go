@@ -0,0 +1,122 @@
+package backend
+
+import (
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// StartupPhase is a step of loading the server before it serves traffic
+type StartupPhase string
+
+const (
+	// PhaseStarting is reported until Load starts
+	PhaseStarting StartupPhase = "starting"
+	// PhaseConnecting connects to MongoDB, retrying while it is unreachable
+	PhaseConnecting StartupPhase = "connecting"
+	// PhaseLoading loads the default tenant's states into the trie
+	PhaseLoading StartupPhase = "loading"
+	// PhaseWarming warms the search cache with the popular prefixes and prepares the search provider
+	PhaseWarming StartupPhase = "warming"
+	// PhaseReady serves traffic
+	PhaseReady StartupPhase = "ready"
+)
+
+// PhaseProgress is the time spent in one startup phase so far
+type PhaseProgress struct {
+	Phase   StartupPhase `json:"phase"`
+	Elapsed string       `json:"elapsed"`
+	Done    bool         `json:"done"`
+}
+
+// StartupStatus is the current startup phase, the states loaded into the trie and the time spent
+// in each phase entered
+type StartupStatus struct {
+	Phase        StartupPhase    `json:"phase"`
+	StatesLoaded int             `json:"statesLoaded"`
+	Phases       []PhaseProgress `json:"phases"`
+}
+
+// StartupProgress tracks the startup phases, so readiness is only reported once MongoDB is
+// connected, the trie loaded and the search cache warmed
+type StartupProgress struct {
+	now func() time.Time
+
+	mu           sync.Mutex
+	phases       []StartupPhase
+	entered      []time.Time
+	statesLoaded int
+	// ready is 1 once PhaseReady is entered, read without the mutex on every request
+	ready int32
+}
+
+// startup tracks the phases of Load
+var startup = NewStartupProgress(time.Now)
+
+// NewStartupProgress creates the progress of a server that has not started loading
+func NewStartupProgress(now func() time.Time) *StartupProgress {
+	return &StartupProgress{now: now}
+}
+
+// Enter ends the current phase and starts the given one
+func (p *StartupProgress) Enter(phase StartupPhase) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.phases = append(p.phases, phase)
+	p.entered = append(p.entered, p.now())
+	if phase == PhaseReady {
+		atomic.StoreInt32(&p.ready, 1)
+	}
+}
+
+// SetStatesLoaded records the number of states loaded into the trie
+func (p *StartupProgress) SetStatesLoaded(count int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.statesLoaded = count
+}
+
+// Ready reports whether every phase before PhaseReady completed
+func (p *StartupProgress) Ready() bool {
+	return atomic.LoadInt32(&p.ready) == 1
+}
+
+// Status returns the current phase and the time spent in each phase before PhaseReady; the current
+// one is still counting
+func (p *StartupProgress) Status() StartupStatus {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	status := StartupStatus{Phase: PhaseStarting, StatesLoaded: p.statesLoaded, Phases: []PhaseProgress{}}
+	for i, phase := range p.phases {
+		status.Phase = phase
+		if phase == PhaseReady {
+			continue
+		}
+		progress := PhaseProgress{Phase: phase}
+		end := p.now()
+		if i+1 < len(p.phases) {
+			end = p.entered[i+1]
+			progress.Done = true
+		}
+		progress.Elapsed = end.Sub(p.entered[i]).String()
+		status.Phases = append(status.Phases, progress)
+	}
+	return status
+}
+
+// withStartup answers requests with 503 Service Unavailable until Load has finished, except the
+// readiness check and metrics, so requests reaching the open port early never see an empty trie
+func withStartup(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !startup.Ready() && r.URL.Path != readyPath && r.URL.Path != "/metrics" {
+			w.Header().Set("Retry-After", standbyRetryAfter)
+			writeJSON(w, http.StatusServiceUnavailable, map[string]string{
+				"error": "server is starting",
+				"phase": string(startup.Status().Phase),
+			})
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,104 @@
+package backend
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestReadinessFollowsStartupPhases(t *testing.T) {
+	s := newTestStore(t,
+		State{Name: "Texas", Code: "TX", Enabled: true, Kind: KindState},
+		State{Name: "Tennessee", Code: "TN", Enabled: true, Kind: KindState},
+	)
+	previous := startup
+	t.Cleanup(func() { startup = previous })
+	clock := &testClock{now: time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)}
+	startup = NewStartupProgress(clock.Now)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc(readyPath, readyHandler)
+	routeAPI(mux)
+	handler := withStartup(mux)
+	serve := func(method, path, body string) *httptest.ResponseRecorder {
+		request := httptest.NewRequest(method, path, strings.NewReader(body))
+		request.Header.Set("Content-Type", "application/json")
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, request)
+		return recorder
+	}
+	ready := func() (int, readiness) {
+		response := serve(http.MethodGet, readyPath, "")
+		var status readiness
+		if err := json.Unmarshal(response.Body.Bytes(), &status); err != nil {
+			t.Fatal(err)
+		}
+		return response.Code, status
+	}
+	search := `{"query": "{ states(search: \"T\") { name } }"}`
+
+	// a fake loader stepping through the phases Load enters, taking a while in each
+	steps := []struct {
+		phase    StartupPhase
+		duration time.Duration
+	}{
+		{PhaseConnecting, 2 * time.Second},
+		{PhaseLoading, 5 * time.Second},
+		{PhaseWarming, time.Second},
+	}
+	if code, status := ready(); code != http.StatusServiceUnavailable || status.Status != "starting" || status.Phase != PhaseStarting {
+		t.Errorf("readiness before loading = %d %+v, want 503 starting", code, status)
+	}
+	for _, step := range steps {
+		startup.Enter(step.phase)
+		if step.phase == PhaseLoading {
+			startup.SetStatesLoaded(len(s.States()))
+		}
+		clock.now = clock.now.Add(step.duration)
+		if code, status := ready(); code != http.StatusServiceUnavailable || status.Status != "starting" || status.Phase != step.phase {
+			t.Errorf("readiness while %s = %d %+v, want 503 starting", step.phase, code, status)
+		}
+		if response := serve(http.MethodPost, config.GraphQLPath, search); response.Code != http.StatusServiceUnavailable || response.Header().Get("Retry-After") == "" ||
+			!strings.Contains(response.Body.String(), string(step.phase)) {
+			t.Errorf("search while %s = %d %s, want 503 with the phase", step.phase, response.Code, response.Body)
+		}
+	}
+
+	startup.Enter(PhaseReady)
+	clock.now = clock.now.Add(time.Minute)
+	code, status := ready()
+	if code != http.StatusOK || status.Status != "active" || status.Phase != PhaseReady || status.StatesLoaded != 2 {
+		t.Errorf("readiness once ready = %d %+v, want 200 active with 2 states loaded", code, status)
+	}
+	// the time in each phase stops counting once the next is entered
+	want := []PhaseProgress{
+		{PhaseConnecting, "2s", true},
+		{PhaseLoading, "5s", true},
+		{PhaseWarming, "1s", true},
+	}
+	if !reflect.DeepEqual(status.Phases, want) {
+		t.Errorf("phases once ready = %+v, want %+v", status.Phases, want)
+	}
+	if response := serve(http.MethodPost, config.GraphQLPath, search); response.Code != http.StatusOK || !strings.Contains(response.Body.String(), `"Texas"`) {
+		t.Errorf("search once ready = %d %s, want Texas", response.Code, response.Body)
+	}
+}
+
+func TestStartupStatusCountsCurrentPhase(t *testing.T) {
+	clock := &testClock{now: time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)}
+	progress := NewStartupProgress(clock.Now)
+	progress.Enter(PhaseConnecting)
+	clock.now = clock.now.Add(3 * time.Second)
+	want := StartupStatus{Phase: PhaseConnecting, Phases: []PhaseProgress{{PhaseConnecting, "3s", false}}}
+	if status := progress.Status(); !reflect.DeepEqual(status, want) || progress.Ready() {
+		t.Errorf("status while connecting = %+v, want %+v", status, want)
+	}
+	clock.now = clock.now.Add(4 * time.Second)
+	if status := progress.Status(); status.Phases[0].Elapsed != "7s" {
+		t.Errorf("elapsed time of the current phase = %s, want 7s", status.Phases[0].Elapsed)
+	}
+}
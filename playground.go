@@ -0,0 +1,97 @@
+package backend
+
+import (
+	"html/template"
+	"log"
+	"net/http"
+)
+
+const (
+	// playgroundPath is where the GraphiQL playground is served
+	playgroundPath = "/playground"
+	// playgroundTitle is the title of the playground page
+	playgroundTitle = "VA State Autocomplete Playground"
+	// playgroundVariables are preloaded with the default query, whose States operation needs them
+	playgroundVariables = `{"search": "New"}`
+)
+
+// playgroundPage holds the values injected into the playground template
+type playgroundPage struct {
+	Title     string
+	Endpoint  string
+	Query     string
+	Variables string
+}
+
+// playgroundTemplate embeds GraphiQL in a dark themed page. Queries and variables given in the URL
+// replace the injected defaults.
+var playgroundTemplate = template.Must(template.New("playground").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+  <meta charset="utf-8" />
+  <title>{{.Title}}</title>
+  <link rel="stylesheet" href="https://cdn.jsdelivr.net/npm/graphiql@2.4.7/graphiql.min.css" />
+  <style>
+    html, body, #playground { height: 100%; margin: 0; }
+    body { background: #1e1e2e; color: #cdd6f4; font-family: system-ui, sans-serif; }
+    .graphiql-container { --color-base: 232, 30%, 15%; }
+  </style>
+</head>
+<body>
+  <div id="playground"></div>
+  <script src="https://cdn.jsdelivr.net/npm/react@18.2.0/umd/react.production.min.js"></script>
+  <script src="https://cdn.jsdelivr.net/npm/react-dom@18.2.0/umd/react-dom.production.min.js"></script>
+  <script src="https://cdn.jsdelivr.net/npm/graphiql@2.4.7/graphiql.min.js"></script>
+  <script>
+    if (!localStorage.getItem("graphiql:theme")) {
+      localStorage.setItem("graphiql:theme", "dark");
+    }
+    var params = new URLSearchParams(window.location.search);
+    var root = ReactDOM.createRoot(document.getElementById("playground"));
+    root.render(React.createElement(GraphiQL, {
+      fetcher: GraphiQL.createFetcher({ url: {{.Endpoint}} }),
+      defaultQuery: {{.Query}},
+      query: params.get("query") || undefined,
+      variables: params.get("variables") || {{.Variables}},
+      operationName: params.get("operationName") || undefined,
+    }));
+  </script>
+</body>
+</html>
+`))
+
+// playgroundHandler serves the playground for the GraphQL API at the endpoint, preloading the
+// default query
+func playgroundHandler(endpoint, defaultQuery string) http.Handler {
+	page := playgroundPage{Title: playgroundTitle, Endpoint: endpoint, Query: defaultQuery}
+	if defaultQuery == defaultGraphiQLQuery {
+		page.Variables = playgroundVariables
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			w.Header().Set("Allow", "GET, HEAD")
+			http.Error(w, "the playground is only served over GET", http.StatusMethodNotAllowed)
+			return
+		}
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		if err := playgroundTemplate.Execute(w, page); err != nil {
+			log.Printf("Error rendering playground: %v", err)
+		}
+	})
+}
+
+// withPlaygroundRedirect sends browsers opening the GraphQL endpoint to the playground, keeping the
+// query, variables and operation they asked for
+func withPlaygroundRedirect(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet && isGraphiQLRequest(r) {
+			target := playgroundPath
+			if r.URL.RawQuery != "" {
+				target += "?" + r.URL.RawQuery
+			}
+			http.Redirect(w, r, target, http.StatusFound)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,133 @@
+package backend
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/graphql-go/graphql"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	// popularPrefixesCollection holds the most searched prefixes the search cache is warmed with
+	popularPrefixesCollection = "popularPrefixes"
+	// maxPopularPrefixes caps the number of prefixes kept in popularPrefixes
+	maxPopularPrefixes = 200
+)
+
+// PopularPrefix is a prefix searched often enough to have its results cached on startup
+type PopularPrefix struct {
+	Prefix    string `bson:"prefix" json:"prefix"`
+	Frequency int    `bson:"frequency" json:"frequency"`
+}
+
+// recordPrefixPopularity counts a search of the prefix towards the popular prefixes, unless the
+// analytics mode forbids storing raw prefixes
+func recordPrefixPopularity(prefix string) {
+	if analytics.StoresRawPrefixes() {
+		prefixCounter.Record(prefix)
+	}
+}
+
+// computePopularPrefixes replaces the popularPrefixes collection with the most searched prefixes
+// counted in prefixStats, returning the number written
+func computePopularPrefixes(ctx context.Context) (int, error) {
+	stats, err := fetchTopPrefixes(maxPopularPrefixes)
+	if err != nil {
+		return 0, err
+	}
+	collection := client.Database(config.MongoDB).Collection(popularPrefixesCollection)
+	prefixes := make([]string, 0, len(stats))
+	models := make([]mongo.WriteModel, 0, len(stats))
+	for _, stat := range stats {
+		prefixes = append(prefixes, stat.Prefix)
+		models = append(models, mongo.NewReplaceOneModel().
+			SetFilter(bson.M{"prefix": stat.Prefix}).
+			SetReplacement(PopularPrefix{Prefix: stat.Prefix, Frequency: stat.Count}).
+			SetUpsert(true))
+	}
+	if len(models) > 0 {
+		if _, err := collection.BulkWrite(ctx, models, options.BulkWrite().SetOrdered(false)); err != nil {
+			return 0, err
+		}
+	}
+	if _, err := collection.DeleteMany(ctx, bson.M{"prefix": bson.M{"$nin": prefixes}}); err != nil {
+		return 0, err
+	}
+	return len(stats), nil
+}
+
+// fetchPopularPrefixes returns the popular prefixes, most searched first
+func fetchPopularPrefixes(ctx context.Context) ([]string, error) {
+	opts := options.Find().
+		SetSort(bson.D{{Key: "frequency", Value: -1}, {Key: "prefix", Value: 1}}).
+		SetLimit(maxPopularPrefixes)
+	cursor, err := client.Database(config.MongoDB).Collection(popularPrefixesCollection).Find(ctx, bson.M{}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var popular []PopularPrefix
+	if err := cursor.All(ctx, &popular); err != nil {
+		return nil, err
+	}
+	prefixes := make([]string, 0, len(popular))
+	for _, p := range popular {
+		prefixes = append(prefixes, p.Prefix)
+	}
+	return prefixes, nil
+}
+
+// loadPopularPrefixes warms the default tenant's search cache with the popular prefixes, and again
+// whenever its trie is swapped
+func loadPopularPrefixes() {
+	prefixes, err := fetchPopularPrefixes(context.Background())
+	if err != nil {
+		log.Printf("Error loading popular prefixes: %v", err)
+		return
+	}
+	store.Cache().SetWarmPrefixes(prefixes)
+	warmSearchCache(store)
+	log.Printf("Warmed search cache with %d popular prefixes", len(prefixes))
+}
+
+// startPopularPrefixJob recomputes the popular prefixes once per interval in the background
+func startPopularPrefixJob(interval time.Duration) {
+	if interval <= 0 {
+		return
+	}
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for range ticker.C {
+			count, err := computePopularPrefixes(context.Background())
+			if err != nil {
+				log.Printf("Error computing popular prefixes: %v", err)
+				continue
+			}
+			log.Printf("Computed %d popular prefixes", count)
+		}
+	}()
+}
+
+// computePopularPrefixesField recomputes the popular prefixes, returning the number written
+var computePopularPrefixesField = &graphql.Field{
+	Type: graphql.Int,
+	Resolve: audited("computePopularPrefixes", nil, func(p graphql.ResolveParams) (interface{}, error) {
+		actor, err := requireAdmin(p.Context)
+		if err != nil {
+			return nil, err
+		}
+		count, err := computePopularPrefixes(p.Context)
+		if err != nil {
+			log.Printf("Error computing popular prefixes for %s: %v", actor, err)
+			return nil, err
+		}
+		log.Printf("Computed %d popular prefixes for %s", count, actor)
+		return count, nil
+	}),
+}
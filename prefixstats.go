@@ -0,0 +1,217 @@
+package backend
+
+import (
+	"context"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/graphql-go/graphql"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	// maxTrackedPrefixLen caps the number of runes counted per prefix
+	maxTrackedPrefixLen = 10
+	// maxTrackedPrefixes caps the number of distinct prefixes counted between flushes
+	maxTrackedPrefixes = 5000
+	// prefixFlushInterval is how often the prefix counts are flushed to MongoDB
+	prefixFlushInterval = time.Minute
+	// defaultTopPrefixLimit is the number of prefixes returned when no limit is given
+	defaultTopPrefixLimit = 20
+)
+
+// PrefixStat represents how often a normalized prefix was searched
+type PrefixStat struct {
+	Prefix string `bson:"prefix" json:"prefix"`
+	Count  int    `bson:"count" json:"count"`
+}
+
+// PrefixCounter counts searches per normalized prefix between flushes
+type PrefixCounter struct {
+	mu     sync.Mutex
+	max    int
+	counts map[string]int
+}
+
+var prefixCounter = NewPrefixCounter(maxTrackedPrefixes)
+
+// NewPrefixCounter creates a counter tracking at most max distinct prefixes
+func NewPrefixCounter(max int) *PrefixCounter {
+	return &PrefixCounter{
+		max:    max,
+		counts: make(map[string]int),
+	}
+}
+
+// normalizePrefix lowercases and trims a search prefix
+func normalizePrefix(prefix string) string {
+	return strings.ToLower(strings.TrimSpace(prefix))
+}
+
+// truncateRunes caps the string at the given number of runes
+func truncateRunes(s string, n int) string {
+	runes := []rune(s)
+	if len(runes) > n {
+		return string(runes[:n])
+	}
+	return s
+}
+
+// Record counts one search of the prefix
+func (c *PrefixCounter) Record(prefix string) {
+	normalized := truncateRunes(normalizePrefix(prefix), maxTrackedPrefixLen)
+	if normalized == "" {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.counts[normalized]++
+	if len(c.counts) > c.max {
+		c.evictLongTail()
+	}
+}
+
+// evictLongTail drops the least searched prefixes until a quarter of the capacity is free again
+func (c *PrefixCounter) evictLongTail() {
+	stats := make([]PrefixStat, 0, len(c.counts))
+	for prefix, count := range c.counts {
+		stats = append(stats, PrefixStat{Prefix: prefix, Count: count})
+	}
+	sortPrefixStats(stats)
+	for _, stat := range stats[c.max*3/4:] {
+		delete(c.counts, stat.Prefix)
+	}
+}
+
+// Drain returns the counts recorded since the last drain and resets them
+func (c *PrefixCounter) Drain() map[string]int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	counts := c.counts
+	c.counts = make(map[string]int)
+	return counts
+}
+
+// Pending returns a copy of the counts recorded since the last drain
+func (c *PrefixCounter) Pending() map[string]int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	counts := make(map[string]int, len(c.counts))
+	for prefix, count := range c.counts {
+		counts[prefix] = count
+	}
+	return counts
+}
+
+// sortPrefixStats sorts prefix stats by count, breaking ties alphabetically
+func sortPrefixStats(stats []PrefixStat) {
+	sort.Slice(stats, func(i, j int) bool {
+		if stats[i].Count != stats[j].Count {
+			return stats[i].Count > stats[j].Count
+		}
+		return stats[i].Prefix < stats[j].Prefix
+	})
+}
+
+// flushPrefixStats adds the pending prefix counts to the prefixStats collection
+func flushPrefixStats() {
+	counts := prefixCounter.Drain()
+	if len(counts) == 0 {
+		return
+	}
+
+	models := make([]mongo.WriteModel, 0, len(counts))
+	for prefix, count := range counts {
+		models = append(models, mongo.NewUpdateOneModel().
+			SetFilter(bson.M{"prefix": prefix}).
+			SetUpdate(bson.M{"$inc": bson.M{"count": count}}).
+			SetUpsert(true))
+	}
+	collection := client.Database(config.MongoDB).Collection("prefixStats")
+	_, err := collection.BulkWrite(context.Background(), models, options.BulkWrite().SetOrdered(false))
+	if err != nil {
+		log.Printf("Error flushing %d prefix stats: %v", len(counts), err)
+		return
+	}
+	log.Printf("Flushed %d prefix stats", len(counts))
+}
+
+// startPrefixStatsFlusher periodically flushes the prefix counts in the background
+func startPrefixStatsFlusher() {
+	go func() {
+		ticker := time.NewTicker(prefixFlushInterval)
+		defer ticker.Stop()
+		for range ticker.C {
+			flushPrefixStats()
+		}
+	}()
+}
+
+// fetchTopPrefixes returns the most searched prefixes including the counts not yet flushed
+func fetchTopPrefixes(limit int) ([]PrefixStat, error) {
+	pending := prefixCounter.Pending()
+	opts := options.Find().
+		SetSort(bson.D{{Key: "count", Value: -1}, {Key: "prefix", Value: 1}}).
+		SetLimit(int64(limit + len(pending)))
+
+	collection := client.Database(config.MongoDB).Collection("prefixStats")
+	cursor, err := collection.Find(context.Background(), bson.M{}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(context.Background())
+
+	var stored []PrefixStat
+	if err := cursor.All(context.Background(), &stored); err != nil {
+		return nil, err
+	}
+	for _, stat := range stored {
+		pending[stat.Prefix] += stat.Count
+	}
+
+	stats := make([]PrefixStat, 0, len(pending))
+	for prefix, count := range pending {
+		stats = append(stats, PrefixStat{Prefix: prefix, Count: count})
+	}
+	sortPrefixStats(stats)
+	if len(stats) > limit {
+		stats = stats[:limit]
+	}
+	return stats, nil
+}
+
+// Define the GraphQL prefix stat type
+var prefixStatType = graphql.NewObject(graphql.ObjectConfig{
+	Name: "PrefixStat",
+	Fields: graphql.Fields{
+		"prefix": &graphql.Field{
+			Type: graphql.String,
+		},
+		"count": &graphql.Field{
+			Type: graphql.Int,
+		},
+	},
+})
+
+// topPrefixesField lists the most searched normalized prefixes
+var topPrefixesField = &graphql.Field{
+	Type: graphql.NewList(prefixStatType),
+	Args: graphql.FieldConfigArgument{
+		"limit": &graphql.ArgumentConfig{
+			Type: graphql.Int,
+		},
+	},
+	Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+		limit, ok := p.Args["limit"].(int)
+		if !ok || limit <= 0 {
+			limit = defaultTopPrefixLimit
+		}
+		return fetchTopPrefixes(limit)
+	},
+}
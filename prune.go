@@ -0,0 +1,204 @@
+package backend
+
+import (
+	"context"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// prunedState is a state pruned from a trie, remembered by name so it can be reloaded
+type prunedState struct {
+	key  string
+	name string
+}
+
+// prunedStates are the states pruned from a store's trie to bound its memory, sorted by case-folded
+// name so a search can find the ones it would match
+type prunedStates struct {
+	mu      sync.Mutex
+	entries []prunedState
+	names   map[string]bool
+}
+
+// reset replaces the pruned states with the given ones
+func (p *prunedStates) reset(states []*State) {
+	p.mu.Lock()
+	p.entries, p.names = nil, nil
+	p.mu.Unlock()
+	p.add(states)
+}
+
+// add remembers the states as pruned
+func (p *prunedStates) add(states []*State) {
+	if len(states) == 0 {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.names == nil {
+		p.names = make(map[string]bool)
+	}
+	for _, state := range states {
+		if !p.names[state.Name] {
+			p.entries = append(p.entries, prunedState{key: foldKey(state.Name), name: state.Name})
+			p.names[state.Name] = true
+		}
+	}
+	sort.Slice(p.entries, func(i, j int) bool {
+		return p.entries[i].key < p.entries[j].key
+	})
+}
+
+// count returns the number of pruned states
+func (p *prunedStates) count() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return len(p.entries)
+}
+
+// remove forgets the named states
+func (p *prunedStates) remove(names []string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	removed := make(map[string]bool, len(names))
+	for _, name := range names {
+		removed[name] = true
+		delete(p.names, name)
+	}
+	kept := p.entries[:0]
+	for _, entry := range p.entries {
+		if !removed[entry.name] {
+			kept = append(kept, entry)
+		}
+	}
+	p.entries = kept
+}
+
+// contains reports whether the named state is pruned
+func (p *prunedStates) contains(name string) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.names[name]
+}
+
+// matching returns the names of the pruned states whose case-folded name starts with the key
+func (p *prunedStates) matching(key string) []string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	var names []string
+	i := sort.Search(len(p.entries), func(i int) bool { return p.entries[i].key >= key })
+	for ; i < len(p.entries) && strings.HasPrefix(p.entries[i].key, key); i++ {
+		names = append(names, p.entries[i].name)
+	}
+	return names
+}
+
+// pruneTrie removes the least frequently selected states from the trie until it has at most
+// maxNodes nodes, returning the removed states. A maxNodes of 0 prunes nothing.
+func pruneTrie(root *TrieNode, maxNodes int) []*State {
+	if maxNodes <= 0 {
+		return nil
+	}
+	nodes := countNodes(root)
+	if nodes <= maxNodes {
+		return nil
+	}
+	var states []*State
+	collectStates(context.Background(), root, &states)
+	sort.Slice(states, func(i, j int) bool {
+		first, second := states[i].loadFrequency(), states[j].loadFrequency()
+		if first != second {
+			return first < second
+		}
+		return states[i].Name < states[j].Name
+	})
+	var pruned []*State
+	for _, state := range states {
+		if nodes <= maxNodes {
+			break
+		}
+		nodes -= removeKey(root, collationKey(state.Name), state)
+		pruned = append(pruned, state)
+	}
+	return pruned
+}
+
+// pruneStore prunes the store's trie down to maxNodes nodes on a copy swapped in once complete,
+// returning the number of states pruned. The pruned states stay in the repository.
+func pruneStore(s *TrieStore, maxNodes int) int {
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+	if maxNodes <= 0 || countNodes(s.Root()) <= maxNodes {
+		return 0
+	}
+	next := cloneTrie(s.Root())
+	pruned := pruneTrie(next, maxNodes)
+	s.pruned.add(pruned)
+	s.Swap(next)
+	return len(pruned)
+}
+
+// restorePruned reloads from the repository the pruned states of the store whose names start with
+// the search in any case, and inserts them back into its trie so the search finds them. Empty and
+// wildcard searches reload nothing, and pruned states are not reloaded for searches matching their
+// code or a word other than the first. The write lock is only taken when a pruned state matches,
+// so searches of a trie with nothing pruned never wait for writers.
+func restorePruned(ctx context.Context, s *TrieStore, search string) error {
+	if search == "" || hasWildcard(search) {
+		return nil
+	}
+	key := foldKey(search)
+	if len(s.pruned.matching(key)) == 0 {
+		return nil
+	}
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+	// Another search may have restored them while this one waited for the lock
+	names := s.pruned.matching(key)
+	if len(names) == 0 {
+		return nil
+	}
+	var restored []*State
+	for _, name := range names {
+		state, err := s.Repository().FindByName(ctx, name)
+		if err == errStateNotFound {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		normalizeState(state)
+		restored = append(restored, state)
+	}
+	if err := addFrequencies(ctx, s.Frequencies(), restored); err != nil {
+		return err
+	}
+	s.pruned.remove(names)
+	if len(restored) > 0 {
+		applyStateChanges(ctx, s, stateChanges{Inserted: restored})
+		logf(ctx, "Reloaded %d pruned states for: %s", len(restored), search)
+	}
+	return nil
+}
+
+// startTriePruner periodically prunes the trie of every loaded tenant down to maxNodes nodes,
+// evicting the least frequently selected states first. A maxNodes of 0 disables pruning.
+func startTriePruner(interval time.Duration, maxNodes int) {
+	if interval <= 0 || maxNodes <= 0 {
+		return
+	}
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for range ticker.C {
+			for tenantID, tenantStore := range tenants.Loaded() {
+				if pruned := pruneStore(tenantStore, maxNodes); pruned > 0 {
+					log.Printf("Pruned %d states from the trie of tenant %s", pruned, tenantID)
+				}
+			}
+		}
+	}()
+}
@@ -0,0 +1,80 @@
+package backend
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestPruneStoreEvictsLeastFrequentStates(t *testing.T) {
+	s := newTestStore(t,
+		State{Name: "Texas", Code: "TX", Frequency: 9, Enabled: true, Kind: KindState},
+		State{Name: "Utah", Code: "UT", Frequency: 1, Enabled: true, Kind: KindState},
+		State{Name: "Ohio", Code: "OH", Frequency: 5, Enabled: true, Kind: KindState},
+		State{Name: "Iowa", Code: "IA", Frequency: 0, Enabled: true, Kind: KindState},
+	)
+	// the root and the five nodes of texas
+	if pruned := pruneStore(s, 6); pruned != 3 {
+		t.Fatalf("pruned %d states, want 3", pruned)
+	}
+	if findState(s.Root(), "Texas") == nil {
+		t.Error("the most frequent state was pruned")
+	}
+	for _, name := range []string{"Utah", "Ohio", "Iowa"} {
+		if findState(s.Root(), name) != nil {
+			t.Errorf("state %q was not pruned", name)
+		}
+		if !s.pruned.contains(name) {
+			t.Errorf("pruned state %q is not remembered", name)
+		}
+	}
+	if pruned := pruneStore(s, 6); pruned != 0 {
+		t.Errorf("pruned %d more states from a trie within the cap", pruned)
+	}
+}
+
+func TestSearchRestoresPrunedStates(t *testing.T) {
+	s := newTestStore(t,
+		State{Name: "Texas", Code: "TX", Frequency: 9, Enabled: true, Kind: KindState},
+		State{Name: "Utah", Code: "UT", Frequency: 1, Enabled: true, Kind: KindState},
+		State{Name: "Ohio", Code: "OH", Frequency: 5, Enabled: true, Kind: KindState},
+	)
+	pruneStore(s, 6)
+
+	results, err := TrieSearchProvider{}.Search(context.Background(), "ut", searchOptions{IgnoreCase: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 1 || results[0].Name != "Utah" || results[0].loadFrequency() != 1 {
+		t.Fatalf("results = %v, want the reloaded Utah", results)
+	}
+	if s.pruned.contains("Utah") || !s.pruned.contains("Ohio") {
+		t.Error("only the searched pruned state should be restored")
+	}
+	if findState(s.Root(), "Ohio") != nil {
+		t.Error("a pruned state not matching the search was restored")
+	}
+}
+
+func TestRestorePrunedSkipsWriteLockWithoutMatches(t *testing.T) {
+	s := newTestStore(t,
+		State{Name: "Texas", Code: "TX", Frequency: 9, Enabled: true, Kind: KindState},
+		State{Name: "Utah", Code: "UT", Frequency: 1, Enabled: true, Kind: KindState},
+	)
+	pruneStore(s, 6)
+
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+	done := make(chan error, 1)
+	go func() {
+		done <- restorePruned(context.Background(), s, "tex")
+	}()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("a search matching no pruned state waited for the write lock")
+	}
+}
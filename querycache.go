@@ -0,0 +1,210 @@
+package backend
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/graphql-go/graphql/language/ast"
+	"github.com/graphql-go/graphql/language/parser"
+	"github.com/graphql-go/graphql/language/printer"
+	"github.com/graphql-go/graphql/language/source"
+)
+
+const (
+	// queryCacheHeader reports whether a GraphQL response was served from the query cache
+	queryCacheHeader = "X-Query-Cache"
+	// queryCacheKeyPrefix namespaces the query cache keys in Redis
+	queryCacheKeyPrefix = "querycache:"
+)
+
+// QueryCache stores serialized GraphQL responses by key
+type QueryCache interface {
+	Get(ctx context.Context, key string) ([]byte, bool, error)
+	Set(ctx context.Context, key string, response []byte, ttl time.Duration) error
+}
+
+// RedisQueryCache stores GraphQL responses in Redis
+type RedisQueryCache struct {
+	client *redis.Client
+}
+
+// NewRedisQueryCache creates a cache storing responses in the Redis server at the address
+func NewRedisQueryCache(addr string) *RedisQueryCache {
+	return &RedisQueryCache{client: redis.NewClient(&redis.Options{Addr: addr})}
+}
+
+// Get returns the response cached under the key, if any
+func (c *RedisQueryCache) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	response, err := c.client.Get(ctx, key).Bytes()
+	if err == redis.Nil {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	return response, true, nil
+}
+
+// Set caches the response under the key for the TTL
+func (c *RedisQueryCache) Set(ctx context.Context, key string, response []byte, ttl time.Duration) error {
+	return c.client.Set(ctx, key, response, ttl).Err()
+}
+
+// graphQLRequest is the query, variables and operation of a GraphQL request
+type graphQLRequest struct {
+	Query         string                 `json:"query"`
+	Variables     map[string]interface{} `json:"variables"`
+	OperationName string                 `json:"operationName"`
+}
+
+// readGraphQLRequest reads the GraphQL request from the URL of a GET request or the body of a POST
+// request, leaving the body readable for the next handler
+func readGraphQLRequest(r *http.Request) (graphQLRequest, bool) {
+	var request graphQLRequest
+	if r.Method == http.MethodGet {
+		values := r.URL.Query()
+		request.Query = values.Get("query")
+		request.OperationName = values.Get("operationName")
+		if raw := values.Get("variables"); raw != "" {
+			if err := json.Unmarshal([]byte(raw), &request.Variables); err != nil {
+				return request, false
+			}
+		}
+		return request, request.Query != ""
+	}
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		return request, false
+	}
+	r.Body = io.NopCloser(bytes.NewReader(body))
+	contentType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	switch contentType {
+	case "application/graphql":
+		request.Query = string(body)
+	case "application/json":
+		if err := json.Unmarshal(body, &request); err != nil {
+			return request, false
+		}
+	default:
+		return request, false
+	}
+	return request, request.Query != ""
+}
+
+// queryCacheKey returns the cache key of a query request: the SHA-256 of the query printed in its
+// normalized form and the variables. The tenant, languages and admin actor of the request are part
+// of the key, since they change the response.
+func queryCacheKey(ctx context.Context, request graphQLRequest) (string, bool) {
+	document, err := parser.Parse(parser.ParseParams{Source: source.NewSource(&source.Source{Body: []byte(request.Query)})})
+	if err != nil {
+		return "", false
+	}
+	normalized, _ := printer.Print(document).(string)
+	variables, err := json.Marshal(request.Variables)
+	if err != nil {
+		return "", false
+	}
+	languages, _ := ctx.Value(acceptLanguageContextKey{}).([]string)
+	actor, _ := actorFromContext(ctx)
+
+	hash := sha256.New()
+	hash.Write([]byte(normalized))
+	hash.Write(variables)
+	for _, part := range []string{request.OperationName, tenantFromContext(ctx), strings.Join(languages, ","), actor} {
+		hash.Write([]byte{0})
+		hash.Write([]byte(part))
+	}
+	return queryCacheKeyPrefix + hex.EncodeToString(hash.Sum(nil)), true
+}
+
+// cacheableResponse reports whether the response succeeded without errors
+func cacheableResponse(status int, body []byte) bool {
+	var response struct {
+		Errors []json.RawMessage `json:"errors"`
+	}
+	return status == http.StatusOK && json.Unmarshal(body, &response) == nil && len(response.Errors) == 0
+}
+
+// refreshMeta replaces the request ID, server time and trie age in the meta extension of a cached
+// response with those of the current request
+func refreshMeta(ctx context.Context, cached []byte) []byte {
+	var response map[string]interface{}
+	if err := json.Unmarshal(cached, &response); err != nil {
+		return cached
+	}
+	extensions, _ := response["extensions"].(map[string]interface{})
+	meta, ok := extensions[metaExtensionName].(map[string]interface{})
+	if !ok {
+		return cached
+	}
+	fresh := newResponseMeta(ctx)
+	meta["requestID"] = fresh.RequestID
+	meta["serverTime"] = fresh.ServerTime
+	meta["trieAge"] = fresh.TrieAge
+	if _, ok := meta["updatesApplied"]; ok {
+		// Cached responses skip the resolvers, so no frequency was updated
+		meta["updatesApplied"] = 0
+	}
+	refreshed, err := json.Marshal(response)
+	if err != nil {
+		return cached
+	}
+	return refreshed
+}
+
+// withQueryCache serves identical GraphQL queries from the cache for the TTL instead of executing
+// them. Mutations, subscriptions, failed responses and requests with debugging enabled are never
+// cached. Cached responses skip the resolvers, so they do not update frequencies.
+func withQueryCache(cache QueryCache, ttl time.Duration, next http.Handler) http.Handler {
+	if cache == nil || ttl <= 0 {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		request, ok := readGraphQLRequest(r)
+		if !ok || debugEnabled(r.Context()) ||
+			operationType(request.Query, request.OperationName) != ast.OperationTypeQuery {
+			next.ServeHTTP(w, r)
+			return
+		}
+		key, ok := queryCacheKey(r.Context(), request)
+		if !ok {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		cached, found, err := cache.Get(r.Context(), key)
+		if err != nil {
+			log.Printf("Error reading query cache: %v", err)
+		}
+		if found {
+			w.Header().Set("Content-Type", "application/json; charset=utf-8")
+			w.Header().Set(queryCacheHeader, "HIT")
+			w.Write(refreshMeta(r.Context(), cached))
+			return
+		}
+
+		buffered := &bufferedResponseWriter{ResponseWriter: w}
+		next.ServeHTTP(buffered, r)
+		if cacheableResponse(buffered.status, buffered.body.Bytes()) {
+			if err := cache.Set(r.Context(), key, buffered.body.Bytes(), ttl); err != nil {
+				log.Printf("Error writing query cache: %v", err)
+			}
+		}
+		w.Header().Set(queryCacheHeader, "MISS")
+		if buffered.status != 0 {
+			w.WriteHeader(buffered.status)
+		}
+		w.Write(buffered.body.Bytes())
+	})
+}
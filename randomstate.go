@@ -0,0 +1,54 @@
+package backend
+
+import (
+	"math/rand"
+	"time"
+
+	"github.com/graphql-go/graphql"
+)
+
+// randomStateAttempts is how many random picks may land on disabled states before the enabled
+// states are listed and picked from directly
+const randomStateAttempts = 16
+
+// randomState returns a uniformly random enabled state, or nil when there is none. Picks from the
+// flat list of states take constant time unless most states are disabled.
+func randomState(states []*State, random *rand.Rand) *State {
+	if len(states) == 0 {
+		return nil
+	}
+	for i := 0; i < randomStateAttempts; i++ {
+		if state := states[random.Intn(len(states))]; state.Enabled {
+			return state
+		}
+	}
+	enabled := filterStates(append([]*State(nil), states...), []stateFilter{isEnabled})
+	if len(enabled) == 0 {
+		return nil
+	}
+	return enabled[random.Intn(len(enabled))]
+}
+
+// randomStateField returns an arbitrary state for demos and smoke tests, without updating its
+// frequency. The same seed picks the same state as long as the loaded states do not change,
+// since they are sorted by name.
+var randomStateField = &graphql.Field{
+	Type: stateType,
+	Args: graphql.FieldConfigArgument{
+		"seed": &graphql.ArgumentConfig{
+			Type:        graphql.Int,
+			Description: "Makes the selection deterministic",
+		},
+	},
+	Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+		tenantStore, err := storeFor(p.Context)
+		if err != nil {
+			return nil, err
+		}
+		seed := time.Now().UnixNano()
+		if value, ok := p.Args["seed"].(int); ok {
+			seed = int64(value)
+		}
+		return randomState(tenantStore.States(), rand.New(rand.NewSource(seed))), nil
+	},
+}
@@ -0,0 +1,61 @@
+package backend
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestRandomStateWithSeed(t *testing.T) {
+	s := newTestStore(t,
+		State{Name: "Texas", Code: "TX", Enabled: true, Kind: KindState},
+		State{Name: "Tennessee", Code: "TN", Enabled: true, Kind: KindState},
+		State{Name: "Utah", Code: "UT", Enabled: true, Kind: KindState},
+		State{Name: "Iowa", Code: "IA", Enabled: true, Kind: KindState},
+	)
+	for _, seed := range []string{"1", "7", "42"} {
+		query := `{ randomState(seed: ` + seed + `) { name } }`
+		want := resolveCodeQuery(t, query)
+		for i := 0; i < 5; i++ {
+			if got := resolveCodeQuery(t, query); got != want {
+				t.Fatalf("%s = %s, then %s", query, want, got)
+			}
+		}
+	}
+	for _, state := range s.States() {
+		if state.loadFrequency() != 0 {
+			t.Errorf("frequency of %s after picking states = %d, want 0", state.Name, state.loadFrequency())
+		}
+	}
+}
+
+func TestRandomStateIsUniform(t *testing.T) {
+	s := newTestStore(t,
+		State{Name: "Texas", Code: "TX", Enabled: true, Kind: KindState},
+		State{Name: "Tennessee", Code: "TN", Enabled: true, Kind: KindState},
+		State{Name: "Utah", Code: "UT", Enabled: true, Kind: KindState},
+		State{Name: "Iowa", Code: "IA", Enabled: true, Kind: KindState},
+		State{Name: "Idaho", Code: "ID", Enabled: false, Kind: KindState},
+	)
+	const draws = 8000
+	random := rand.New(rand.NewSource(1))
+	picked := make(map[string]int)
+	for i := 0; i < draws; i++ {
+		picked[randomState(s.States(), random).Name]++
+	}
+	if picked["Idaho"] != 0 {
+		t.Errorf("disabled Idaho was picked %d times", picked["Idaho"])
+	}
+	for _, name := range []string{"Texas", "Tennessee", "Utah", "Iowa"} {
+		if count := picked[name]; count < draws/4*9/10 || count > draws/4*11/10 {
+			t.Errorf("%s was picked %d times out of %d, want about %d", name, count, draws, draws/4)
+		}
+	}
+
+	if state := randomState(nil, random); state != nil {
+		t.Errorf("random state without states = %+v, want nil", state)
+	}
+	disabled := []*State{{Name: "Idaho", Enabled: false}}
+	if state := randomState(disabled, random); state != nil {
+		t.Errorf("random state of only disabled states = %+v, want nil", state)
+	}
+}
@@ -0,0 +1,100 @@
+package backend
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/graphql-go/graphql"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// selectionTotal is the total selections of one state code aggregated from frequencyRollups
+type selectionTotal struct {
+	StateCode  string `bson:"_id"`
+	Selections int    `bson:"selections"`
+}
+
+// aggregateSelections sums the selections per state code in the rollups of the days before today,
+// adding today's selections counted in memory since they are not rolled up yet
+func aggregateSelections(ctx context.Context, tracker *TrendTracker) (map[string]int, error) {
+	now := tracker.now().UTC()
+	today := now.Truncate(24 * time.Hour)
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"date": bson.M{"$lt": today.Format(rollupDateLayout)}}}},
+		{{Key: "$group", Value: bson.M{"_id": "$stateCode", "selections": bson.M{"$sum": "$selections"}}}},
+	}
+	collection := client.Database(config.MongoDB).Collection("frequencyRollups")
+	cursor, err := collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var totals []selectionTotal
+	if err := cursor.All(ctx, &totals); err != nil {
+		return nil, err
+	}
+	selections := tracker.CountsBetween(today, now.Add(trendBucketSize))
+	for _, total := range totals {
+		selections[total.StateCode] += total.Selections
+	}
+	return selections, nil
+}
+
+// recomputeFrequencies sets the frequency of every state of the store with a code to its number of
+// selections, updating its frequency store and trie, and returns the number of states whose
+// frequency changed
+func recomputeFrequencies(ctx context.Context, s *TrieStore, selections map[string]int) (int, error) {
+	deltas := make(map[string]int)
+	var updated []*State
+	for _, state := range s.States() {
+		frequency := state.loadFrequency()
+		if state.Code == "" || frequency == int64(selections[state.Code]) {
+			continue
+		}
+		recomputed := copyState(state)
+		recomputed.Frequency = int64(selections[state.Code])
+		deltas[state.Name] = int(recomputed.Frequency - frequency)
+		updated = append(updated, recomputed)
+	}
+	if len(updated) == 0 {
+		return 0, nil
+	}
+	if err := s.Frequencies().BulkIncrement(ctx, deltas); err != nil {
+		return 0, err
+	}
+	s.writeMu.Lock()
+	applyStateChanges(ctx, s, stateChanges{Updated: updated})
+	s.writeMu.Unlock()
+	return len(updated), nil
+}
+
+// recomputeFrequenciesField rewrites the frequencies of the default tenant's states to their
+// selections in the frequency rollups, correcting drift from missed updates
+var recomputeFrequenciesField = &graphql.Field{
+	Type: graphql.Int,
+	Resolve: audited("recomputeFrequencies", nil, func(p graphql.ResolveParams) (interface{}, error) {
+		actor, err := requireAdmin(p.Context)
+		if err != nil {
+			return nil, err
+		}
+		if tenantID := tenantFromContext(p.Context); tenantID != defaultTenantID {
+			return nil, fmt.Errorf("selections are only rolled up for the %q tenant, not %q", defaultTenantID, tenantID)
+		}
+		selections, err := aggregateSelections(p.Context, trends)
+		if err != nil {
+			log.Printf("Error aggregating selections for %s: %v", actor, err)
+			return nil, err
+		}
+		count, err := recomputeFrequencies(p.Context, store, selections)
+		if err != nil {
+			log.Printf("Error recomputing frequencies for %s: %v", actor, err)
+			return nil, err
+		}
+		log.Printf("Recomputed %d frequencies for %s", count, actor)
+		return count, nil
+	}),
+}
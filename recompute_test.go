@@ -0,0 +1,73 @@
+package backend
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func TestRecomputeFrequencies(t *testing.T) {
+	s := newTestStore(t,
+		State{Name: "Texas", Code: "TX", Enabled: true, Kind: KindState, Frequency: 40},
+		State{Name: "Tennessee", Code: "TN", Enabled: true, Kind: KindState, Frequency: 2},
+		State{Name: "Utah", Code: "UT", Enabled: true, Kind: KindState, Frequency: 7},
+		State{Name: "Atlantis", Enabled: true, Kind: KindTerritory, Frequency: 3},
+	)
+	ctx := context.Background()
+	// as aggregated from the rollups; Texas drifted up and Tennessee down, Utah is right
+	selections := map[string]int{"TX": 12, "TN": 30, "UT": 7}
+
+	count, err := recomputeFrequencies(ctx, s, selections)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count != 2 {
+		t.Errorf("recomputed %d frequencies, want Texas and Tennessee", count)
+	}
+	want := map[string]int64{"Texas": 12, "Tennessee": 30, "Utah": 7, "Atlantis": 3}
+	for name, frequency := range want {
+		if state := findState(s.Root(), name); state.loadFrequency() != frequency {
+			t.Errorf("frequency of %s in the trie = %d, want %d", name, state.loadFrequency(), frequency)
+		}
+		if state, _ := s.Repository().FindByName(ctx, name); state.Frequency != frequency {
+			t.Errorf("frequency of %s in the repository = %d, want %d", name, state.Frequency, frequency)
+		}
+	}
+	if names := searchNames(s.Root(), "T"); !reflect.DeepEqual(names, []string{"Tennessee", "Texas"}) {
+		t.Errorf("searching T after recomputing = %v, want Tennessee first", names)
+	}
+
+	if count, err := recomputeFrequencies(ctx, s, selections); err != nil || count != 0 {
+		t.Errorf("recomputing again = %d, %v, want nothing to recompute", count, err)
+	}
+}
+
+func TestAggregateSelections(t *testing.T) {
+	db := useTestMongo(t)
+	ctx := context.Background()
+	_, err := db.Collection("frequencyRollups").InsertMany(ctx, []interface{}{
+		bson.M{"stateCode": "TX", "date": "2024-02-28", "selections": 3},
+		bson.M{"stateCode": "TX", "date": "2024-02-29", "selections": 4},
+		bson.M{"stateCode": "UT", "date": "2024-02-29", "selections": 1},
+		// today is counted from memory rather than from a rollup
+		bson.M{"stateCode": "UT", "date": "2024-03-01", "selections": 100},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	clock := &testClock{now: time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)}
+	tracker := NewTrendTracker(clock.Now)
+	tracker.Record("UT")
+	tracker.Record("ID")
+
+	selections, err := aggregateSelections(ctx, tracker)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := map[string]int{"TX": 7, "UT": 2, "ID": 1}; !reflect.DeepEqual(selections, want) {
+		t.Errorf("aggregated selections = %v, want %v", selections, want)
+	}
+}
@@ -0,0 +1,160 @@
+package backend
+
+import (
+	"context"
+	"crypto/rand"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// reloadPending marks a reload that is still running
+	reloadPending = "pending"
+	// reloadComplete marks a reload that swapped in the new trie
+	reloadComplete = "complete"
+	// reloadFailed marks a reload that kept the old trie
+	reloadFailed = "failed"
+	// maxTrackedReloads is the number of reloads whose status is kept, oldest dropped first
+	maxTrackedReloads = 100
+)
+
+// ReloadStatus is the progress of a trie reload started through the admin API
+type ReloadStatus struct {
+	Status   string `json:"status"`
+	Duration string `json:"duration"`
+	Error    string `json:"error,omitempty"`
+}
+
+// reloadJob is a trie reload running or finished in the background
+type reloadJob struct {
+	id        string
+	startedAt time.Time
+	status    string
+	duration  time.Duration
+	err       error
+}
+
+// ReloadTracker starts trie reloads in the background and keeps the status of the latest ones
+type ReloadTracker struct {
+	mu    sync.Mutex
+	jobs  map[string]*reloadJob
+	order []string
+}
+
+var reloads = NewReloadTracker()
+
+// NewReloadTracker creates a tracker without reloads
+func NewReloadTracker() *ReloadTracker {
+	return &ReloadTracker{jobs: make(map[string]*reloadJob)}
+}
+
+// newUUID returns a random version 4 UUID
+func newUUID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16]), nil
+}
+
+// Start rebuilds the store's trie in the background and returns the ID to check its status with
+func (t *ReloadTracker) Start(s *TrieStore) (string, error) {
+	id, err := newUUID()
+	if err != nil {
+		return "", err
+	}
+	job := &reloadJob{id: id, startedAt: time.Now(), status: reloadPending}
+
+	t.mu.Lock()
+	t.jobs[id] = job
+	t.order = append(t.order, id)
+	if len(t.order) > maxTrackedReloads {
+		delete(t.jobs, t.order[0])
+		t.order = t.order[1:]
+	}
+	t.mu.Unlock()
+
+	go func() {
+		err := s.RebuildTrie(context.Background())
+		t.mu.Lock()
+		defer t.mu.Unlock()
+		job.duration = time.Since(job.startedAt)
+		job.err = err
+		if err != nil {
+			job.status = reloadFailed
+			log.Printf("Error reloading trie %s: %v", id, err)
+			return
+		}
+		job.status = reloadComplete
+		log.Printf("Reloaded trie %s in %s", id, job.duration)
+	}()
+	return id, nil
+}
+
+// Status returns the status of the reload with the ID, or false if it is unknown. The duration of a
+// pending reload is the time it has been running.
+func (t *ReloadTracker) Status(id string) (ReloadStatus, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	job, ok := t.jobs[id]
+	if !ok {
+		return ReloadStatus{}, false
+	}
+	duration := job.duration
+	if job.status == reloadPending {
+		duration = time.Since(job.startedAt)
+	}
+	status := ReloadStatus{Status: job.status, Duration: duration.String()}
+	if job.err != nil {
+		status.Error = job.err.Error()
+	}
+	return status, true
+}
+
+// reloadHandler serves POST /admin/reload, which starts rebuilding the tenant's trie and returns
+// 202 Accepted with the reload ID, and GET /admin/reload/{reloadID}, which returns its status
+func reloadHandler(w http.ResponseWriter, r *http.Request) {
+	actor, err := requireAdmin(r.Context())
+	if err != nil {
+		writeJSON(w, http.StatusUnauthorized, map[string]string{"error": err.Error()})
+		return
+	}
+
+	id := strings.TrimPrefix(strings.TrimPrefix(r.URL.Path, "/admin/reload"), "/")
+	switch {
+	case id == "" && r.Method == http.MethodPost:
+		tenantStore, err := storeFor(r.Context())
+		if err != nil {
+			writeJSON(w, http.StatusServiceUnavailable, map[string]string{"error": err.Error()})
+			return
+		}
+		reloadID, err := reloads.Start(tenantStore)
+		if err != nil {
+			log.Printf("Error starting trie reload for %s: %v", actor, err)
+			writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "could not start reload"})
+			return
+		}
+		log.Printf("Started trie reload %s for %s", reloadID, actor)
+		writeJSON(w, http.StatusAccepted, map[string]string{"reloadID": reloadID})
+	case id != "" && r.Method == http.MethodGet:
+		status, ok := reloads.Status(id)
+		if !ok {
+			writeJSON(w, http.StatusNotFound, map[string]string{"error": "not found"})
+			return
+		}
+		writeJSON(w, http.StatusOK, status)
+	default:
+		if id == "" {
+			w.Header().Set("Allow", http.MethodPost)
+		} else {
+			w.Header().Set("Allow", http.MethodGet)
+		}
+		writeJSON(w, http.StatusMethodNotAllowed, map[string]string{"error": "method not allowed"})
+	}
+}
@@ -0,0 +1,51 @@
+package backend
+
+import (
+	"context"
+	"log"
+
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// readClient is the MongoDB client states are read through: the read replica of MONGO_READ_URI
+// when one is configured, otherwise the primary client
+var readClient *mongo.Client
+
+// primaryReadsContextKey marks a context whose state reads must see the writes made just before
+type primaryReadsContextKey struct{}
+
+// initReadClient connects to the read replica, or reads through the primary client when
+// MONGO_READ_URI is unset
+func initReadClient() {
+	if config.MongoReadURI == "" {
+		readClient = client
+		return
+	}
+	var err error
+	readClient, err = connectMongo(context.Background(), config.MongoReadURI, mongoConnectAttempts, mongoConnectBaseDelay)
+	if err != nil {
+		log.Fatal(err)
+	}
+	log.Printf("Reading states from the MongoDB read replica")
+}
+
+// stateRepository returns the repository of the states collection, reading through the read
+// client and writing through the primary one
+func stateRepository(collection string) *MongoStateRepository {
+	return NewReplicatedMongoStateRepository(
+		client.Database(config.MongoDB).Collection(collection),
+		readClient.Database(config.MongoDB).Collection(collection),
+	)
+}
+
+// withPrimaryReads returns a context whose state reads go to the primary, for reloads right after
+// a write that a lagging replica may not have applied yet
+func withPrimaryReads(ctx context.Context) context.Context {
+	return context.WithValue(ctx, primaryReadsContextKey{}, true)
+}
+
+// primaryReads reports whether state reads through the context must go to the primary
+func primaryReads(ctx context.Context) bool {
+	primary, _ := ctx.Value(primaryReadsContextKey{}).(bool)
+	return primary
+}
@@ -0,0 +1,91 @@
+package backend
+
+import (
+	"context"
+	"io"
+	"log"
+	"strings"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	// testPrimaryAddr and testReplicaAddr are closed ports, so every operation fails with a server
+	// selection error naming the server it was routed to
+	testPrimaryAddr = "127.0.0.1:1"
+	testReplicaAddr = "127.0.0.1:2"
+)
+
+// newUnreachableClient creates a client for the address that gives up selecting a server quickly
+func newUnreachableClient(t *testing.T, addr string) *mongo.Client {
+	t.Helper()
+	c, err := mongo.Connect(context.Background(), options.Client().
+		ApplyURI("mongodb://"+addr).
+		SetServerSelectionTimeout(200*time.Millisecond))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { c.Disconnect(context.Background()) })
+	return c
+}
+
+// routedTo returns the address the failed operation was sent to
+func routedTo(t *testing.T, operation string, err error) string {
+	t.Helper()
+	if err == nil {
+		t.Fatalf("%s reached an unreachable server", operation)
+	}
+	for _, addr := range []string{testPrimaryAddr, testReplicaAddr} {
+		if strings.Contains(err.Error(), addr) {
+			return addr
+		}
+	}
+	t.Fatalf("%s failed without naming a server: %v", operation, err)
+	return ""
+}
+
+func TestStateRepositoryReadsFromReplica(t *testing.T) {
+	log.SetOutput(io.Discard)
+	primary := newUnreachableClient(t, testPrimaryAddr)
+	replica := newUnreachableClient(t, testReplicaAddr)
+	repo := NewReplicatedMongoStateRepository(
+		primary.Database("statesDB").Collection("states"),
+		replica.Database("statesDB").Collection("states"),
+	)
+	s := NewTrieStore(repo)
+	ctx := context.Background()
+
+	tests := []struct {
+		operation string
+		run       func() error
+		want      string
+	}{
+		{"load", func() error { return s.RebuildTrie(ctx) }, testReplicaAddr},
+		{"sync", func() error { _, err := syncTrie(ctx, s); return err }, testReplicaAddr},
+		{"lookup", func() error { _, err := repo.FindByName(ctx, "Texas"); return err }, testReplicaAddr},
+		{"count", func() error { _, err := repo.Count(ctx); return err }, testReplicaAddr},
+		{"reload after a write", func() error { return s.RebuildTrie(withPrimaryReads(ctx)) }, testPrimaryAddr},
+		{"insert", func() error { return repo.Insert(ctx, &State{Name: "Texas", Code: "TX"}) }, testPrimaryAddr},
+		{"increment", func() error { return s.Frequencies().Increment(ctx, "Texas", 1) }, testPrimaryAddr},
+		{"enable", func() error { return repo.SetEnabled(ctx, "Texas", false) }, testPrimaryAddr},
+		{"delete", func() error { return repo.Delete(ctx, "Texas") }, testPrimaryAddr},
+	}
+	for _, test := range tests {
+		if got := routedTo(t, test.operation, test.run()); got != test.want {
+			t.Errorf("%s went to %s, want %s", test.operation, got, test.want)
+		}
+	}
+}
+
+func TestStateRepositoryWithoutReplicaReadsFromPrimary(t *testing.T) {
+	primary := newUnreachableClient(t, testPrimaryAddr)
+	repo := NewMongoStateRepository(primary.Database("statesDB").Collection("states"))
+
+	_, err := repo.FindAll(context.Background())
+	if got := routedTo(t, "load", err); got != testPrimaryAddr {
+		t.Errorf("load went to %s, want %s", got, testPrimaryAddr)
+	}
+}
@@ -0,0 +1,503 @@
+package backend
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"regexp"
+	"strings"
+	"sync"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// errStateNotFound is returned when a state does not exist in the repository
+var errStateNotFound = errors.New("state not found")
+
+// StateRepository stores the states loaded into the trie and persists changes made to them.
+// The trie and GraphQL code only use this interface, so other databases can back it.
+type StateRepository interface {
+	FindAll(ctx context.Context) ([]*State, error)
+	FindByName(ctx context.Context, name string) (*State, error)
+	// FindByPrefix reads at most limit states whose name starts with the prefix under the
+	// collation policy
+	FindByPrefix(ctx context.Context, prefix string, limit int) ([]*State, error)
+	// Count returns the number of stored states
+	Count(ctx context.Context) (int, error)
+	// Sample reads at most n states picked at random
+	Sample(ctx context.Context, n int) ([]*State, error)
+	Insert(ctx context.Context, state *State) error
+	SetEnabled(ctx context.Context, name string, enabled bool) error
+	SetKind(ctx context.Context, name, kind string) error
+	SetTranslation(ctx context.Context, name, locale, translation string) error
+	RemoveTranslation(ctx context.Context, name, locale string) error
+	Delete(ctx context.Context, name string) error
+	DeleteAll(ctx context.Context) (int, error)
+	// NormalizeNames rewrites the stored states whose name or translations are not in NFC,
+	// returning the number rewritten
+	NormalizeNames(ctx context.Context) (int, error)
+	// WithTransaction runs fn so that the repository changes it makes through ctx are applied
+	// together or not at all
+	WithTransaction(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
+// MongoStateRepository stores states in a MongoDB collection, optionally reading them from the
+// same collection on a read replica
+type MongoStateRepository struct {
+	collection *mongo.Collection
+	reads      *mongo.Collection
+}
+
+// InMemoryStateRepository keeps states in memory, for tests and fixtures
+type InMemoryStateRepository struct {
+	mu     sync.Mutex
+	states []State
+}
+
+// NewMongoStateRepository creates a repository backed by the given collection
+func NewMongoStateRepository(collection *mongo.Collection) *MongoStateRepository {
+	return &MongoStateRepository{collection: collection, reads: collection}
+}
+
+// NewReplicatedMongoStateRepository creates a repository writing to the collection and reading
+// from reads, the collection on a read replica
+func NewReplicatedMongoStateRepository(collection, reads *mongo.Collection) *MongoStateRepository {
+	return &MongoStateRepository{collection: collection, reads: reads}
+}
+
+// reader returns the collection to read states from: the read replica unless the context asks for
+// primary reads or runs in a transaction, whose session belongs to the primary client
+func (r *MongoStateRepository) reader(ctx context.Context) *mongo.Collection {
+	if primaryReads(ctx) || mongo.SessionFromContext(ctx) != nil {
+		return r.collection
+	}
+	return r.reads
+}
+
+// FindAll reads every state from the collection
+func (r *MongoStateRepository) FindAll(ctx context.Context) ([]*State, error) {
+	cursor, err := r.reader(ctx).Find(ctx, bson.M{})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	states := []*State{}
+	for cursor.Next(ctx) {
+		var state State
+		if err := cursor.Decode(&state); err != nil {
+			return nil, err
+		}
+		states = append(states, &state)
+	}
+	return states, cursor.Err()
+}
+
+// FindByName reads the named state from the collection
+func (r *MongoStateRepository) FindByName(ctx context.Context, name string) (*State, error) {
+	var state State
+	err := r.reader(ctx).FindOne(ctx, bson.M{"name": name}).Decode(&state)
+	if err == mongo.ErrNoDocuments {
+		return nil, errStateNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &state, nil
+}
+
+// FindByPrefix reads the states whose name matches the prefix anchored to its start, ignoring case
+// unless the collation policy is exact. Accents are not folded, so accent-folding policies only
+// find names spelled with the prefix's accents.
+func (r *MongoStateRepository) FindByPrefix(ctx context.Context, prefix string, limit int) ([]*State, error) {
+	pattern := primitive.Regex{Pattern: "^" + regexp.QuoteMeta(nfc(prefix))}
+	if collationPolicy != CollationExact {
+		pattern.Options = "i"
+	}
+	cursor, err := r.reader(ctx).Find(ctx, bson.M{"name": pattern}, options.Find().SetLimit(int64(limit)))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var states []*State
+	for cursor.Next(ctx) {
+		var state State
+		if err := cursor.Decode(&state); err != nil {
+			return nil, err
+		}
+		states = append(states, &state)
+	}
+	return states, cursor.Err()
+}
+
+// Count counts the documents of the collection
+func (r *MongoStateRepository) Count(ctx context.Context) (int, error) {
+	count, err := r.reader(ctx).CountDocuments(ctx, bson.M{})
+	return int(count), err
+}
+
+// Sample reads n documents of the collection picked at random with $sample
+func (r *MongoStateRepository) Sample(ctx context.Context, n int) ([]*State, error) {
+	pipeline := mongo.Pipeline{{{Key: "$sample", Value: bson.M{"size": n}}}}
+	cursor, err := r.reader(ctx).Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var states []*State
+	for cursor.Next(ctx) {
+		var state State
+		if err := cursor.Decode(&state); err != nil {
+			return nil, err
+		}
+		states = append(states, &state)
+	}
+	return states, cursor.Err()
+}
+
+// Insert adds the state to the collection
+func (r *MongoStateRepository) Insert(ctx context.Context, state *State) error {
+	_, err := r.collection.InsertOne(ctx, state)
+	return err
+}
+
+// SetEnabled sets whether the named state is enabled in the collection
+func (r *MongoStateRepository) SetEnabled(ctx context.Context, name string, enabled bool) error {
+	_, err := r.collection.UpdateOne(ctx, bson.M{"name": name}, bson.M{"$set": bson.M{"enabled": enabled}})
+	return err
+}
+
+// SetKind sets the kind of the named state in the collection
+func (r *MongoStateRepository) SetKind(ctx context.Context, name, kind string) error {
+	res, err := r.collection.UpdateOne(ctx, bson.M{"name": name}, bson.M{"$set": bson.M{"kind": kind}})
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return errStateNotFound
+	}
+	return nil
+}
+
+// SetTranslation sets the name of the named state in the locale in the collection
+func (r *MongoStateRepository) SetTranslation(ctx context.Context, name, locale, translation string) error {
+	res, err := r.collection.UpdateOne(ctx, bson.M{"name": name}, bson.M{"$set": bson.M{"translations." + locale: translation}})
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return errStateNotFound
+	}
+	return nil
+}
+
+// RemoveTranslation removes the name of the named state in the locale from the collection
+func (r *MongoStateRepository) RemoveTranslation(ctx context.Context, name, locale string) error {
+	res, err := r.collection.UpdateOne(ctx, bson.M{"name": name}, bson.M{"$unset": bson.M{"translations." + locale: ""}})
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return errStateNotFound
+	}
+	return nil
+}
+
+// Delete deletes the named state from the collection
+func (r *MongoStateRepository) Delete(ctx context.Context, name string) error {
+	res, err := r.collection.DeleteOne(ctx, bson.M{"name": name})
+	if err != nil {
+		return err
+	}
+	if res.DeletedCount == 0 {
+		return errStateNotFound
+	}
+	return nil
+}
+
+// DeleteAll deletes every state from the collection, returning the number deleted
+func (r *MongoStateRepository) DeleteAll(ctx context.Context) (int, error) {
+	res, err := r.collection.DeleteMany(ctx, bson.M{})
+	if err != nil {
+		return 0, err
+	}
+	return int(res.DeletedCount), nil
+}
+
+// NormalizeNames rewrites the documents whose name or translations are not in NFC
+func (r *MongoStateRepository) NormalizeNames(ctx context.Context) (int, error) {
+	cursor, err := r.collection.Find(ctx, bson.M{})
+	if err != nil {
+		return 0, err
+	}
+	defer cursor.Close(ctx)
+
+	fixed := 0
+	for cursor.Next(ctx) {
+		var doc struct {
+			ID           interface{}       `bson:"_id"`
+			Name         string            `bson:"name"`
+			Translations map[string]string `bson:"translations"`
+		}
+		if err := cursor.Decode(&doc); err != nil {
+			return fixed, err
+		}
+		state := State{Name: doc.Name, Translations: doc.Translations}
+		if !normalizeStateNames(&state) {
+			continue
+		}
+		update := bson.M{"name": state.Name}
+		if state.Translations != nil {
+			update["translations"] = state.Translations
+		}
+		if _, err := r.collection.UpdateOne(ctx, bson.M{"_id": doc.ID}, bson.M{"$set": update}); err != nil {
+			return fixed, err
+		}
+		fixed++
+	}
+	return fixed, cursor.Err()
+}
+
+// WithTransaction runs fn in a MongoDB transaction, retrying it on transient errors. Transactions
+// need MongoDB to run as a replica set or sharded cluster.
+func (r *MongoStateRepository) WithTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	session, err := r.collection.Database().Client().StartSession()
+	if err != nil {
+		return err
+	}
+	defer session.EndSession(ctx)
+
+	_, err = session.WithTransaction(ctx, func(sessionCtx mongo.SessionContext) (interface{}, error) {
+		return nil, fn(sessionCtx)
+	})
+	return err
+}
+
+// NewInMemoryStateRepository creates a repository holding copies of the given states
+func NewInMemoryStateRepository(states ...State) *InMemoryStateRepository {
+	held := make([]State, 0, len(states))
+	for _, state := range states {
+		held = append(held, *copyHeldState(state))
+	}
+	return &InMemoryStateRepository{states: held}
+}
+
+// copyHeldState returns a copy of the state that shares no translations with it, so states read
+// from the repository can be normalized without changing the held ones
+func copyHeldState(state State) *State {
+	if state.Translations != nil {
+		state.Translations = copyTranslations(state.Translations)
+	}
+	return &state
+}
+
+// FindAll returns copies of the held states
+func (r *InMemoryStateRepository) FindAll(ctx context.Context) ([]*State, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	states := make([]*State, 0, len(r.states))
+	for i := range r.states {
+		states = append(states, copyHeldState(r.states[i]))
+	}
+	return states, nil
+}
+
+// FindByName returns a copy of the named state
+func (r *InMemoryStateRepository) FindByName(ctx context.Context, name string) (*State, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i := range r.states {
+		if r.states[i].Name == name {
+			return copyHeldState(r.states[i]), nil
+		}
+	}
+	return nil, errStateNotFound
+}
+
+// FindByPrefix returns copies of at most limit states whose collation key starts with the prefix's
+func (r *InMemoryStateRepository) FindByPrefix(ctx context.Context, prefix string, limit int) ([]*State, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	key := collationKey(prefix)
+	var states []*State
+	for i := range r.states {
+		if len(states) == limit {
+			break
+		}
+		if strings.HasPrefix(collationKey(r.states[i].Name), key) {
+			states = append(states, copyHeldState(r.states[i]))
+		}
+	}
+	return states, nil
+}
+
+// Count returns the number of held states
+func (r *InMemoryStateRepository) Count(ctx context.Context) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.states), nil
+}
+
+// Sample returns copies of at most n held states picked at random
+func (r *InMemoryStateRepository) Sample(ctx context.Context, n int) ([]*State, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	states := make([]*State, 0, n)
+	for _, i := range rand.Perm(len(r.states)) {
+		if len(states) == n {
+			break
+		}
+		states = append(states, copyHeldState(r.states[i]))
+	}
+	return states, nil
+}
+
+// Insert adds a copy of the state
+func (r *InMemoryStateRepository) Insert(ctx context.Context, state *State) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.states = append(r.states, *copyHeldState(*state))
+	return nil
+}
+
+// Increment adds the delta to the frequency of the named state, so the repository is its own
+// frequency store
+func (r *InMemoryStateRepository) Increment(ctx context.Context, name string, delta int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i := range r.states {
+		if r.states[i].Name == name {
+			r.states[i].Frequency += int64(delta)
+			return nil
+		}
+	}
+	return errStateNotFound
+}
+
+// BulkIncrement adds the deltas to the frequencies of the named states, ignoring unknown names
+func (r *InMemoryStateRepository) BulkIncrement(ctx context.Context, deltas map[string]int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i := range r.states {
+		r.states[i].Frequency += int64(deltas[r.states[i].Name])
+	}
+	return nil
+}
+
+// Frequencies returns nil, since the increments are already part of the states
+func (r *InMemoryStateRepository) Frequencies(ctx context.Context) (map[string]int, error) {
+	return nil, nil
+}
+
+// SetEnabled sets whether the named state is enabled
+func (r *InMemoryStateRepository) SetEnabled(ctx context.Context, name string, enabled bool) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i := range r.states {
+		if r.states[i].Name == name {
+			r.states[i].Enabled = enabled
+			return nil
+		}
+	}
+	return errStateNotFound
+}
+
+// SetKind sets the kind of the named state
+func (r *InMemoryStateRepository) SetKind(ctx context.Context, name, kind string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i := range r.states {
+		if r.states[i].Name == name {
+			r.states[i].Kind = kind
+			return nil
+		}
+	}
+	return errStateNotFound
+}
+
+// SetTranslation sets the name of the named state in the locale
+func (r *InMemoryStateRepository) SetTranslation(ctx context.Context, name, locale, translation string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i := range r.states {
+		if r.states[i].Name == name {
+			translations := copyTranslations(r.states[i].Translations)
+			translations[locale] = translation
+			r.states[i].Translations = translations
+			return nil
+		}
+	}
+	return errStateNotFound
+}
+
+// RemoveTranslation removes the name of the named state in the locale
+func (r *InMemoryStateRepository) RemoveTranslation(ctx context.Context, name, locale string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i := range r.states {
+		if r.states[i].Name == name {
+			translations := copyTranslations(r.states[i].Translations)
+			delete(translations, locale)
+			r.states[i].Translations = translations
+			return nil
+		}
+	}
+	return errStateNotFound
+}
+
+// Delete removes the named state
+func (r *InMemoryStateRepository) Delete(ctx context.Context, name string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i := range r.states {
+		if r.states[i].Name == name {
+			r.states = append(r.states[:i], r.states[i+1:]...)
+			return nil
+		}
+	}
+	return errStateNotFound
+}
+
+// DeleteAll removes every held state, returning the number removed
+func (r *InMemoryStateRepository) DeleteAll(ctx context.Context) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	deleted := len(r.states)
+	r.states = nil
+	return deleted, nil
+}
+
+// NormalizeNames rewrites the held states whose name or translations are not in NFC
+func (r *InMemoryStateRepository) NormalizeNames(ctx context.Context) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	fixed := 0
+	for i := range r.states {
+		state := copyHeldState(r.states[i])
+		if normalizeStateNames(state) {
+			r.states[i] = *state
+			fixed++
+		}
+	}
+	return fixed, nil
+}
+
+// WithTransaction runs fn, restoring the held states if it fails
+func (r *InMemoryStateRepository) WithTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	r.mu.Lock()
+	saved := append([]State(nil), r.states...)
+	r.mu.Unlock()
+
+	if err := fn(ctx); err != nil {
+		r.mu.Lock()
+		r.states = saved
+		r.mu.Unlock()
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,50 @@
+package backend
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"log"
+	"net/http"
+)
+
+// requestIDHeader carries the request ID in requests and responses
+const requestIDHeader = "X-Request-Id"
+
+type requestIDContextKey struct{}
+
+// newRequestID returns a random request ID
+func newRequestID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return ""
+	}
+	return hex.EncodeToString(b)
+}
+
+// withRequestID attaches the caller's request ID, or a new one, to the request context and response
+func withRequestID(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestID := r.Header.Get(requestIDHeader)
+		if requestID == "" {
+			requestID = newRequestID()
+		}
+		w.Header().Set(requestIDHeader, requestID)
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), requestIDContextKey{}, requestID)))
+	})
+}
+
+// requestIDFromContext returns the request ID attached to the context, if any
+func requestIDFromContext(ctx context.Context) string {
+	requestID, _ := ctx.Value(requestIDContextKey{}).(string)
+	return requestID
+}
+
+// logf logs like log.Printf, prefixed with the request ID attached to the context so the lines of
+// one request can be told apart
+func logf(ctx context.Context, format string, args ...interface{}) {
+	if requestID := requestIDFromContext(ctx); requestID != "" {
+		format = "[" + requestID + "] " + format
+	}
+	log.Printf(format, args...)
+}
@@ -0,0 +1,85 @@
+package backend
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// findNode walks the trie along the key and returns the node it ends at, if any
+func findNode(root *TrieNode, key string) *TrieNode {
+	node := root
+	for _, char := range key {
+		node = node.Children[char]
+		if node == nil {
+			return nil
+		}
+	}
+	return node
+}
+
+// stateNamed returns the state on the node with exactly the given name, if any
+func stateNamed(node *TrieNode, name string) *State {
+	if node == nil {
+		return nil
+	}
+	for _, state := range node.States {
+		if state.Name == name {
+			return state
+		}
+	}
+	return nil
+}
+
+// findState walks the trie along the key of the name and returns the state with exactly that name
+// in NFC, if any
+func findState(root *TrieNode, name string) *State {
+	name = nfc(name)
+	return stateNamed(findNode(root, collationKey(name)), name)
+}
+
+// stateResponse is the body of a state found by stateHandler
+type stateResponse struct {
+	Name      string `json:"name"`
+	Code      string `json:"code"`
+	Frequency int64  `json:"frequency"`
+}
+
+// stateHandler serves GET /states/{name} without updating the state's frequency. Disabled states
+// are not found, as in the GraphQL lookups.
+func stateHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", http.MethodGet)
+		writeJSON(w, http.StatusMethodNotAllowed, map[string]string{"error": "method not allowed"})
+		return
+	}
+
+	name, err := url.PathUnescape(strings.TrimPrefix(r.URL.EscapedPath(), "/states/"))
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid state name"})
+		return
+	}
+
+	tenantStore, err := storeFor(r.Context())
+	if err != nil {
+		writeJSON(w, http.StatusServiceUnavailable, map[string]string{"error": "states unavailable"})
+		return
+	}
+	state := findState(tenantStore.Root(), name)
+	if state == nil || !state.Enabled {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "not found"})
+		return
+	}
+	writeJSON(w, http.StatusOK, stateResponse{Name: state.Name, Code: state.Code, Frequency: state.loadFrequency()})
+}
+
+// writeJSON writes the value as a JSON response with the given status code
+func writeJSON(w http.ResponseWriter, status int, value interface{}) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(value); err != nil {
+		log.Printf("Error writing JSON response: %v", err)
+	}
+}
@@ -0,0 +1,41 @@
+package backend
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestStateHandler(t *testing.T) {
+	s := newTestStore(t,
+		State{Name: "New York", Code: "NY", Frequency: 7, Enabled: true, Kind: KindState, Translations: map[string]string{"es": "Nueva York"}},
+		State{Name: "Guam", Code: "GU", Frequency: 2, Enabled: false, Kind: KindTerritory},
+	)
+
+	tests := []struct {
+		method string
+		path   string
+		status int
+		body   string
+	}{
+		{http.MethodGet, "/states/New%20York", http.StatusOK, `{"name":"New York","code":"NY","frequency":7}`},
+		{http.MethodGet, "/states/new%20york", http.StatusNotFound, `{"error":"not found"}`},
+		{http.MethodGet, "/states/Guam", http.StatusNotFound, `{"error":"not found"}`},
+		{http.MethodGet, "/states/Ohio", http.StatusNotFound, `{"error":"not found"}`},
+		{http.MethodPost, "/states/New%20York", http.StatusMethodNotAllowed, `{"error":"method not allowed"}`},
+	}
+	for _, test := range tests {
+		recorder := httptest.NewRecorder()
+		stateHandler(recorder, httptest.NewRequest(test.method, test.path, nil))
+		if recorder.Code != test.status {
+			t.Errorf("%s %s status = %d, want %d", test.method, test.path, recorder.Code, test.status)
+		}
+		if body := strings.TrimSpace(recorder.Body.String()); body != test.body {
+			t.Errorf("%s %s body = %s, want %s", test.method, test.path, body, test.body)
+		}
+	}
+	if frequency := findState(s.Root(), "New York").loadFrequency(); frequency != 7 {
+		t.Errorf("frequency = %d after lookups, want 7", frequency)
+	}
+}
@@ -0,0 +1,49 @@
+package backend
+
+import (
+	"github.com/graphql-go/graphql"
+)
+
+// StateResult wraps a state with per-request metadata for the GraphQL response
+type StateResult struct {
+	*State
+	Explanation         *SearchExplanation
+	HighlightedName     string
+	DisplayName         string
+	NormalizedFrequency float64
+}
+
+// Resolve resolves the per-request fields and defers every other field to the wrapped state
+func (r *StateResult) Resolve(p graphql.ResolveParams) (interface{}, error) {
+	switch p.Info.FieldName {
+	case "_explanation":
+		return r.Explanation, nil
+	case "highlightedName":
+		return r.HighlightedName, nil
+	case "normalizedFrequency":
+		return r.NormalizedFrequency, nil
+	}
+	p.Source = r.State
+	return graphql.DefaultResolveFn(p)
+}
+
+// resolveFrequency resolves the frequency field of a state or result, reading it atomically since
+// selections increment it while the response is resolved
+func resolveFrequency(p graphql.ResolveParams) (interface{}, error) {
+	switch source := p.Source.(type) {
+	case *State:
+		return source.loadFrequency(), nil
+	case *StateResult:
+		return source.loadFrequency(), nil
+	}
+	return graphql.DefaultResolveFn(p)
+}
+
+// wrapResults wraps each state so per-request metadata can be attached to it
+func wrapResults(states []*State) []*StateResult {
+	results := make([]*StateResult, 0, len(states))
+	for _, state := range states {
+		results = append(results, &StateResult{State: state})
+	}
+	return results
+}
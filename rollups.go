@@ -0,0 +1,152 @@
+package backend
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/graphql-go/graphql"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	// rollupDateLayout is the layout of the date stored on each rollup
+	rollupDateLayout = "2006-01-02"
+	// defaultHistoryDays is the number of days returned when the query does not give one
+	defaultHistoryDays = 30
+)
+
+// FrequencyRollup holds the selections of one state on one day
+type FrequencyRollup struct {
+	StateCode  string `bson:"stateCode" json:"stateCode"`
+	Date       string `bson:"date" json:"date"`
+	Selections int    `bson:"selections" json:"selections"`
+}
+
+// CountsBetween sums the selections per state code in the buckets starting within [from, to)
+func (t *TrendTracker) CountsBetween(from, to time.Time) map[string]int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	counts := make(map[string]int)
+	for _, bucket := range t.buckets {
+		if bucket.Counts == nil || bucket.Start.Before(from) || !bucket.Start.Before(to) {
+			continue
+		}
+		for code, count := range bucket.Counts {
+			counts[code] += count
+		}
+	}
+	return counts
+}
+
+// dailyRollups computes the rollups of the UTC day containing the given time
+func dailyRollups(tracker *TrendTracker, day time.Time) []FrequencyRollup {
+	from := day.UTC().Truncate(24 * time.Hour)
+	date := from.Format(rollupDateLayout)
+
+	rollups := []FrequencyRollup{}
+	for code, selections := range tracker.CountsBetween(from, from.Add(24*time.Hour)) {
+		rollups = append(rollups, FrequencyRollup{StateCode: code, Date: date, Selections: selections})
+	}
+	return rollups
+}
+
+// writeRollups upserts the rollups on stateCode and date, so writing a day twice is harmless
+func writeRollups(ctx context.Context, rollups []FrequencyRollup) error {
+	if len(rollups) == 0 {
+		return nil
+	}
+	models := make([]mongo.WriteModel, 0, len(rollups))
+	for _, rollup := range rollups {
+		models = append(models, mongo.NewReplaceOneModel().
+			SetFilter(bson.M{"stateCode": rollup.StateCode, "date": rollup.Date}).
+			SetReplacement(rollup).
+			SetUpsert(true))
+	}
+	collection := client.Database(config.MongoDB).Collection("frequencyRollups")
+	_, err := collection.BulkWrite(ctx, models, options.BulkWrite().SetOrdered(false))
+	return err
+}
+
+// rollupPreviousDay writes the rollups of the last complete UTC day before now
+func rollupPreviousDay(ctx context.Context, tracker *TrendTracker, now time.Time) {
+	rollups := dailyRollups(tracker, now.UTC().Add(-24*time.Hour))
+	if err := writeRollups(ctx, rollups); err != nil {
+		log.Printf("Error writing %d frequency rollups: %v", len(rollups), err)
+		return
+	}
+	log.Printf("Wrote %d frequency rollups", len(rollups))
+}
+
+// startRollupJob writes the rollups of the previous day on startup and then once per interval
+func startRollupJob(interval time.Duration) {
+	if interval <= 0 {
+		return
+	}
+	go func() {
+		rollupPreviousDay(context.Background(), trends, trends.now())
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for range ticker.C {
+			rollupPreviousDay(context.Background(), trends, trends.now())
+		}
+	}()
+}
+
+// fetchFrequencyHistory returns the daily rollups of the state over the last days, oldest first
+func fetchFrequencyHistory(ctx context.Context, code string, days int, now time.Time) ([]*FrequencyRollup, error) {
+	since := now.UTC().AddDate(0, 0, -days).Format(rollupDateLayout)
+	opts := options.Find().SetSort(bson.D{{Key: "date", Value: 1}})
+
+	collection := client.Database(config.MongoDB).Collection("frequencyRollups")
+	cursor, err := collection.Find(ctx, bson.M{"stateCode": code, "date": bson.M{"$gt": since}}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	rollups := []*FrequencyRollup{}
+	if err := cursor.All(ctx, &rollups); err != nil {
+		return nil, err
+	}
+	return rollups, nil
+}
+
+// Define the GraphQL frequency rollup type
+var frequencyRollupType = graphql.NewObject(graphql.ObjectConfig{
+	Name: "FrequencyRollup",
+	Fields: graphql.Fields{
+		"stateCode": &graphql.Field{
+			Type: graphql.String,
+		},
+		"date": &graphql.Field{
+			Type: graphql.String,
+		},
+		"selections": &graphql.Field{
+			Type: graphql.Int,
+		},
+	},
+})
+
+// frequencyHistoryField lists the daily selections of a state
+var frequencyHistoryField = &graphql.Field{
+	Type: graphql.NewList(frequencyRollupType),
+	Args: graphql.FieldConfigArgument{
+		"code": &graphql.ArgumentConfig{
+			Type: graphql.NewNonNull(graphql.String),
+		},
+		"days": &graphql.ArgumentConfig{
+			Type: graphql.Int,
+		},
+	},
+	Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+		days, ok := p.Args["days"].(int)
+		if !ok || days <= 0 {
+			days = defaultHistoryDays
+		}
+		return fetchFrequencyHistory(p.Context, p.Args["code"].(string), days, time.Now())
+	},
+}
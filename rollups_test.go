@@ -0,0 +1,79 @@
+package backend
+
+import (
+	"context"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func TestDailyRollupsSplitAtMidnight(t *testing.T) {
+	clock := &testClock{now: time.Date(2024, 3, 1, 23, 59, 59, 0, time.UTC)}
+	tracker := NewTrendTracker(clock.Now)
+	tracker.Record("FL")
+	tracker.Record("TX")
+	tracker.Record("TX")
+	clock.now = time.Date(2024, 3, 2, 0, 0, 0, 0, time.UTC)
+	tracker.Record("TX")
+
+	tests := []struct {
+		day  time.Time
+		want []FrequencyRollup
+	}{
+		{time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC), []FrequencyRollup{
+			{StateCode: "FL", Date: "2024-03-01", Selections: 1},
+			{StateCode: "TX", Date: "2024-03-01", Selections: 2},
+		}},
+		// the day is taken in UTC, whatever the zone of the time
+		{time.Date(2024, 3, 1, 20, 0, 0, 0, time.FixedZone("EST", -5*3600)), []FrequencyRollup{
+			{StateCode: "TX", Date: "2024-03-02", Selections: 1},
+		}},
+		{time.Date(2024, 3, 3, 0, 0, 0, 0, time.UTC), []FrequencyRollup{}},
+	}
+	for _, test := range tests {
+		rollups := dailyRollups(tracker, test.day)
+		sort.Slice(rollups, func(i, j int) bool { return rollups[i].StateCode < rollups[j].StateCode })
+		if !reflect.DeepEqual(rollups, test.want) {
+			t.Errorf("rollups of %s = %+v, want %+v", test.day, rollups, test.want)
+		}
+	}
+}
+
+func TestRollupPreviousDayIsIdempotent(t *testing.T) {
+	db := useTestMongo(t)
+	ctx := context.Background()
+	clock := &testClock{now: time.Date(2024, 3, 1, 23, 30, 0, 0, time.UTC)}
+	tracker := NewTrendTracker(clock.Now)
+	tracker.Record("FL")
+	tracker.Record("FL")
+
+	// crossing midnight rolls up the day that ended, as often as the job runs
+	clock.now = time.Date(2024, 3, 2, 0, 10, 0, 0, time.UTC)
+	tracker.Record("FL")
+	rollupPreviousDay(ctx, tracker, clock.Now())
+	rollupPreviousDay(ctx, tracker, clock.Now())
+	if count, _ := db.Collection("frequencyRollups").CountDocuments(ctx, bson.M{}); count != 1 {
+		t.Errorf("got %d rollups after rolling up the same day twice, want 1", count)
+	}
+
+	clock.now = time.Date(2024, 3, 3, 0, 10, 0, 0, time.UTC)
+	rollupPreviousDay(ctx, tracker, clock.Now())
+	history, err := fetchFrequencyHistory(ctx, "FL", 7, clock.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []*FrequencyRollup{
+		{StateCode: "FL", Date: "2024-03-01", Selections: 2},
+		{StateCode: "FL", Date: "2024-03-02", Selections: 1},
+	}
+	if !reflect.DeepEqual(history, want) {
+		t.Errorf("history of FL = %+v, want %+v", history, want)
+	}
+	// the current day has no rollup yet, so the last two days hold only the previous one
+	if history, _ := fetchFrequencyHistory(ctx, "FL", 2, clock.Now()); len(history) != 1 || history[0].Date != "2024-03-02" {
+		t.Errorf("history of FL over the last two days = %+v, want only 2024-03-02", history)
+	}
+}
@@ -0,0 +1,49 @@
+package backend
+
+import (
+	"time"
+
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/language/ast"
+)
+
+// durationScalar represents a Go duration string such as "90m" or "24h"
+var durationScalar = graphql.NewScalar(graphql.ScalarConfig{
+	Name:        "Duration",
+	Description: "The `Duration` scalar type represents a duration written as a Go duration string, e.g. \"24h\".",
+	Serialize: func(value interface{}) interface{} {
+		switch value := value.(type) {
+		case time.Duration:
+			return value.String()
+		case *time.Duration:
+			if value == nil {
+				return nil
+			}
+			return value.String()
+		default:
+			return nil
+		}
+	},
+	ParseValue: func(value interface{}) interface{} {
+		str, ok := value.(string)
+		if !ok {
+			return nil
+		}
+		d, err := time.ParseDuration(str)
+		if err != nil {
+			return nil
+		}
+		return d
+	},
+	ParseLiteral: func(valueAST ast.Value) interface{} {
+		str, ok := valueAST.(*ast.StringValue)
+		if !ok {
+			return nil
+		}
+		d, err := time.ParseDuration(str.Value)
+		if err != nil {
+			return nil
+		}
+		return d
+	},
+})
@@ -0,0 +1,172 @@
+package backend
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// searchCacheSize caps the number of searches cached per tenant
+	searchCacheSize = 1000
+	// searchCacheTTL is how long cached results are served, bounding how stale their order gets as
+	// frequencies change
+	searchCacheTTL = time.Minute
+)
+
+// searchCacheEntry holds the results of one search until it expires, along with the trie key
+// searched so it can be invalidated by name
+type searchCacheEntry struct {
+	prefix  string
+	fold    bool
+	results []*State
+	expires time.Time
+}
+
+// SearchCache keeps the results of recent searches of one trie. Warm prefixes are searched again
+// whenever the cache is reset, so it is never cold after a rebuild.
+type SearchCache struct {
+	mu      sync.Mutex
+	max     int
+	ttl     time.Duration
+	now     func() time.Time
+	entries map[string]searchCacheEntry
+	warm    []string
+}
+
+// NewSearchCache creates a cache holding at most max searches for the given time
+func NewSearchCache(max int, ttl time.Duration, now func() time.Time) *SearchCache {
+	return &SearchCache{max: max, ttl: ttl, now: now, entries: make(map[string]searchCacheEntry)}
+}
+
+// searchCacheKey returns the cache key of a search with the options
+func searchCacheKey(search string, opts searchOptions) string {
+	return fmt.Sprintf("%t|%t|%t|%s|%s", opts.Tokenize, opts.IgnoreCase, opts.WordFallback, opts.Locale, search)
+}
+
+// Get returns a copy of the cached results of the search, so callers may sort them
+func (c *SearchCache) Get(key string) ([]*State, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry, ok := c.entries[key]
+	if !ok || c.now().After(entry.expires) {
+		return nil, false
+	}
+	return append([]*State(nil), entry.results...), true
+}
+
+// searchCachePrefix returns the trie key a search is made under, lowercased for case-insensitive
+// searches
+func searchCachePrefix(search string, opts searchOptions) string {
+	if opts.IgnoreCase {
+		return foldKey(search)
+	}
+	return collationKey(search)
+}
+
+// Put caches the results of the search, dropping expired searches when the cache is full
+func (c *SearchCache) Put(key, prefix string, fold bool, results []*State) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	now := c.now()
+	if len(c.entries) >= c.max {
+		for k, entry := range c.entries {
+			if now.After(entry.expires) {
+				delete(c.entries, k)
+			}
+		}
+	}
+	if len(c.entries) >= c.max {
+		return
+	}
+	c.entries[key] = searchCacheEntry{prefix: prefix, fold: fold, results: append([]*State(nil), results...), expires: now.Add(c.ttl)}
+}
+
+// Invalidate drops the searches whose order may change with the state's frequency: those whose
+// prefix is a prefix of the state's name or starts with it, and those whose results include the
+// state through an expansion, a translation or a word. Other searches stay cached. It is called for
+// frequencies changed outside the trie, not for selections made through it.
+func (c *SearchCache) Invalidate(state *State) {
+	name, folded := collationKey(state.Name), foldKey(state.Name)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for key, entry := range c.entries {
+		nameKey := name
+		if entry.fold {
+			nameKey = folded
+		}
+		if strings.HasPrefix(nameKey, entry.prefix) || strings.HasPrefix(entry.prefix, nameKey) || containsState(entry.results, state) {
+			delete(c.entries, key)
+		}
+	}
+}
+
+// containsState reports whether the state is one of the states
+func containsState(states []*State, state *State) bool {
+	for _, s := range states {
+		if s == state {
+			return true
+		}
+	}
+	return false
+}
+
+// Reset drops every cached search
+func (c *SearchCache) Reset() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries = make(map[string]searchCacheEntry)
+}
+
+// SetWarmPrefixes sets the prefixes searched whenever the cache is warmed
+func (c *SearchCache) SetWarmPrefixes(prefixes []string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.warm = prefixes
+}
+
+// WarmPrefixes returns the prefixes searched whenever the cache is warmed
+func (c *SearchCache) WarmPrefixes() []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.warm
+}
+
+// cachedSearch returns the results of the search from the store's cache, searching the trie and
+// caching them on a miss. Filtered searches, and searches cut short by their context, are not cached.
+func cachedSearch(ctx context.Context, s *TrieStore, search string, opts searchOptions, filters ...stateFilter) []*State {
+	if len(filters) > 0 {
+		return uncachedSearch(ctx, s, search, opts, filters...)
+	}
+	key := searchCacheKey(search, opts)
+	if results, ok := s.Cache().Get(key); ok {
+		return results
+	}
+	results := uncachedSearch(ctx, s, search, opts)
+	if ctx.Err() != nil {
+		return nil
+	}
+	s.Cache().Put(key, searchCachePrefix(search, opts), opts.IgnoreCase, results)
+	return results
+}
+
+// uncachedSearch searches the store's trie, including expanded abbreviations, falling back to its
+// word index when requested
+func uncachedSearch(ctx context.Context, s *TrieStore, search string, opts searchOptions, filters ...stateFilter) []*State {
+	results := searchWithExpansions(ctx, s, search, opts, filters...)
+	if len(results) == 0 && opts.WordFallback && !hasWildcard(search) {
+		return searchWords(s.Words(), search, filters...)
+	}
+	return results
+}
+
+// warmSearchCache caches the results of the store's warm prefixes for plain and case-insensitive
+// searches
+func warmSearchCache(s *TrieStore) {
+	for _, prefix := range s.Cache().WarmPrefixes() {
+		cachedSearch(context.Background(), s, prefix, searchOptions{})
+		cachedSearch(context.Background(), s, prefix, searchOptions{IgnoreCase: true})
+	}
+}
@@ -0,0 +1,97 @@
+package backend
+
+import (
+	"context"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+)
+
+func TestSearchCacheInvalidateEvictsAffectedPrefixes(t *testing.T) {
+	texas := &State{Name: "Texas", Code: "TX"}
+	utah := &State{Name: "Utah", Code: "UT"}
+	cache := NewSearchCache(10, time.Minute, time.Now)
+	for _, search := range []struct {
+		prefix  string
+		fold    bool
+		results []*State
+	}{
+		{"T", false, []*State{texas}},
+		{"Tex", false, []*State{texas}},
+		{"Texas City", false, nil},
+		{"tex", true, []*State{texas}},
+		{"tex", false, nil},
+		{"U", false, []*State{utah}},
+		{"Tr", false, nil},
+		{"lone star", false, []*State{texas}},
+	} {
+		cache.Put(searchCacheKey(search.prefix, searchOptions{IgnoreCase: search.fold}), search.prefix, search.fold, search.results)
+	}
+
+	cache.Invalidate(texas)
+
+	var kept []string
+	for key := range cache.entries {
+		kept = append(kept, key)
+	}
+	sort.Strings(kept)
+	want := []string{
+		searchCacheKey("Tr", searchOptions{}),
+		searchCacheKey("U", searchOptions{}),
+		searchCacheKey("tex", searchOptions{}),
+	}
+	sort.Strings(want)
+	if !reflect.DeepEqual(kept, want) {
+		t.Errorf("kept %q, want %q", kept, want)
+	}
+}
+
+func TestSearchCacheExpires(t *testing.T) {
+	now := time.Now()
+	cache := NewSearchCache(1, time.Minute, func() time.Time { return now })
+	key := searchCacheKey("Tex", searchOptions{})
+	cache.Put(key, "Tex", false, []*State{{Name: "Texas"}})
+	if _, ok := cache.Get(key); !ok {
+		t.Fatal("a search just cached missed")
+	}
+	now = now.Add(2 * time.Minute)
+	if _, ok := cache.Get(key); ok {
+		t.Error("an expired search hit")
+	}
+	cache.Put(searchCacheKey("Utah", searchOptions{}), "Utah", false, nil)
+	if _, ok := cache.Get(searchCacheKey("Utah", searchOptions{})); !ok {
+		t.Error("a full cache did not drop its expired search")
+	}
+}
+
+func TestSelectionKeepsSearchCached(t *testing.T) {
+	s := newTestStore(t,
+		State{Name: "Texas", Code: "TX", Enabled: true, Kind: KindState},
+		State{Name: "Tennessee", Code: "TN", Enabled: true, Kind: KindState},
+	)
+	ctx := context.Background()
+	key := searchCacheKey("Te", searchOptions{})
+
+	results := cachedSearch(ctx, s, "Te", searchOptions{})
+	if len(results) != 2 {
+		t.Fatalf("results = %v, want 2 states", results)
+	}
+	updateFrequency(ctx, s, "Texas")
+	if _, ok := s.Cache().Get(key); !ok {
+		t.Fatal("selecting a result evicted the search it came from")
+	}
+
+	// a frequency changed outside the trie evicts it
+	updated := copyState(findState(s.Root(), "Tennessee"))
+	updated.Frequency = 5
+	s.writeMu.Lock()
+	applyStateChanges(ctx, s, stateChanges{Updated: []*State{updated}})
+	s.writeMu.Unlock()
+	if _, ok := s.Cache().Get(key); ok {
+		t.Fatal("a synced frequency change did not evict the search")
+	}
+	if results := cachedSearch(ctx, s, "Te", searchOptions{}); results[0].Name != "Tennessee" {
+		t.Errorf("results = %v, want Tennessee first", results)
+	}
+}
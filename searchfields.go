@@ -0,0 +1,124 @@
+package backend
+
+import (
+	"context"
+	"strings"
+
+	"golang.org/x/sync/errgroup"
+)
+
+const (
+	// searchFieldName matches searches against state names
+	searchFieldName = "name"
+	// searchFieldCode matches searches against state codes
+	searchFieldCode = "code"
+)
+
+// searchFields are the state fields a search is matched against
+type searchFields struct {
+	Name bool
+	Code bool
+}
+
+// codeKey returns the key of a state code in the code trie, so codes match in any case
+func codeKey(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
+
+// buildCodeTrie builds a trie keyed by the uppercased codes of the states under root. States
+// without a code are left out.
+func buildCodeTrie(root *TrieNode) *TrieNode {
+	codes := newTrieRoot()
+	var states []*State
+	collectStates(context.Background(), root, &states)
+	for _, state := range states {
+		if key := codeKey(state.Code); key != "" {
+			insertKey(codes, key, state)
+		}
+	}
+	return codes
+}
+
+// parseSearchFields reads the searchField argument, matching names only by default
+func parseSearchFields(arg interface{}) (searchFields, error) {
+	values := stringListArg(arg)
+	if len(values) == 0 {
+		return searchFields{Name: true}, nil
+	}
+	var fields searchFields
+	for _, value := range values {
+		switch value {
+		case searchFieldName:
+			fields.Name = true
+		case searchFieldCode:
+			fields.Code = true
+		default:
+			return fields, invalidInput("searchField %q must be %q or %q", value, searchFieldName, searchFieldCode)
+		}
+	}
+	return fields, nil
+}
+
+// searchByFields searches the names and codes of the tenant's states as selected. Searching both runs
+// the searches in parallel and merges their results, most frequently selected first, keeping states
+// matched by both once. States are told apart by name, since search backends other than the trie
+// return their own copies.
+func searchByFields(ctx context.Context, s *TrieStore, search string, opts searchOptions, fields searchFields, filters ...stateFilter) ([]*State, error) {
+	if !fields.Code {
+		return searchProvider.Search(ctx, search, opts, filters...)
+	}
+	if !fields.Name {
+		return searchStates(ctx, s.Codes(), codeKey(search), filters...), nil
+	}
+
+	var byName, byCode []*State
+	group, groupCtx := errgroup.WithContext(ctx)
+	group.Go(func() error {
+		var err error
+		byName, err = searchProvider.Search(groupCtx, search, opts, filters...)
+		return err
+	})
+	group.Go(func() error {
+		byCode = searchStates(groupCtx, s.Codes(), codeKey(search), filters...)
+		return nil
+	})
+	if err := group.Wait(); err != nil {
+		return nil, err
+	}
+
+	merged := make([]*State, 0, len(byName)+len(byCode))
+	seen := make(map[string]bool, len(byName)+len(byCode))
+	for _, state := range append(byName, byCode...) {
+		if !seen[state.Name] {
+			merged = append(merged, state)
+			seen[state.Name] = true
+		}
+	}
+	sortStatesByFrequency(merged)
+	return merged, nil
+}
+
+// buildCodeIndex maps the uppercased code of every state with one to the states having it, so
+// codes are looked up without walking a trie
+func buildCodeIndex(states []*State) map[string][]*State {
+	index := make(map[string][]*State, len(states))
+	for _, state := range states {
+		if key := codeKey(state.Code); key != "" {
+			index[key] = append(index[key], state)
+		}
+	}
+	return index
+}
+
+// stateByCode returns the enabled state with exactly the code in any case from the code index, or
+// nil when there is none. When several states share the code the most frequently selected is
+// returned.
+func stateByCode(index map[string][]*State, code string) *State {
+	var found *State
+	for _, state := range index[codeKey(code)] {
+		if state.Enabled && (found == nil || state.loadFrequency() > found.loadFrequency()) {
+			found = state
+		}
+	}
+	return found
+}
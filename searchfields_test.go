@@ -0,0 +1,46 @@
+package backend
+
+import (
+	"context"
+	"testing"
+)
+
+func TestSearchNamesAndCodes(t *testing.T) {
+	s := newTestStore(t,
+		State{Name: "California", Code: "CA", Enabled: true, Kind: KindState, Frequency: 10},
+		State{Name: "Colorado", Code: "CO", Enabled: true, Kind: KindState, Frequency: 20},
+		State{Name: "Nevada", Code: "NV", Enabled: true, Kind: KindState, Frequency: 5},
+		State{Name: "Ontario", Code: "ON", Enabled: true, Kind: KindProvince, Frequency: 1},
+	)
+	tests := []struct {
+		query string
+		want  string
+	}{
+		// CA only matches California through its code, Ca only through its name
+		{`{ states(search: "CA", searchField: ["name", "code"]) { name } }`, `{"states":[{"name":"California"}]}`},
+		{`{ states(search: "Ca", searchField: ["name", "code"]) { name } }`, `{"states":[{"name":"California"}]}`},
+		{`{ states(search: "CA") { name } }`, `{"states":[]}`},
+		{`{ states(search: "ca", searchField: ["code"]) { name } }`, `{"states":[{"name":"California"}]}`},
+		// results of both searches are merged by frequency
+		{`{ states(search: "N", searchField: ["name", "code"]) { name } }`, `{"states":[{"name":"Nevada"}]}`},
+		{`{ states(search: "O", searchField: ["name", "code"]) { name } }`, `{"states":[{"name":"Ontario"}]}`},
+		{`{ states(search: "C", searchField: ["code", "name"]) { name } }`, `{"states":[{"name":"Colorado"},{"name":"California"}]}`},
+	}
+	for _, test := range tests {
+		if got := resolveCodeQuery(t, test.query); got != test.want {
+			t.Errorf("%s = %s, want %s", test.query, got, test.want)
+		}
+	}
+
+	// states matched by both their name and code are selected once per search
+	before := findState(s.Root(), "Colorado").loadFrequency()
+	resolveCodeQuery(t, `{ states(search: "Co", searchField: ["name", "code"]) { name } }`)
+	if frequency := findState(s.Root(), "Colorado").loadFrequency(); frequency != before+1 {
+		t.Errorf("frequency of Colorado after matching its name and code = %d, want %d", frequency, before+1)
+	}
+
+	result := runGraphQL(t, context.Background(), `{ states(search: "C", searchField: ["kind"]) { name } }`)
+	if len(result.Errors) != 1 || result.Errors[0].Extensions["code"] != invalidInputCode {
+		t.Errorf("searching an unknown field = %v, want an %s error", result.Errors, invalidInputCode)
+	}
+}
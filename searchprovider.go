@@ -0,0 +1,68 @@
+package backend
+
+import (
+	"context"
+	"fmt"
+)
+
+const (
+	// SearchBackendTrie searches the in-memory tries
+	SearchBackendTrie = "trie"
+	// SearchBackendElasticsearch searches Elasticsearch indexes rebuilt from MongoDB
+	SearchBackendElasticsearch = "elasticsearch"
+)
+
+// SearchProvider finds the states of the request's tenant matching a search and passing the filters,
+// most frequently selected first. Disabled states are never returned.
+type SearchProvider interface {
+	Search(ctx context.Context, search string, opts searchOptions, filters ...stateFilter) ([]*State, error)
+}
+
+// TrieSearchProvider searches the tenant's in-memory trie, including expanded abbreviations, through
+// its search cache
+type TrieSearchProvider struct{}
+
+// searchProvider is the search backend serving the states and search queries
+var searchProvider SearchProvider = TrieSearchProvider{}
+
+// Search searches the tenant's trie, falling back to its word index when requested and to its
+// repository when MONGO_FALLBACK is set and the trie has no match
+func (TrieSearchProvider) Search(ctx context.Context, search string, opts searchOptions, filters ...stateFilter) ([]*State, error) {
+	tenantStore, err := storeFor(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := restorePruned(ctx, tenantStore, search); err != nil {
+		logf(ctx, "Error reloading pruned states for %s: %v", search, err)
+	}
+	results := cachedSearch(ctx, tenantStore, search, opts, filters...)
+	if len(results) == 0 && config.MongoFallback && ctx.Err() == nil {
+		if inserted, err := fallbackToRepository(ctx, tenantStore, search); err != nil {
+			logf(ctx, "Error falling back to the repository for %s: %v", search, err)
+		} else if inserted {
+			results = cachedSearch(ctx, tenantStore, search, opts, filters...)
+		}
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// initSearchProvider selects the search backend configured by SEARCH_BACKEND
+func initSearchProvider() error {
+	switch config.SearchBackend {
+	case SearchBackendTrie:
+		searchProvider = TrieSearchProvider{}
+	case SearchBackendElasticsearch:
+		provider, err := NewElasticsearchSearchProvider(config.ESAddresses)
+		if err != nil {
+			return err
+		}
+		searchProvider = provider
+		startSearchIndexRebuild(provider, config.ESReindexInterval)
+	default:
+		return fmt.Errorf("unknown search backend %q", config.SearchBackend)
+	}
+	return nil
+}
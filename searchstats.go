@@ -0,0 +1,275 @@
+package backend
+
+import (
+	"math/bits"
+	"sync"
+	"time"
+
+	"github.com/graphql-go/graphql"
+)
+
+const (
+	// statsBucketSize is the width of a single search stats bucket
+	statsBucketSize = time.Minute
+	// statsRetention is how far back search stats are kept
+	statsRetention = 24 * time.Hour
+	// maxStatsPrefixesPerBucket caps the distinct prefixes tracked per bucket
+	maxStatsPrefixesPerBucket = 10000
+	// defaultStatsWindow is the window used when the query does not give one
+	defaultStatsWindow = 15 * time.Minute
+)
+
+const (
+	// latencySubBuckets is the number of linear sub-buckets per power of two in the latency histogram
+	latencySubBucketBits = 3
+	latencySubBuckets    = 1 << latencySubBucketBits
+	// latencyBuckets covers latencies up to 2^40 microseconds
+	latencyBuckets = 41 * latencySubBuckets
+)
+
+// latencyHistogram is an HDR-style log-linear histogram of latencies in microseconds
+type latencyHistogram [latencyBuckets]uint64
+
+// latencyBucketIndex returns the histogram bucket for the latency in microseconds
+func latencyBucketIndex(micros uint64) int {
+	if micros < latencySubBuckets {
+		return int(micros)
+	}
+	exponent := bits.Len64(micros) - 1 - latencySubBucketBits
+	sub := int(micros>>uint(exponent)) - latencySubBuckets
+	index := (exponent+1)*latencySubBuckets + sub
+	if index >= latencyBuckets {
+		return latencyBuckets - 1
+	}
+	return index
+}
+
+// latencyBucketUpperBound returns the largest latency in microseconds counted in the bucket
+func latencyBucketUpperBound(index int) uint64 {
+	if index < latencySubBuckets {
+		return uint64(index)
+	}
+	exponent := index/latencySubBuckets - 1
+	sub := uint64(index%latencySubBuckets + latencySubBuckets)
+	return (sub+1)<<uint(exponent) - 1
+}
+
+// add counts one latency in the histogram
+func (h *latencyHistogram) add(latency time.Duration) {
+	h[latencyBucketIndex(uint64(latency/time.Microsecond))]++
+}
+
+// merge adds the counts of another histogram
+func (h *latencyHistogram) merge(other *latencyHistogram) {
+	for i, count := range other {
+		h[i] += count
+	}
+}
+
+// quantile returns the latency below which the given fraction of the counted latencies fall
+func (h *latencyHistogram) quantile(q float64, total uint64) time.Duration {
+	if total == 0 {
+		return 0
+	}
+	rank := uint64(q*float64(total) + 0.5)
+	if rank < 1 {
+		rank = 1
+	}
+	var seen uint64
+	for i, count := range h {
+		seen += count
+		if seen >= rank {
+			return time.Duration(latencyBucketUpperBound(i)) * time.Microsecond
+		}
+	}
+	return time.Duration(latencyBucketUpperBound(latencyBuckets-1)) * time.Microsecond
+}
+
+// statsBucket aggregates the searches made during one bucket
+type statsBucket struct {
+	start       time.Time
+	searches    uint64
+	zeroResults uint64
+	results     uint64
+	prefixes    map[string]int
+	latency     latencyHistogram
+}
+
+// SearchStats aggregates search counts, results and latencies in a ring buffer of time buckets
+type SearchStats struct {
+	mu      sync.Mutex
+	now     func() time.Time
+	buckets []statsBucket
+}
+
+// SearchStatsSummary summarizes the searches made within a window
+type SearchStatsSummary struct {
+	Window         time.Duration `json:"window"`
+	TotalSearches  int           `json:"totalSearches"`
+	UniquePrefixes int           `json:"uniquePrefixes"`
+	ZeroResultRate float64       `json:"zeroResultRate"`
+	AverageResults float64       `json:"averageResults"`
+	P50Latency     time.Duration `json:"p50Latency"`
+	P95Latency     time.Duration `json:"p95Latency"`
+}
+
+var searchStats = NewSearchStats(time.Now)
+
+// NewSearchStats creates search stats that read the current time from the given clock
+func NewSearchStats(now func() time.Time) *SearchStats {
+	return &SearchStats{
+		now:     now,
+		buckets: make([]statsBucket, int(statsRetention/statsBucketSize)),
+	}
+}
+
+// bucketFor returns the bucket for the given time, evicting whatever it held before
+func (s *SearchStats) bucketFor(at time.Time) *statsBucket {
+	start := at.Truncate(statsBucketSize)
+	bucket := &s.buckets[int(start.Unix()/int64(statsBucketSize/time.Second))%len(s.buckets)]
+	if !bucket.start.Equal(start) {
+		*bucket = statsBucket{start: start, prefixes: make(map[string]int)}
+	}
+	return bucket
+}
+
+// Record counts one search of the prefix that returned the given number of results
+func (s *SearchStats) Record(prefix string, results int, latency time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	bucket := s.bucketFor(s.now())
+	bucket.searches++
+	bucket.results += uint64(results)
+	if results == 0 {
+		bucket.zeroResults++
+	}
+	normalized := normalizePrefix(prefix)
+	if _, ok := bucket.prefixes[normalized]; ok || len(bucket.prefixes) < maxStatsPrefixesPerBucket {
+		bucket.prefixes[normalized]++
+	}
+	bucket.latency.add(latency)
+}
+
+// Summary aggregates the buckets within the window ending now
+func (s *SearchStats) Summary(window time.Duration) *SearchStatsSummary {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	since := s.now().Add(-window).Truncate(statsBucketSize)
+	var searches, zeroResults, results uint64
+	var latency latencyHistogram
+	prefixes := make(map[string]int)
+	for i := range s.buckets {
+		bucket := &s.buckets[i]
+		if bucket.searches == 0 || bucket.start.Before(since) {
+			continue
+		}
+		searches += bucket.searches
+		zeroResults += bucket.zeroResults
+		results += bucket.results
+		latency.merge(&bucket.latency)
+		for prefix, count := range bucket.prefixes {
+			prefixes[prefix] += count
+		}
+	}
+
+	summary := &SearchStatsSummary{
+		Window:         window,
+		TotalSearches:  int(searches),
+		UniquePrefixes: len(prefixes),
+		P50Latency:     latency.quantile(0.50, searches),
+		P95Latency:     latency.quantile(0.95, searches),
+	}
+	if searches > 0 {
+		summary.ZeroResultRate = float64(zeroResults) / float64(searches)
+		summary.AverageResults = float64(results) / float64(searches)
+	}
+	return summary
+}
+
+// TopPrefixes returns the most searched prefixes within the window ending now
+func (s *SearchStats) TopPrefixes(window time.Duration, limit int) []PrefixStat {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	since := s.now().Add(-window).Truncate(statsBucketSize)
+	counts := make(map[string]int)
+	for i := range s.buckets {
+		bucket := &s.buckets[i]
+		if bucket.searches == 0 || bucket.start.Before(since) {
+			continue
+		}
+		for prefix, count := range bucket.prefixes {
+			counts[prefix] += count
+		}
+	}
+
+	stats := make([]PrefixStat, 0, len(counts))
+	for prefix, count := range counts {
+		stats = append(stats, PrefixStat{Prefix: prefix, Count: count})
+	}
+	sortPrefixStats(stats)
+	if limit > 0 && len(stats) > limit {
+		stats = stats[:limit]
+	}
+	return stats
+}
+
+// Define the GraphQL search stats type
+var searchStatsType = graphql.NewObject(graphql.ObjectConfig{
+	Name: "SearchStats",
+	Fields: graphql.Fields{
+		"window": &graphql.Field{
+			Type: durationScalar,
+		},
+		"totalSearches": &graphql.Field{
+			Type: graphql.Int,
+		},
+		"uniquePrefixes": &graphql.Field{
+			Type: graphql.Int,
+		},
+		"zeroResultRate": &graphql.Field{
+			Type: graphql.Float,
+		},
+		"averageResults": &graphql.Field{
+			Type: graphql.Float,
+		},
+		"p50LatencyMs": &graphql.Field{
+			Type: graphql.Float,
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				return durationMillis(p.Source.(*SearchStatsSummary).P50Latency), nil
+			},
+		},
+		"p95LatencyMs": &graphql.Field{
+			Type: graphql.Float,
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				return durationMillis(p.Source.(*SearchStatsSummary).P95Latency), nil
+			},
+		},
+	},
+})
+
+// durationMillis converts a duration to fractional milliseconds
+func durationMillis(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
+
+// searchStatsField summarizes the searches made within a recent window
+var searchStatsField = &graphql.Field{
+	Type: searchStatsType,
+	Args: graphql.FieldConfigArgument{
+		"window": &graphql.ArgumentConfig{
+			Type: durationScalar,
+		},
+	},
+	Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+		window, ok := p.Args["window"].(time.Duration)
+		if !ok || window <= 0 {
+			window = defaultStatsWindow
+		}
+		if window > statsRetention {
+			window = statsRetention
+		}
+		return searchStats.Summary(window), nil
+	},
+}
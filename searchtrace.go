@@ -0,0 +1,99 @@
+package backend
+
+import (
+	"errors"
+	"sort"
+
+	"github.com/graphql-go/graphql"
+)
+
+// errDebugDisabled is returned by debug-only queries when the request did not enable debugging
+var errDebugDisabled = errors.New("debug information requires the debug=1 query parameter")
+
+// TraceResult describes how far a prefix can be followed in the trie. FailedAt is the first
+// character without a matching child, or zero when the whole prefix matched, and
+// SuggestedNextChars are the children of the deepest node reached.
+type TraceResult struct {
+	MatchedChars       int
+	FailedAt           rune
+	SuggestedNextChars []rune
+}
+
+// TraceSearch follows the prefix through the trie and reports where it diverged
+func TraceSearch(root *TrieNode, prefix string) TraceResult {
+	result := TraceResult{}
+	node := root
+	for _, char := range prefix {
+		child := node.Children[char]
+		if child == nil {
+			result.FailedAt = char
+			break
+		}
+		node = child
+		result.MatchedChars++
+	}
+
+	result.SuggestedNextChars = make([]rune, 0, len(node.Children))
+	for char := range node.Children {
+		result.SuggestedNextChars = append(result.SuggestedNextChars, char)
+	}
+	sort.Slice(result.SuggestedNextChars, func(i, j int) bool {
+		return result.SuggestedNextChars[i] < result.SuggestedNextChars[j]
+	})
+	return result
+}
+
+// Define the GraphQL trace result type
+var traceResultType = graphql.NewObject(graphql.ObjectConfig{
+	Name: "TraceResult",
+	Fields: graphql.Fields{
+		"matchedChars": &graphql.Field{
+			Type: graphql.Int,
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				return p.Source.(TraceResult).MatchedChars, nil
+			},
+		},
+		"failedAt": &graphql.Field{
+			Type:        graphql.String,
+			Description: "First character without a match, null when the whole prefix matched",
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				failedAt := p.Source.(TraceResult).FailedAt
+				if failedAt == 0 {
+					return nil, nil
+				}
+				return string(failedAt), nil
+			},
+		},
+		"suggestedNextChars": &graphql.Field{
+			Type: graphql.NewList(graphql.String),
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				chars := p.Source.(TraceResult).SuggestedNextChars
+				strs := make([]string, len(chars))
+				for i, char := range chars {
+					strs[i] = string(char)
+				}
+				return strs, nil
+			},
+		},
+	},
+})
+
+// traceSearchField traces a prefix through the tenant's trie, for requests with debugging enabled
+var traceSearchField = &graphql.Field{
+	Type: traceResultType,
+	Args: graphql.FieldConfigArgument{
+		"prefix": &graphql.ArgumentConfig{
+			Type: graphql.NewNonNull(graphql.String),
+		},
+	},
+	Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+		if !debugEnabled(p.Context) {
+			return nil, errDebugDisabled
+		}
+		tenantStore, err := storeFor(p.Context)
+		if err != nil {
+			return nil, err
+		}
+		return TraceSearch(tenantStore.Root(), collationKey(p.Args["prefix"].(string))), nil
+	},
+}
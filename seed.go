@@ -0,0 +1,235 @@
+package backend
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// seedRecord is a state as written in a seed file. Enabled is a pointer so states are enabled
+// unless the file says otherwise.
+type seedRecord struct {
+	Name         string            `json:"name"`
+	Code         string            `json:"code"`
+	Frequency    int               `json:"frequency"`
+	Enabled      *bool             `json:"enabled"`
+	Kind         string            `json:"kind"`
+	Translations map[string]string `json:"translations"`
+}
+
+// SeedError is a record of a seed file that cannot be inserted
+type SeedError struct {
+	Line int
+	Err  error
+}
+
+// Error returns the line and the reason
+func (e SeedError) Error() string {
+	return fmt.Sprintf("line %d: %v", e.Line, e.Err)
+}
+
+// state validates the record and returns it as a state
+func (r seedRecord) state() (*State, error) {
+	state := &State{Name: r.Name, Code: r.Code, Frequency: int64(r.Frequency), Enabled: true, Kind: r.Kind, Translations: r.Translations}
+	if r.Enabled != nil {
+		state.Enabled = *r.Enabled
+	}
+	if state.Kind == "" {
+		state.Kind = KindState
+	}
+	normalizeState(state)
+	if strings.TrimSpace(state.Name) == "" {
+		return nil, invalidInput("name must not be empty")
+	}
+	if err := inputPolicy.Validate(state.Name); err != nil {
+		return nil, err
+	}
+	if err := validateCode(state.Code, config.StateCodeLength); err != nil {
+		return nil, err
+	}
+	if state.Frequency < 0 {
+		return nil, invalidInput("frequency must not be negative")
+	}
+	if kindIndex(state.Kind) == 0 && state.Kind != KindState {
+		return nil, invalidInput("kind %q must be one of %s", state.Kind, strings.Join(stateKinds, ", "))
+	}
+	return state, nil
+}
+
+// ReadSeedFile reads the states of a JSON or CSV seed file, chosen by its extension, returning the
+// valid states and an error for each invalid or duplicate record
+func ReadSeedFile(path string) ([]*State, []SeedError, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		return readSeedJSON(data)
+	case ".csv":
+		return readSeedCSV(data)
+	}
+	return nil, nil, fmt.Errorf("seed file %s must be .json or .csv", path)
+}
+
+// seedStates validates the records by line, dropping invalid ones and names seen on earlier lines.
+// The errors of the records are added to the ones already found and sorted by line.
+func seedStates(records []seedRecord, lines []int, errs []SeedError) ([]*State, []SeedError) {
+	var states []*State
+	seen := make(map[string]int)
+	for i, record := range records {
+		state, err := record.state()
+		if err != nil {
+			errs = append(errs, SeedError{Line: lines[i], Err: err})
+			continue
+		}
+		if line, ok := seen[state.Name]; ok {
+			errs = append(errs, SeedError{Line: lines[i], Err: fmt.Errorf("state %q already defined on line %d", state.Name, line)})
+			continue
+		}
+		seen[state.Name] = lines[i]
+		states = append(states, state)
+	}
+	sort.SliceStable(errs, func(i, j int) bool { return errs[i].Line < errs[j].Line })
+	return states, errs
+}
+
+// readSeedJSON reads a JSON array of states, reporting each record by the line it starts on
+func readSeedJSON(data []byte) ([]*State, []SeedError, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	if token, err := decoder.Token(); err != nil || token != json.Delim('[') {
+		return nil, nil, errors.New("JSON seed file must hold an array of states")
+	}
+	lineAt := func(offset int64) int {
+		return bytes.Count(data[:offset], []byte("\n")) + 1
+	}
+	var records []seedRecord
+	var lines []int
+	var errs []SeedError
+	for decoder.More() {
+		var raw json.RawMessage
+		if err := decoder.Decode(&raw); err != nil {
+			return nil, nil, fmt.Errorf("line %d: %w", lineAt(decoder.InputOffset()), err)
+		}
+		line := lineAt(decoder.InputOffset() - int64(len(raw)))
+		var record seedRecord
+		if err := json.Unmarshal(raw, &record); err != nil {
+			errs = append(errs, SeedError{Line: line, Err: err})
+			continue
+		}
+		records = append(records, record)
+		lines = append(lines, line)
+	}
+	states, errs := seedStates(records, lines, errs)
+	return states, errs, nil
+}
+
+// readSeedCSV reads CSV states with a header row naming the columns: name and code are required,
+// frequency, enabled and kind optional
+func readSeedCSV(data []byte) ([]*State, []SeedError, error) {
+	reader := csv.NewReader(bytes.NewReader(data))
+	reader.FieldsPerRecord = -1
+	header, err := reader.Read()
+	if err != nil {
+		return nil, nil, fmt.Errorf("CSV seed file must start with a header row: %w", err)
+	}
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	for _, required := range []string{"name", "code"} {
+		if _, ok := columns[required]; !ok {
+			return nil, nil, fmt.Errorf("CSV seed file has no %s column", required)
+		}
+	}
+
+	var records []seedRecord
+	var lines []int
+	var errs []SeedError
+	for {
+		row, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		var parseErr *csv.ParseError
+		if errors.As(err, &parseErr) {
+			errs = append(errs, SeedError{Line: parseErr.StartLine, Err: parseErr.Err})
+			continue
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+		line, _ := reader.FieldPos(0)
+		field := func(name string) string {
+			if i, ok := columns[name]; ok && i < len(row) {
+				return strings.TrimSpace(row[i])
+			}
+			return ""
+		}
+		record := seedRecord{Name: field("name"), Code: field("code"), Kind: field("kind")}
+		if value := field("frequency"); value != "" {
+			if record.Frequency, err = strconv.Atoi(value); err != nil {
+				errs = append(errs, SeedError{Line: line, Err: fmt.Errorf("frequency %q is not an integer", value)})
+				continue
+			}
+		}
+		if value := field("enabled"); value != "" {
+			enabled, err := strconv.ParseBool(value)
+			if err != nil {
+				errs = append(errs, SeedError{Line: line, Err: fmt.Errorf("enabled %q is not a boolean", value)})
+				continue
+			}
+			record.Enabled = &enabled
+		}
+		records = append(records, record)
+		lines = append(lines, line)
+	}
+	states, errs := seedStates(records, lines, errs)
+	return states, errs, nil
+}
+
+// SeedCollection inserts the states into the collection, first dropping it when clear is set, and
+// creates its indexes. It returns the number of states inserted; states whose name is already in
+// the collection are skipped and reported in the error.
+func SeedCollection(ctx context.Context, collection *mongo.Collection, states []*State, clear bool) (int, error) {
+	if clear {
+		if err := collection.Drop(ctx); err != nil {
+			return 0, err
+		}
+	}
+	if err := EnsureStateIndexes(ctx, collection); err != nil {
+		return 0, err
+	}
+	if len(states) == 0 {
+		return 0, nil
+	}
+	documents := make([]interface{}, len(states))
+	for i, state := range states {
+		documents[i] = state
+	}
+	_, err := collection.InsertMany(ctx, documents, options.InsertMany().SetOrdered(false))
+	var writeErr mongo.BulkWriteException
+	if !errors.As(err, &writeErr) || len(writeErr.WriteErrors) == 0 {
+		if err != nil {
+			return 0, err
+		}
+		return len(states), nil
+	}
+	failed := make([]string, 0, len(writeErr.WriteErrors))
+	for _, e := range writeErr.WriteErrors {
+		failed = append(failed, fmt.Sprintf("%q: %s", states[e.Index].Name, e.Message))
+	}
+	return len(states) - len(failed), fmt.Errorf("%d states not inserted: %s", len(failed), strings.Join(failed, "; "))
+}
@@ -0,0 +1,70 @@
+package backend
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// writeTestFile writes the content to a file with the given name in a temporary directory and
+// returns its path
+func writeTestFile(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestReadSeedFileCSVReportsErrorsPerLine(t *testing.T) {
+	path := writeTestFile(t, "states.csv", `name,code,frequency
+Texas,TX,4
+"Ohio"x,OH,1
+Utah,UTAH,2
+Iowa,IA,many
+Texas,TX,1
+Maine,ME,0
+`)
+	states, errs, err := ReadSeedFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var names []string
+	for _, state := range states {
+		names = append(names, state.Name)
+	}
+	if want := []string{"Texas", "Maine"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("states = %v, want %v", names, want)
+	}
+	var lines []int
+	for _, e := range errs {
+		lines = append(lines, e.Line)
+	}
+	if want := []int{3, 4, 5, 6}; !reflect.DeepEqual(lines, want) {
+		t.Errorf("error lines = %v, want %v (%v)", lines, want, errs)
+	}
+}
+
+func TestReadSeedFileJSON(t *testing.T) {
+	path := writeTestFile(t, "states.json", `[
+  {"name": "Texas", "code": "TX", "frequency": 4},
+  {"name": "Guam", "code": "GU", "kind": "TERRITORY", "enabled": false},
+  {"name": "", "code": "XX"}
+]`)
+	states, errs, err := ReadSeedFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(states) != 2 {
+		t.Fatalf("states = %v, want 2", states)
+	}
+	if states[0].Frequency != 4 || states[1].Enabled || states[1].Kind != KindTerritory {
+		t.Errorf("states = %+v, %+v", states[0], states[1])
+	}
+	if len(errs) != 1 || errs[0].Line != 4 {
+		t.Errorf("errors = %v, want one on line 4", errs)
+	}
+}
@@ -0,0 +1,97 @@
+package backend
+
+import (
+	"log"
+	"net/http"
+	"sync/atomic"
+)
+
+const (
+	// readyPath reports whether the instance serves traffic, for load balancer readiness checks
+	readyPath = "/readyz"
+	// promotePath promotes an instance in warm standby to active
+	promotePath = "/admin/promote"
+	// standbyRetryAfter is the Retry-After, in seconds, of requests rejected during standby
+	standbyRetryAfter = "5"
+)
+
+// active is 1 while the instance serves traffic and 0 while it is in warm standby, where the trie
+// is loaded and its caches warmed but searches are answered with 503 until an admin promotes it
+var active int32 = 1
+
+// isActive reports whether the instance serves traffic
+func isActive() bool {
+	return atomic.LoadInt32(&active) == 1
+}
+
+// setActive switches the instance between serving traffic and warm standby
+func setActive(serving bool) {
+	var value int32
+	if serving {
+		value = 1
+	}
+	atomic.StoreInt32(&active, value)
+}
+
+// withActive answers requests with 503 Service Unavailable while the instance is in warm standby
+func withActive(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !isActive() {
+			w.Header().Set("Retry-After", standbyRetryAfter)
+			writeJSON(w, http.StatusServiceUnavailable, map[string]string{"error": "instance is in standby"})
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// readiness is the body of /readyz: whether the instance is ready and how far startup got
+type readiness struct {
+	Status  string `json:"status"`
+	Missing int    `json:"missing,omitempty"`
+	StartupStatus
+}
+
+// readyHandler serves GET /readyz, which returns 200 once the states are loaded and the search
+// cache warmed and the instance is active, and 503 while it is starting, in warm standby or its
+// trie lacks more states than TRIE_VERIFY_MAX_MISSING. The body reports the startup phases.
+func readyHandler(w http.ResponseWriter, r *http.Request) {
+	status := readiness{Status: "starting", StartupStatus: startup.Status()}
+	if !startup.Ready() {
+		// the default tenant's store is still being set up by Load
+		writeJSON(w, http.StatusServiceUnavailable, status)
+		return
+	}
+	if !isActive() {
+		status.Status = "standby"
+		writeJSON(w, http.StatusServiceUnavailable, status)
+		return
+	}
+	if missing, incomplete := trieIncomplete(); incomplete {
+		status.Status = "incomplete"
+		status.Missing = missing
+		writeJSON(w, http.StatusServiceUnavailable, status)
+		return
+	}
+	status.Status = "active"
+	writeJSON(w, http.StatusOK, status)
+}
+
+// promoteHandler serves POST /admin/promote, which makes an instance in warm standby serve
+// traffic. Promoting an active instance does nothing.
+func promoteHandler(w http.ResponseWriter, r *http.Request) {
+	actor, err := requireAdmin(r.Context())
+	if err != nil {
+		writeJSON(w, http.StatusUnauthorized, map[string]string{"error": err.Error()})
+		return
+	}
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		writeJSON(w, http.StatusMethodNotAllowed, map[string]string{"error": "method not allowed"})
+		return
+	}
+	if atomic.CompareAndSwapInt32(&active, 0, 1) {
+		log.Printf("Promoted instance to active for %s", actor)
+	}
+	writeJSON(w, http.StatusOK, map[string]string{"status": "active"})
+}
@@ -0,0 +1,69 @@
+package backend
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPromoteFromStandby(t *testing.T) {
+	newTestStore(t, State{Name: "Texas", Code: "TX", Enabled: true, Kind: KindState})
+	previous := startup
+	t.Cleanup(func() {
+		startup = previous
+		setActive(true)
+	})
+	startup = NewStartupProgress(time.Now)
+	startup.Enter(PhaseReady)
+	setActive(false)
+
+	mux := http.NewServeMux()
+	routeAPI(mux)
+	mux.HandleFunc(readyPath, readyHandler)
+	serve := func(ctx context.Context, method, path string) *httptest.ResponseRecorder {
+		var request *http.Request
+		if path == config.GraphQLPath {
+			request = httptest.NewRequest(method, path, strings.NewReader(`{"query": "{ states(search: \"T\") { name } }"}`))
+			request.Header.Set("Content-Type", "application/json")
+		} else {
+			request = httptest.NewRequest(method, path, nil)
+		}
+		recorder := httptest.NewRecorder()
+		mux.ServeHTTP(recorder, request.WithContext(ctx))
+		return recorder
+	}
+	ctx := context.Background()
+
+	// the trie is loaded, but neither the load balancer nor clients are served
+	if response := serve(ctx, http.MethodGet, readyPath); response.Code != http.StatusServiceUnavailable || !strings.Contains(response.Body.String(), `"standby"`) {
+		t.Errorf("readiness in standby = %d %s, want 503 standby", response.Code, response.Body)
+	}
+	if response := serve(ctx, http.MethodPost, config.GraphQLPath); response.Code != http.StatusServiceUnavailable || response.Header().Get("Retry-After") == "" {
+		t.Errorf("search in standby = %d, Retry-After %q, want 503 with a Retry-After", response.Code, response.Header().Get("Retry-After"))
+	}
+
+	if response := serve(ctx, http.MethodPost, promotePath); response.Code != http.StatusUnauthorized {
+		t.Errorf("promoting without an admin = %d, want 401", response.Code)
+	}
+	if response := serve(asAdmin(ctx, "ops"), http.MethodGet, promotePath); response.Code != http.StatusMethodNotAllowed {
+		t.Errorf("promoting with GET = %d, want 405", response.Code)
+	}
+	if isActive() {
+		t.Fatal("instance was promoted by rejected requests")
+	}
+
+	for i := 0; i < 2; i++ {
+		if response := serve(asAdmin(ctx, "ops"), http.MethodPost, promotePath); response.Code != http.StatusOK {
+			t.Errorf("promoting as an admin = %d %s, want 200", response.Code, response.Body)
+		}
+	}
+	if response := serve(ctx, http.MethodGet, readyPath); response.Code != http.StatusOK || !strings.Contains(response.Body.String(), `"active"`) {
+		t.Errorf("readiness after the promotion = %d %s, want 200 active", response.Code, response.Body)
+	}
+	if response := serve(ctx, http.MethodPost, config.GraphQLPath); response.Code != http.StatusOK || !strings.Contains(response.Body.String(), `"Texas"`) {
+		t.Errorf("search after the promotion = %d %s, want Texas", response.Code, response.Body)
+	}
+}
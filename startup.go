@@ -0,0 +1,70 @@
+package backend
+
+import (
+	"encoding/json"
+	"log"
+)
+
+// listenAddr is the address the GraphQL API, REST lookups and metrics are served on
+const listenAddr = ":8082"
+
+// StartupLimits are the effective limits the server runs with; 0 means unlimited
+type StartupLimits struct {
+	MinPrefixLength  int    `json:"minPrefixLength"`
+	AdaptiveLimits   string `json:"adaptiveLimits"`
+	InputMaxLength   int    `json:"inputMaxLength"`
+	TrieMaxNodes     int    `json:"trieMaxNodes"`
+	MaxLoadedTenants int    `json:"maxLoadedTenants"`
+	QueryCacheTTL    string `json:"queryCacheTTL"`
+}
+
+// StartupSummary is the configuration and data the server started with, logged once loaded so
+// operators can check a deployment at a glance
+type StartupSummary struct {
+	Version      string        `json:"version"`
+	StatesLoaded int           `json:"statesLoaded"`
+	StatesPruned int           `json:"statesPruned"`
+	TrieNodes    int           `json:"trieNodes"`
+	ListenAddr   string        `json:"listenAddr"`
+	TLS          bool          `json:"tls"`
+	HTTPRedirect bool          `json:"httpRedirect"`
+	GraphiQL     bool          `json:"graphiql"`
+	GraphQLPath  string        `json:"graphqlPath"`
+	Standby      bool          `json:"standby"`
+	Limits       StartupLimits `json:"limits"`
+}
+
+// newStartupSummary summarizes the default tenant's trie and the configuration
+func newStartupSummary() StartupSummary {
+	return StartupSummary{
+		Version:      version,
+		StatesLoaded: len(store.States()),
+		StatesPruned: store.pruned.count(),
+		TrieNodes:    countNodes(store.Root()),
+		ListenAddr:   listenAddr,
+		TLS:          tlsEnabled(),
+		HTTPRedirect: tlsEnabled() && !config.DisableHTTPRedirect,
+		// GraphiQL is always served at /playground
+		GraphiQL:    true,
+		GraphQLPath: config.GraphQLPath,
+		Standby:     config.Standby,
+		Limits: StartupLimits{
+			MinPrefixLength:  config.MinPrefixLen,
+			AdaptiveLimits:   config.AdaptiveLimits,
+			InputMaxLength:   inputPolicy.MaxLength,
+			TrieMaxNodes:     config.TrieMaxNodes,
+			MaxLoadedTenants: config.MaxLoadedTenants,
+			QueryCacheTTL:    config.QueryCacheTTL.String(),
+		},
+	}
+}
+
+// logStartupSummary logs the startup summary as a single JSON line
+func logStartupSummary() {
+	summary, err := json.Marshal(newStartupSummary())
+	if err != nil {
+		log.Printf("Error encoding startup summary: %v", err)
+		return
+	}
+	log.Printf("Startup summary: %s", summary)
+}
@@ -0,0 +1,47 @@
+package backend
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"log"
+	"strings"
+	"testing"
+)
+
+func TestLogStartupSummary(t *testing.T) {
+	newTestStore(t,
+		State{Name: "Iowa", Code: "IA", Enabled: true, Kind: KindState, Frequency: 3},
+		State{Name: "Idaho", Code: "ID", Enabled: true, Kind: KindState, Frequency: 5},
+	)
+	previous := *config
+	t.Cleanup(func() { *config = previous })
+	config.TLSCertFile, config.TLSKeyFile, config.DisableHTTPRedirect = "cert.pem", "key.pem", false
+	config.MinPrefixLen, config.TrieMaxNodes = 2, 1000
+
+	var output bytes.Buffer
+	log.SetOutput(&output)
+	defer log.SetOutput(io.Discard)
+	logStartupSummary()
+
+	// the summary is a single line of JSON after its label
+	line := strings.TrimSuffix(output.String(), "\n")
+	index := strings.Index(line, "Startup summary: ")
+	if index < 0 || strings.Contains(line, "\n") {
+		t.Fatalf("startup summary logged as %q, want a single labelled line", output.String())
+	}
+	var summary StartupSummary
+	if err := json.Unmarshal([]byte(line[index+len("Startup summary: "):]), &summary); err != nil {
+		t.Fatal(err)
+	}
+	// the root, I, o-w-a and d-a-h-o
+	if summary.StatesLoaded != 2 || summary.TrieNodes != 9 || summary.StatesPruned != 0 {
+		t.Errorf("summary of the trie = %d states, %d nodes, %d pruned, want 2 states in 9 nodes", summary.StatesLoaded, summary.TrieNodes, summary.StatesPruned)
+	}
+	if summary.ListenAddr != listenAddr || !summary.TLS || !summary.HTTPRedirect || !summary.GraphiQL || summary.GraphQLPath != config.GraphQLPath {
+		t.Errorf("summary of the server = %+v, want TLS with the HTTP redirect on %s", summary, listenAddr)
+	}
+	if limits := summary.Limits; limits.MinPrefixLength != 2 || limits.TrieMaxNodes != 1000 || limits.InputMaxLength != inputPolicy.MaxLength {
+		t.Errorf("summary of the limits = %+v, want the configured ones", limits)
+	}
+}
@@ -0,0 +1,52 @@
+package backend
+
+import (
+	"github.com/graphql-go/graphql"
+)
+
+// statesByCodesField resolves a set of state codes to their states in one call, without updating
+// their frequency. The states are returned in the order of the codes, with null for codes no
+// enabled state has, so results line up with the codes.
+var statesByCodesField = &graphql.Field{
+	Type: graphql.NewList(stateType),
+	Args: graphql.FieldConfigArgument{
+		"codes": &graphql.ArgumentConfig{
+			Type:        graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(graphql.String))),
+			Description: "Codes matched exactly in any case; unknown codes resolve to null",
+		},
+	},
+	Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+		tenantStore, err := storeFor(p.Context)
+		if err != nil {
+			return nil, err
+		}
+		index := tenantStore.CodeIndex()
+		states := []*State{}
+		for _, code := range stringListArg(p.Args["codes"]) {
+			states = append(states, stateByCode(index, code))
+		}
+		return states, nil
+	},
+}
+
+// stateByCodeField looks up the enabled state with a code in constant time, without searching or
+// updating its frequency
+var stateByCodeField = &graphql.Field{
+	Type: stateType,
+	Args: graphql.FieldConfigArgument{
+		"code": &graphql.ArgumentConfig{
+			Type:        graphql.NewNonNull(graphql.String),
+			Description: "Code matched exactly in any case",
+		},
+	},
+	Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+		tenantStore, err := storeFor(p.Context)
+		if err != nil {
+			return nil, err
+		}
+		if state := stateByCode(tenantStore.CodeIndex(), p.Args["code"].(string)); state != nil {
+			return state, nil
+		}
+		return nil, nil
+	},
+}
@@ -0,0 +1,96 @@
+package backend
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log"
+	"testing"
+	"time"
+
+	"github.com/graphql-go/graphql"
+)
+
+// newTestStore loads the states into the default tenant's store
+func newTestStore(t *testing.T, states ...State) *TrieStore {
+	t.Helper()
+	log.SetOutput(io.Discard)
+	store = NewTrieStore(NewInMemoryStateRepository(states...))
+	if err := store.RebuildTrie(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	tenants = NewTenantRegistry(store, nil, time.Hour, 10, nil, time.Now)
+	// searches through the resolvers record analytics events
+	analytics = NewAnalytics(AnalyticsModeOff, 0, "", nil, time.Now)
+	return store
+}
+
+// resolveCodeQuery runs the query against the schema and returns its data as JSON
+func resolveCodeQuery(t *testing.T, query string) string {
+	t.Helper()
+	schema, err := NewSchema()
+	if err != nil {
+		t.Fatal(err)
+	}
+	result := graphql.Do(graphql.Params{Schema: schema, RequestString: query, Context: context.Background()})
+	if len(result.Errors) > 0 {
+		t.Fatalf("query %s failed: %v", query, result.Errors)
+	}
+	data, err := json.Marshal(result.Data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func TestStateByCodeAfterCodeChange(t *testing.T) {
+	s := newTestStore(t,
+		State{Name: "Texas", Code: "TX", Enabled: true, Kind: KindState},
+		State{Name: "Utah", Code: "UT", Enabled: true, Kind: KindState},
+	)
+
+	updated := *findState(s.Root(), "Texas")
+	updated.Code = "TZ"
+	s.writeMu.Lock()
+	applyStateChanges(context.Background(), s, stateChanges{Updated: []*State{&updated}})
+	s.writeMu.Unlock()
+
+	tests := []struct {
+		query string
+		want  string
+	}{
+		{`{ stateByCode(code: "tz") { name code } }`, `{"stateByCode":{"code":"TZ","name":"Texas"}}`},
+		{`{ stateByCode(code: "TX") { name } }`, `{"stateByCode":null}`},
+		{`{ statesByCodes(codes: ["TX", "TZ", "UT"]) { name } }`, `{"statesByCodes":[null,{"name":"Texas"},{"name":"Utah"}]}`},
+	}
+	for _, test := range tests {
+		if got := resolveCodeQuery(t, test.query); got != test.want {
+			t.Errorf("%s = %s, want %s", test.query, got, test.want)
+		}
+	}
+}
+
+func TestStatesByCodes(t *testing.T) {
+	s := newTestStore(t,
+		State{Name: "Texas", Code: "TX", Enabled: true, Kind: KindState},
+		State{Name: "Utah", Code: "UT", Enabled: true, Kind: KindState},
+		State{Name: "Idaho", Code: "ID", Enabled: false, Kind: KindState},
+	)
+	tests := []struct {
+		query string
+		want  string
+	}{
+		// states come back in the order of the codes, with null for unknown, partial and disabled codes
+		{`{ statesByCodes(codes: ["UT", "ZZ", "tx", "T", "ID"]) { name } }`, `{"statesByCodes":[{"name":"Utah"},null,{"name":"Texas"},null,null]}`},
+		{`{ statesByCodes(codes: ["TX", "TX"]) { name } }`, `{"statesByCodes":[{"name":"Texas"},{"name":"Texas"}]}`},
+		{`{ statesByCodes(codes: []) { name } }`, `{"statesByCodes":[]}`},
+	}
+	for _, test := range tests {
+		if got := resolveCodeQuery(t, test.query); got != test.want {
+			t.Errorf("%s = %s, want %s", test.query, got, test.want)
+		}
+	}
+	if frequency := findState(s.Root(), "Texas").loadFrequency(); frequency != 0 {
+		t.Errorf("frequency of Texas after resolving its code = %d, want 0", frequency)
+	}
+}
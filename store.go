@@ -0,0 +1,216 @@
+package backend
+
+import (
+	"context"
+	"log"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/graphql-go/graphql"
+)
+
+// TrieStore holds the trie currently serving searches of one tenant along with its token-sorted,
+// localized, case-folded, code and word indexes, a flat list of its states, its search cache, and
+// the state repository it is loaded from with the frequency store its selections are persisted
+// in. Rebuilds happen on a fresh trie without holding the lock, which is only taken to swap the
+// root pointers. Incremental updates and frequency increments hold writeMu so they are applied
+// one at a time. Searches read the trie without locking: states are replaced rather than changed,
+// and frequencies are incremented and read atomically.
+type TrieStore struct {
+	mu          sync.RWMutex
+	writeMu     sync.Mutex
+	repo        StateRepository
+	frequencies FrequencyStore
+	loadedAt    time.Time
+	root        *TrieNode
+	tokens      *TrieNode
+	folded      *TrieNode
+	codes       *TrieNode
+	byCode      map[string][]*State
+	locales     map[string]*TrieNode
+	words       map[string][]*State
+	states      []*State
+	cache       *SearchCache
+	pruned      prunedStates
+	// missing is the number of states the last verified trie lacks, accessed atomically
+	missing int64
+}
+
+// store is the trie store of the default tenant
+var store = NewTrieStore(nil)
+
+// newTrieRoot returns an empty trie root
+func newTrieRoot() *TrieNode {
+	return &TrieNode{
+		Children: make(map[rune]*TrieNode),
+	}
+}
+
+// NewTrieStore creates a store holding an empty trie loaded from the given repository
+func NewTrieStore(repo StateRepository) *TrieStore {
+	return &TrieStore{
+		repo:        repo,
+		frequencies: repositoryFrequencyStore(repo),
+		loadedAt:    time.Now(),
+		root:        newTrieRoot(),
+		tokens:      newTrieRoot(),
+		folded:      newTrieRoot(),
+		codes:       newTrieRoot(),
+		locales:     map[string]*TrieNode{},
+		cache:       NewSearchCache(searchCacheSize, searchCacheTTL, time.Now),
+	}
+}
+
+// Repository returns the state repository the trie is loaded from
+func (s *TrieStore) Repository() StateRepository {
+	return s.repo
+}
+
+// Frequencies returns the store frequency increments are persisted in
+func (s *TrieStore) Frequencies() FrequencyStore {
+	return s.frequencies
+}
+
+// Root returns the root of the trie currently serving searches
+func (s *TrieStore) Root() *TrieNode {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.root
+}
+
+// Tokens returns the trie keyed by token-sorted state names
+func (s *TrieStore) Tokens() *TrieNode {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.tokens
+}
+
+// Age returns the time since the trie was last swapped in
+func (s *TrieStore) Age() time.Duration {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return time.Since(s.loadedAt)
+}
+
+// Folded returns the trie keyed by lowercased state names
+func (s *TrieStore) Folded() *TrieNode {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.folded
+}
+
+// Codes returns the trie keyed by uppercased state codes
+func (s *TrieStore) Codes() *TrieNode {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.codes
+}
+
+// CodeIndex returns the states by uppercased code
+func (s *TrieStore) CodeIndex() map[string][]*State {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.byCode
+}
+
+// Cache returns the cache of searches of the trie
+func (s *TrieStore) Cache() *SearchCache {
+	return s.cache
+}
+
+// Words returns the states indexed by each word of their names
+func (s *TrieStore) Words() map[string][]*State {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.words
+}
+
+// States returns every state of the trie sorted by name. The list is shared, so callers must copy
+// it before filtering or sorting it.
+func (s *TrieStore) States() []*State {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.states
+}
+
+// Locale returns the trie keyed by the state names localized in the locale, or nil when no
+// state has a translation in it
+func (s *TrieStore) Locale(locale string) *TrieNode {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.locales[locale]
+}
+
+// Swap replaces the trie serving searches, rebuilds its indexes and warms its search cache again.
+// Searches already holding the old root finish on it.
+func (s *TrieStore) Swap(root *TrieNode) {
+	tokens := buildTokenTrie(root)
+	folded := buildFoldedTrie(root)
+	codes := buildCodeTrie(root)
+	locales := buildLocaleTries(root)
+	words := buildWordIndex(root)
+	var states []*State
+	collectStates(context.Background(), root, &states)
+	sort.Slice(states, func(i, j int) bool {
+		return states[i].Name < states[j].Name
+	})
+	byCode := buildCodeIndex(states)
+	s.mu.Lock()
+	s.loadedAt = time.Now()
+	s.root = root
+	s.tokens = tokens
+	s.folded = folded
+	s.codes = codes
+	s.byCode = byCode
+	s.locales = locales
+	s.words = words
+	s.states = states
+	s.mu.Unlock()
+	s.cache.Reset()
+	warmSearchCache(s)
+}
+
+// Reset replaces the trie with an empty one
+func (s *TrieStore) Reset() {
+	s.Swap(newTrieRoot())
+}
+
+// RebuildTrie loads the states of the repository into a new trie and swaps it in once complete,
+// pruning it to TRIE_MAX_NODES nodes first. The current trie keeps serving searches while the new
+// one is built. The states are read and verified through the read replica unless ctx asks for
+// primary reads.
+func (s *TrieStore) RebuildTrie(ctx context.Context) error {
+	start := time.Now()
+	root := newTrieRoot()
+	if err := loadStatesIntoTrie(ctx, root, s.repo, s.frequencies); err != nil {
+		return err
+	}
+	loaded := countLeaves(root)
+	pruned := pruneTrie(root, config.TrieMaxNodes)
+	s.pruned.reset(pruned)
+	s.Swap(root)
+	log.Printf("Rebuilt trie in %s", time.Since(start))
+	recordTrieVerification(ctx, s, root, loaded)
+	return nil
+}
+
+// reloadStatesField rebuilds the tenant's trie from MongoDB and returns the number of loaded states
+var reloadStatesField = &graphql.Field{
+	Type: graphql.Int,
+	Resolve: audited("reloadStates", stateCountSnapshot, func(p graphql.ResolveParams) (interface{}, error) {
+		actor, err := requireAdmin(p.Context)
+		if err != nil {
+			return nil, err
+		}
+		tenantStore, err := storeFor(p.Context)
+		if err != nil {
+			return nil, err
+		}
+		if err := tenantStore.RebuildTrie(p.Context); err != nil {
+			log.Printf("Error reloading states for %s: %v", actor, err)
+			return nil, err
+		}
+		return countLeaves(tenantStore.Root()), nil
+	}),
+}
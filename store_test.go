@@ -0,0 +1,146 @@
+package backend
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+)
+
+// TestTrieConcurrentInsertSearch inserts, deletes and changes states for a few seconds while
+// searches select and encode them. Run it with -race: states are replaced rather than changed and
+// frequencies are atomic, so nothing a search reads may be written in place.
+func TestTrieConcurrentInsertSearch(t *testing.T) {
+	const (
+		inserters = 10
+		searchers = 10
+		deleters  = 5
+		updaters  = 5
+	)
+	duration := 5 * time.Second
+	if testing.Short() {
+		duration = time.Second
+	}
+	var states []State
+	for i := 0; i < 20; i++ {
+		states = append(states, State{Name: fmt.Sprintf("State %02d", i), Code: fmt.Sprintf("S%d", i), Enabled: true, Kind: KindState})
+	}
+	// searches run against the default tenant's store
+	s := newTestStore(t, states...)
+	ctx := context.Background()
+	deadline := time.Now().Add(duration)
+
+	var mu sync.Mutex
+	var inserted []string
+	deleted := make(map[string]bool)
+	toDelete := make(chan string, 1000)
+
+	var wg sync.WaitGroup
+	for w := 0; w < inserters; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := 0; time.Now().Before(deadline); i++ {
+				name := fmt.Sprintf("Inserted %d-%d", w, i)
+				s.writeMu.Lock()
+				applyStateChanges(ctx, s, stateChanges{Inserted: []*State{{Name: name, Code: fmt.Sprintf("I%d", w), Enabled: true, Kind: KindState}}})
+				s.writeMu.Unlock()
+				mu.Lock()
+				inserted = append(inserted, name)
+				mu.Unlock()
+				// every other insert is offered for deletion
+				if i%2 == 0 {
+					select {
+					case toDelete <- name:
+					default:
+					}
+				}
+			}
+		}(w)
+	}
+	for w := 0; w < deleters; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for time.Now().Before(deadline) {
+				var name string
+				select {
+				case name = <-toDelete:
+				case <-time.After(10 * time.Millisecond):
+					continue
+				}
+				s.writeMu.Lock()
+				if state := findState(s.Root(), name); state != nil {
+					applyStateChanges(ctx, s, stateChanges{Deleted: []*State{state}})
+				}
+				s.writeMu.Unlock()
+				mu.Lock()
+				deleted[name] = true
+				mu.Unlock()
+			}
+		}()
+	}
+	for w := 0; w < updaters; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := 0; time.Now().Before(deadline); i++ {
+				s.writeMu.Lock()
+				if state := findState(s.Root(), states[(w+i)%len(states)].Name); state != nil {
+					updated := copyState(state)
+					updated.Code = fmt.Sprintf("U%d", i%10)
+					updated.Enabled = i%2 == 0
+					updated.Frequency += 10
+					applyStateChanges(ctx, s, stateChanges{Updated: []*State{updated}})
+				}
+				s.writeMu.Unlock()
+			}
+		}(w)
+	}
+	for w := 0; w < searchers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := 0; time.Now().Before(deadline); i++ {
+				search := []string{"state", "inserted", fmt.Sprintf("inserted %d", w)}[i%3]
+				results, err := searchAndUpdateFrequency(ctx, s, search, searchOptions{IgnoreCase: true}, searchFields{Name: true, Code: true}, 5)
+				if err != nil {
+					t.Error(err)
+					return
+				}
+				if _, err := json.Marshal(results); err != nil {
+					t.Error(err)
+					return
+				}
+				for _, state := range s.CodeIndex()["U1"] {
+					_ = state.Enabled && state.loadFrequency() > 0
+				}
+			}
+		}(w)
+	}
+	wg.Wait()
+
+	root := s.Root()
+	if len(inserted) == 0 || len(deleted) == 0 {
+		t.Fatalf("inserted %d and deleted %d states, want both", len(inserted), len(deleted))
+	}
+	for _, name := range inserted {
+		found := false
+		for _, state := range SearchStates(root, name) {
+			found = found || state.Name == name
+		}
+		if deleted[name] && found {
+			t.Errorf("deleted state %q is still searchable", name)
+		}
+		if !deleted[name] && !found {
+			t.Errorf("inserted state %q is not searchable", name)
+		}
+	}
+	for _, state := range states {
+		if findState(root, state.Name) == nil {
+			t.Errorf("state %q is missing from the trie", state.Name)
+		}
+	}
+}
@@ -0,0 +1,198 @@
+package backend
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/graphql-go/graphql"
+)
+
+const (
+	// matchedOnName marks a suggestion whose English name starts with the search
+	matchedOnName = "NAME"
+	// matchedOnCode marks a suggestion whose code starts with the search
+	matchedOnCode = "CODE"
+	// matchedOnLocalizedName marks a suggestion whose name in another locale starts with the search
+	matchedOnLocalizedName = "LOCALIZED_NAME"
+)
+
+// matchedOnRank orders the fields a state can match on, so a state matching several reports the
+// first one
+var matchedOnRank = map[string]int{matchedOnName: 0, matchedOnCode: 1, matchedOnLocalizedName: 2}
+
+// SuggestMatch is a state suggested for a search with the field and text it matched on
+type SuggestMatch struct {
+	State       *State `json:"state"`
+	MatchedOn   string `json:"matchedOn"`
+	MatchedText string `json:"matchedText"`
+	// Exact is set when the matched text is the whole search rather than a prefix of it
+	Exact bool `json:"-"`
+}
+
+// betterMatch reports whether a describes why its state matched better than b: exact matches beat
+// prefix matches, then names beat codes and codes beat localized names
+func betterMatch(a, b SuggestMatch) bool {
+	if a.Exact != b.Exact {
+		return a.Exact
+	}
+	return matchedOnRank[a.MatchedOn] < matchedOnRank[b.MatchedOn]
+}
+
+// rankMatches sorts the matches exact first, then most frequently selected first, breaking ties by
+// matched field and name, so a code typed in full ranks above names it is only a prefix of
+func rankMatches(matches []SuggestMatch) {
+	sort.Slice(matches, func(i, j int) bool {
+		a, b := matches[i], matches[j]
+		if a.Exact != b.Exact {
+			return a.Exact
+		}
+		if first, second := a.State.loadFrequency(), b.State.loadFrequency(); first != second {
+			return first > second
+		}
+		if a.MatchedOn != b.MatchedOn {
+			return matchedOnRank[a.MatchedOn] < matchedOnRank[b.MatchedOn]
+		}
+		return a.State.Name < b.State.Name
+	})
+}
+
+// suggestStates searches the names, codes and translated names of the store's enabled states
+// passing the filters, ignoring case, and returns each matched state once with the best field it
+// matched on, ranked by rankMatches. Translated names are matched by scanning the states, and not
+// at all for wildcard searches.
+func suggestStates(ctx context.Context, s *TrieStore, search string, filters ...stateFilter) []SuggestMatch {
+	nameKey, code := foldKey(search), codeKey(search)
+	best := make(map[*State]SuggestMatch)
+	add := func(match SuggestMatch) {
+		if current, ok := best[match.State]; !ok || betterMatch(match, current) {
+			best[match.State] = match
+		}
+	}
+
+	for _, state := range searchStates(ctx, s.Folded(), nameKey, filters...) {
+		add(SuggestMatch{State: state, MatchedOn: matchedOnName, MatchedText: state.Name, Exact: foldKey(state.Name) == nameKey})
+	}
+	if code != "" {
+		for _, state := range searchStates(ctx, s.Codes(), code, filters...) {
+			add(SuggestMatch{State: state, MatchedOn: matchedOnCode, MatchedText: state.Code, Exact: codeKey(state.Code) == code})
+		}
+	}
+	if !hasWildcard(search) {
+		filters = append([]stateFilter{isEnabled}, filters...)
+		for _, state := range s.States() {
+			for _, translation := range state.Translations {
+				key := foldKey(translation)
+				if strings.HasPrefix(key, nameKey) && acceptState(state, filters) {
+					add(SuggestMatch{State: state, MatchedOn: matchedOnLocalizedName, MatchedText: translation, Exact: key == nameKey})
+				}
+			}
+		}
+	}
+
+	matches := make([]SuggestMatch, 0, len(best))
+	for _, match := range best {
+		matches = append(matches, match)
+	}
+	rankMatches(matches)
+	return matches
+}
+
+// suggestAndUpdateFrequency suggests states for the search, keeps the first limit unless limit is
+// 0, and updates their frequency in the store like the states query does
+func suggestAndUpdateFrequency(ctx context.Context, s *TrieStore, search string, limit int, filters ...stateFilter) []SuggestMatch {
+	matches := suggestStates(ctx, s, search, filters...)
+	if limit > 0 && len(matches) > limit {
+		matches = matches[:limit]
+	}
+	for _, match := range matches {
+		updateFrequency(ctx, s, match.State.Name)
+	}
+	return matches
+}
+
+// Define the GraphQL matched on enum
+var matchedOnEnum = graphql.NewEnum(graphql.EnumConfig{
+	Name: "MatchedOn",
+	Values: graphql.EnumValueConfigMap{
+		matchedOnName: &graphql.EnumValueConfig{
+			Value:       matchedOnName,
+			Description: "The English name of the state",
+		},
+		matchedOnCode: &graphql.EnumValueConfig{
+			Value:       matchedOnCode,
+			Description: "The code of the state",
+		},
+		matchedOnLocalizedName: &graphql.EnumValueConfig{
+			Value:       matchedOnLocalizedName,
+			Description: "A translation of the name of the state",
+		},
+	},
+})
+
+// Define the GraphQL suggest match type
+var suggestMatchType = graphql.NewObject(graphql.ObjectConfig{
+	Name: "SuggestMatch",
+	Fields: graphql.Fields{
+		"state": &graphql.Field{
+			Type: stateType,
+		},
+		"matchedOn": &graphql.Field{
+			Type: matchedOnEnum,
+		},
+		"matchedText": &graphql.Field{
+			Type:        graphql.String,
+			Description: "The value of the matched field, e.g. TX for a search matching the code of Texas",
+		},
+	},
+})
+
+// suggestField searches every indexed field of the tenant's states at once, so clients do not have
+// to tell codes from names, returning each state once with what it matched on
+var suggestField = &graphql.Field{
+	Type: graphql.NewList(suggestMatchType),
+	Args: graphql.FieldConfigArgument{
+		"q": &graphql.ArgumentConfig{
+			Type: graphql.NewNonNull(graphql.String),
+		},
+		"limit": &graphql.ArgumentConfig{
+			Type:        graphql.Int,
+			Description: "Maximum number of suggestions returned; overrides ADAPTIVE_LIMITS",
+		},
+		"exclude": &graphql.ArgumentConfig{
+			Type:        graphql.NewList(graphql.NewNonNull(graphql.String)),
+			Description: "Names or codes of states never to suggest, e.g. ones already selected; they do not count toward the limit",
+		},
+	},
+	Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+		search := p.Args["q"].(string)
+		if err := checkPrefixLength(search, config.MinPrefixLen); err != nil {
+			return nil, err
+		}
+		if !checkSearchInput(p.Context, search) {
+			return []SuggestMatch{}, nil
+		}
+		limit, err := resultLimit(p.Args["limit"], search)
+		if err != nil {
+			return nil, err
+		}
+		tenantStore, err := storeFor(p.Context)
+		if err != nil {
+			return nil, err
+		}
+		var filters []stateFilter
+		if exclude := stringListArg(p.Args["exclude"]); len(exclude) > 0 {
+			filters = append(filters, excludeStates(exclude))
+		}
+		logf(p.Context, "Suggesting for: %s", search)
+		start := time.Now()
+		if err := restorePruned(p.Context, tenantStore, search); err != nil {
+			logf(p.Context, "Error reloading pruned states for %s: %v", search, err)
+		}
+		matches := suggestAndUpdateFrequency(p.Context, tenantStore, search, limit, filters...)
+		recordSearch(p.Context, search, len(matches), time.Since(start))
+		analytics.Record(p.Context, search, len(matches))
+		return matches, nil
+	},
+}
@@ -0,0 +1,63 @@
+package backend
+
+import "testing"
+
+func TestSuggest(t *testing.T) {
+	newTestStore(t,
+		State{Name: "Colorado", Code: "CO", Enabled: true, Kind: KindState, Frequency: 1},
+		State{Name: "Coahuila", Code: "CU", Enabled: true, Kind: KindProvince, Frequency: 300},
+		State{Name: "Connecticut", Code: "CT", Enabled: true, Kind: KindState, Frequency: 200},
+		State{Name: "Texas", Code: "TX", Enabled: true, Kind: KindState, Frequency: 100, Translations: map[string]string{"es": "Tejas"}},
+		State{Name: "Tennessee", Code: "TN", Enabled: true, Kind: KindState, Frequency: 50},
+	)
+	tests := []struct {
+		query string
+		want  string
+	}{
+		// Colorado matches its code exactly and its name by prefix, and is returned once, ahead of
+		// more frequently selected names CO is only a prefix of
+		{`{ suggest(q: "CO") { matchedOn matchedText state { name } } }`, `{"suggest":[` +
+			`{"matchedOn":"CODE","matchedText":"CO","state":{"name":"Colorado"}},` +
+			`{"matchedOn":"NAME","matchedText":"Coahuila","state":{"name":"Coahuila"}},` +
+			`{"matchedOn":"NAME","matchedText":"Connecticut","state":{"name":"Connecticut"}}]}`},
+		// Texas matches its name and its Spanish name, and reports its name
+		{`{ suggest(q: "te") { matchedOn matchedText state { name } } }`, `{"suggest":[` +
+			`{"matchedOn":"NAME","matchedText":"Texas","state":{"name":"Texas"}},` +
+			`{"matchedOn":"NAME","matchedText":"Tennessee","state":{"name":"Tennessee"}}]}`},
+		{`{ suggest(q: "Tej") { matchedOn matchedText state { name } } }`, `{"suggest":[` +
+			`{"matchedOn":"LOCALIZED_NAME","matchedText":"Tejas","state":{"name":"Texas"}}]}`},
+		{`{ suggest(q: "t") { matchedOn matchedText state { name } } }`, `{"suggest":[` +
+			`{"matchedOn":"NAME","matchedText":"Texas","state":{"name":"Texas"}},` +
+			`{"matchedOn":"NAME","matchedText":"Tennessee","state":{"name":"Tennessee"}}]}`},
+		{`{ suggest(q: "C", limit: 1) { matchedOn matchedText state { name } } }`, `{"suggest":[` +
+			`{"matchedOn":"NAME","matchedText":"Coahuila","state":{"name":"Coahuila"}}]}`},
+		{`{ suggest(q: "C", exclude: ["CU", "Connecticut"]) { state { name } } }`, `{"suggest":[{"state":{"name":"Colorado"}}]}`},
+		{`{ suggest(q: "Zz") { state { name } } }`, `{"suggest":[]}`},
+	}
+	for _, test := range tests {
+		if got := resolveCodeQuery(t, test.query); got != test.want {
+			t.Errorf("%s = %s, want %s", test.query, got, test.want)
+		}
+	}
+}
+
+func TestBetterMatch(t *testing.T) {
+	name := SuggestMatch{MatchedOn: matchedOnName}
+	code := SuggestMatch{MatchedOn: matchedOnCode}
+	localized := SuggestMatch{MatchedOn: matchedOnLocalizedName}
+	exactCode := SuggestMatch{MatchedOn: matchedOnCode, Exact: true}
+	for _, test := range []struct {
+		a, b SuggestMatch
+		want bool
+	}{
+		{name, code, true},
+		{code, localized, true},
+		{localized, name, false},
+		{exactCode, name, true},
+		{name, exactCode, false},
+	} {
+		if got := betterMatch(test.a, test.b); got != test.want {
+			t.Errorf("betterMatch(%+v, %+v) = %t, want %t", test.a, test.b, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,62 @@
+package backend
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"strconv"
+)
+
+// suggestionCountHeader reports how many suggestions the states field returned
+const suggestionCountHeader = "X-Search-Suggestion-Count"
+
+// bufferedResponseWriter holds back the status and body so headers can still be set after the handler ran
+type bufferedResponseWriter struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+// WriteHeader records the status code instead of sending it
+func (w *bufferedResponseWriter) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+	}
+}
+
+// Write buffers the body instead of sending it
+func (w *bufferedResponseWriter) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	return w.body.Write(b)
+}
+
+// countSuggestions returns the length of the data.states array in a GraphQL response body
+func countSuggestions(body []byte) (int, bool) {
+	var response struct {
+		Data struct {
+			States []json.RawMessage `json:"states"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(body, &response); err != nil || response.Data.States == nil {
+		return 0, false
+	}
+	return len(response.Data.States), true
+}
+
+// withSuggestionCount sets the X-Search-Suggestion-Count header from the serialized GraphQL response
+func withSuggestionCount(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		buffered := &bufferedResponseWriter{ResponseWriter: w}
+		next.ServeHTTP(buffered, r)
+
+		if count, ok := countSuggestions(buffered.body.Bytes()); ok {
+			w.Header().Set(suggestionCountHeader, strconv.Itoa(count))
+		}
+		if buffered.status != 0 {
+			w.WriteHeader(buffered.status)
+		}
+		w.Write(buffered.body.Bytes())
+	})
+}
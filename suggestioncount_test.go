@@ -0,0 +1,69 @@
+package backend
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/graphql-go/handler"
+)
+
+func TestSuggestionCountHeader(t *testing.T) {
+	withUnreachableMongo(t)
+	newTestStore(t,
+		State{Name: "Texas", Code: "TX", Enabled: true, Kind: KindState},
+		State{Name: "Tennessee", Code: "TN", Enabled: true, Kind: KindState},
+		State{Name: "Utah", Code: "UT", Enabled: true, Kind: KindState},
+	)
+	schema, err := NewSchema()
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := httptest.NewServer(withSuggestionCount(handler.New(&handler.Config{Schema: &schema, Pretty: true})))
+	defer server.Close()
+
+	for _, test := range []struct {
+		query string
+		want  string
+	}{
+		{`{ states(search: "T") { name } }`, "2"},
+		{`{ states(search: "Utah") { name } }`, "1"},
+		{`{ states(search: "Zz") { name } }`, "0"},
+		// responses without a states field carry no count
+		{`{ stateByCode(code: "TX") { name } }`, ""},
+		{`{ states(`, ""},
+	} {
+		body, _ := json.Marshal(map[string]string{"query": test.query})
+		response, err := http.Post(server.URL, "application/json", bytes.NewReader(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		response.Body.Close()
+		if got := response.Header.Get(suggestionCountHeader); got != test.want {
+			t.Errorf("%s for %s = %q, want %q", suggestionCountHeader, test.query, got, test.want)
+		}
+		if response.StatusCode != http.StatusOK && test.want != "" {
+			t.Errorf("%s answered %d", test.query, response.StatusCode)
+		}
+	}
+}
+
+func TestCountSuggestions(t *testing.T) {
+	for _, test := range []struct {
+		body  string
+		count int
+		ok    bool
+	}{
+		{`{"data":{"states":[{"name":"Texas"},{"name":"Utah"}]}}`, 2, true},
+		{`{"data":{"states":[]}}`, 0, true},
+		{`{"data":{"states":null}}`, 0, false},
+		{`{"data":{"stateByCode":{"name":"Texas"}}}`, 0, false},
+		{`not json`, 0, false},
+	} {
+		if count, ok := countSuggestions([]byte(test.body)); count != test.count || ok != test.ok {
+			t.Errorf("countSuggestions(%s) = %d, %t, want %d, %t", test.body, count, ok, test.count, test.ok)
+		}
+	}
+}
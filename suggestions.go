@@ -0,0 +1,125 @@
+package backend
+
+import (
+	"sort"
+	"unicode/utf8"
+
+	"github.com/graphql-go/graphql"
+)
+
+const (
+	// maxSuggestions caps the did-you-mean suggestions returned for a search
+	maxSuggestions = 3
+	// minSuggestionLength is the number of characters a search needs before names are suggested,
+	// since shorter searches are close to too many names
+	minSuggestionLength = 4
+	// longSuggestionLength is the number of characters from which two edits are tolerated
+	longSuggestionLength = 6
+)
+
+// Suggestion is a state name close to a search that matched nothing
+type Suggestion struct {
+	Name     string `json:"name"`
+	Distance int    `json:"distance"`
+}
+
+// prefixEditDistance returns the fewest single character insertions, deletions and substitutions
+// turning the search into a prefix of the name, or max+1 once it exceeds max
+func prefixEditDistance(search, name []rune, max int) int {
+	previous := make([]int, len(name)+1)
+	current := make([]int, len(name)+1)
+	for i := 1; i <= len(search); i++ {
+		current[0] = i
+		lowest := current[0]
+		for j := 1; j <= len(name); j++ {
+			cost := 1
+			if search[i-1] == name[j-1] {
+				cost = 0
+			}
+			current[j] = previous[j-1] + cost
+			if previous[j]+1 < current[j] {
+				current[j] = previous[j] + 1
+			}
+			if current[j-1]+1 < current[j] {
+				current[j] = current[j-1] + 1
+			}
+			if current[j] < lowest {
+				lowest = current[j]
+			}
+		}
+		if lowest > max {
+			return max + 1
+		}
+		previous, current = current, previous
+	}
+	distance := previous[0]
+	for _, d := range previous {
+		if d < distance {
+			distance = d
+		}
+	}
+	return distance
+}
+
+// suggestNames returns up to maxSuggestions names of the enabled states, localized in the locale,
+// that the search is a few edits away from starting, closest and most frequently selected first.
+// Case is ignored. Searches shorter than minSuggestionLength get no suggestions.
+func suggestNames(states []*State, search, locale string) []Suggestion {
+	if utf8.RuneCountInString(search) < minSuggestionLength || hasWildcard(search) {
+		return []Suggestion{}
+	}
+	maxDistance := 1
+	if utf8.RuneCountInString(search) >= longSuggestionLength {
+		maxDistance = 2
+	}
+
+	type candidate struct {
+		state    *State
+		name     string
+		distance int
+	}
+	key := []rune(foldKey(search))
+	var candidates []candidate
+	for _, state := range states {
+		if !state.Enabled {
+			continue
+		}
+		name := state.LocalizedName(locale)
+		if distance := prefixEditDistance(key, []rune(foldKey(name)), maxDistance); distance <= maxDistance {
+			candidates = append(candidates, candidate{state: state, name: name, distance: distance})
+		}
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		if candidates[i].distance != candidates[j].distance {
+			return candidates[i].distance < candidates[j].distance
+		}
+		first, second := candidates[i].state.loadFrequency(), candidates[j].state.loadFrequency()
+		if first != second {
+			return first > second
+		}
+		return candidates[i].name < candidates[j].name
+	})
+
+	suggestions := []Suggestion{}
+	for _, c := range candidates {
+		if len(suggestions) == maxSuggestions {
+			break
+		}
+		suggestions = append(suggestions, Suggestion{Name: c.name, Distance: c.distance})
+	}
+	return suggestions
+}
+
+// Define the GraphQL suggestion type
+var suggestionType = graphql.NewObject(graphql.ObjectConfig{
+	Name: "Suggestion",
+	Fields: graphql.Fields{
+		"name": &graphql.Field{
+			Type: graphql.String,
+		},
+		"distance": &graphql.Field{
+			Type:        graphql.Int,
+			Description: "Characters to insert, delete or replace in the search for the name to start with it",
+		},
+	},
+})
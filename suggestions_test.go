@@ -0,0 +1,66 @@
+package backend
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSuggestNames(t *testing.T) {
+	s := newTestStore(t,
+		State{Name: "Tennessee", Code: "TN", Enabled: true, Kind: KindState, Frequency: 5},
+		State{Name: "Texas", Code: "TX", Enabled: true, Kind: KindState, Frequency: 9},
+		State{Name: "California", Code: "CA", Enabled: true, Kind: KindState},
+		State{Name: "Colorado", Code: "CO", Enabled: false, Kind: KindState},
+		State{Name: "Mexico", Code: "MX", Enabled: true, Kind: KindState, Translations: map[string]string{"es": "México"}},
+	)
+	tests := []struct {
+		search string
+		locale string
+		want   []Suggestion
+	}{
+		{"Tenessee", "", []Suggestion{{"Tennessee", 1}}},
+		{"calfornia", "", []Suggestion{{"California", 1}}},
+		// the search only has to be close to the start of the name
+		{"Texsa", "", []Suggestion{{"Texas", 1}}},
+		// two edits are only tolerated in longer searches
+		{"Tenesee", "", []Suggestion{{"Tennessee", 2}}},
+		{"Txeas", "", []Suggestion{}},
+		{"Mejico", "es", []Suggestion{{"México", 2}}},
+		// disabled states are not suggested
+		{"Colorad", "", []Suggestion{}},
+		// short and gibberish searches get nothing
+		{"Tnx", "", []Suggestion{}},
+		{"Qwzxvbn", "", []Suggestion{}},
+	}
+	for _, test := range tests {
+		if got := suggestNames(s.States(), test.search, test.locale); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("suggestions for %q in %q = %v, want %v", test.search, test.locale, got, test.want)
+		}
+	}
+}
+
+func TestSearchSuggestions(t *testing.T) {
+	newTestStore(t,
+		State{Name: "Tennessee", Code: "TN", Enabled: true, Kind: KindState},
+		State{Name: "Texas", Code: "TX", Enabled: true, Kind: KindState},
+	)
+	tests := []struct {
+		query string
+		want  string
+	}{
+		{`{ search(search: "Tenessee") { items { name } suggestions { name distance } } }`,
+			`{"search":{"items":[],"suggestions":[{"distance":1,"name":"Tennessee"}]}}`},
+		// searches matching states get no suggestions
+		{`{ search(search: "Tex") { items { name } suggestions { name distance } } }`,
+			`{"search":{"items":[{"name":"Texas"}],"suggestions":[]}}`},
+		{`{ search(search: "Tx") { items { name } suggestions { name distance } } }`,
+			`{"search":{"items":[],"suggestions":[]}}`},
+		{`{ search(search: "Zzzzzz") { items { name } suggestions { name distance } } }`,
+			`{"search":{"items":[],"suggestions":[]}}`},
+	}
+	for _, test := range tests {
+		if got := resolveCodeQuery(t, test.query); got != test.want {
+			t.Errorf("%s = %s, want %s", test.query, got, test.want)
+		}
+	}
+}
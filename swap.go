@@ -0,0 +1,82 @@
+package backend
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/graphql-go/graphql"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// swapFrequencies exchanges the frequencies of the two named states of the store in its frequency
+// store and trie, returning both states. It holds s.writeMu throughout, so no increment lands
+// between reading the frequencies and writing them back.
+func swapFrequencies(ctx context.Context, s *TrieStore, a, b string) ([]*State, error) {
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+	root := s.Root()
+	first, second := findState(root, a), findState(root, b)
+	if first == nil {
+		return nil, fmt.Errorf("state %q not found", a)
+	}
+	if second == nil {
+		return nil, fmt.Errorf("state %q not found", b)
+	}
+	if first == second {
+		return nil, invalidInput("cannot swap the frequency of state %q with itself", first.Name)
+	}
+
+	firstFrequency, secondFrequency := first.loadFrequency(), second.loadFrequency()
+	delta := secondFrequency - firstFrequency
+	if delta != 0 {
+		deltas := map[string]int{first.Name: int(delta), second.Name: int(-delta)}
+		if err := s.Frequencies().BulkIncrement(ctx, deltas); err != nil {
+			return nil, err
+		}
+		swappedFirst, swappedSecond := copyState(first), copyState(second)
+		swappedFirst.Frequency, swappedSecond.Frequency = secondFrequency, firstFrequency
+		applyStateChanges(ctx, s, stateChanges{Updated: []*State{swappedFirst, swappedSecond}})
+	}
+	logf(ctx, "Swapped frequencies of states %s and %s, now %d and %d", first.Name, second.Name, secondFrequency, firstFrequency)
+	return []*State{first, second}, nil
+}
+
+// swapFrequenciesSnapshot captures the frequencies of the two states for the audit log
+func swapFrequenciesSnapshot(p graphql.ResolveParams) bson.M {
+	tenantStore, err := storeFor(p.Context)
+	if err != nil {
+		return nil
+	}
+	snapshot := bson.M{}
+	for _, arg := range []string{"a", "b"} {
+		name, _ := p.Args[arg].(string)
+		if state := findState(tenantStore.Root(), name); state != nil {
+			snapshot[state.Name] = state.loadFrequency()
+		}
+	}
+	return snapshot
+}
+
+// swapFrequenciesField re-ranks two states by hand in a single call, returning both with their new
+// frequencies
+var swapFrequenciesField = &graphql.Field{
+	Type: graphql.NewList(stateType),
+	Args: graphql.FieldConfigArgument{
+		"a": &graphql.ArgumentConfig{
+			Type: graphql.NewNonNull(graphql.String),
+		},
+		"b": &graphql.ArgumentConfig{
+			Type: graphql.NewNonNull(graphql.String),
+		},
+	},
+	Resolve: audited("swapFrequencies", swapFrequenciesSnapshot, func(p graphql.ResolveParams) (interface{}, error) {
+		if _, err := requireAdmin(p.Context); err != nil {
+			return nil, err
+		}
+		tenantStore, err := storeFor(p.Context)
+		if err != nil {
+			return nil, err
+		}
+		return swapFrequencies(p.Context, tenantStore, p.Args["a"].(string), p.Args["b"].(string))
+	}),
+}
@@ -0,0 +1,60 @@
+package backend
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSwapFrequencies(t *testing.T) {
+	withUnreachableMongo(t)
+	s := newTestStore(t,
+		State{Name: "Texas", Code: "TX", Enabled: true, Kind: KindState, Frequency: 40},
+		State{Name: "Tennessee", Code: "TN", Enabled: true, Kind: KindState, Frequency: 3},
+	)
+	ctx := asAdmin(context.Background(), "ops")
+
+	result := runGraphQL(t, ctx, `mutation { swapFrequencies(a: "Texas", b: "Tennessee") { name frequency } }`)
+	if len(result.Errors) > 0 {
+		t.Fatal(result.Errors)
+	}
+	want := []interface{}{
+		map[string]interface{}{"name": "Texas", "frequency": 3},
+		map[string]interface{}{"name": "Tennessee", "frequency": 40},
+	}
+	if got := result.Data.(map[string]interface{})["swapFrequencies"]; !reflect.DeepEqual(got, want) {
+		t.Errorf("swapFrequencies = %v, want %v", got, want)
+	}
+	for name, frequency := range map[string]int64{"Texas": 3, "Tennessee": 40} {
+		if state := findState(s.Root(), name); state.loadFrequency() != frequency {
+			t.Errorf("frequency of %s in the trie = %d, want %d", name, state.loadFrequency(), frequency)
+		}
+		if state, _ := s.Repository().FindByName(context.Background(), name); state.Frequency != frequency {
+			t.Errorf("frequency of %s in the repository = %d, want %d", name, state.Frequency, frequency)
+		}
+	}
+	if names := searchNames(s.Root(), "T"); !reflect.DeepEqual(names, []string{"Tennessee", "Texas"}) {
+		t.Errorf("searching T after the swap = %v, want Tennessee first", names)
+	}
+
+	for _, test := range []struct {
+		mutation string
+		message  string
+	}{
+		{`swapFrequencies(a: "Texas", b: "Atlantis")`, `"Atlantis" not found`},
+		{`swapFrequencies(a: "Atlantis", b: "Texas")`, `"Atlantis" not found`},
+		{`swapFrequencies(a: "Texas", b: "Texas")`, "with itself"},
+	} {
+		result := runGraphQL(t, ctx, `mutation { `+test.mutation+` { name } }`)
+		if len(result.Errors) != 1 || !strings.Contains(result.Errors[0].Message, test.message) {
+			t.Errorf("%s = %v, want an error containing %q", test.mutation, result.Errors, test.message)
+		}
+	}
+	if result := runGraphQL(t, context.Background(), `mutation { swapFrequencies(a: "Texas", b: "Tennessee") { name } }`); len(result.Errors) != 1 {
+		t.Errorf("swapFrequencies without an admin = %v, want an error", result.Errors)
+	}
+	if frequency := findState(s.Root(), "Texas").loadFrequency(); frequency != 3 {
+		t.Errorf("frequency of Texas after rejected swaps = %d, want 3", frequency)
+	}
+}
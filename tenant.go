@@ -0,0 +1,240 @@
+package backend
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const (
+	// tenantHeader selects the tenant whose states a request works on
+	tenantHeader = "X-Tenant-Id"
+	// defaultTenantID is used for requests without a tenant header
+	defaultTenantID = "default"
+	// tenantEvictionInterval is how often idle tenants are evicted
+	tenantEvictionInterval = time.Minute
+	// tenantLoadWait is how long a request waits for its tenant's trie to load
+	tenantLoadWait = 2 * time.Second
+)
+
+var (
+	// errUnknownTenant is returned for tenant IDs that are not configured
+	errUnknownTenant = errors.New("unknown tenant")
+	// errTenantWarming is returned while a tenant's trie is still loading
+	errTenantWarming = errors.New("tenant is warming up, retry shortly")
+)
+
+type tenantContextKey struct{}
+
+// tenant is the lazily loaded trie store of one tenant. ready is closed once loading finished,
+// after which err holds the load error, if any.
+type tenant struct {
+	store    *TrieStore
+	ready    chan struct{}
+	err      error
+	lastUsed time.Time
+}
+
+// TenantRegistry holds a trie store per tenant. The first request for a tenant starts loading its
+// trie in the background; tries idle for too long or beyond the maximum number of loaded tenants
+// are evicted, least recently used first. The default tenant is always loaded and never evicted.
+type TenantRegistry struct {
+	mu            sync.Mutex
+	now           func() time.Time
+	allowed       map[string]bool
+	idleTimeout   time.Duration
+	maxLoaded     int
+	loadWait      time.Duration
+	newRepository func(tenantID string) StateRepository
+	tenants       map[string]*tenant
+}
+
+var tenants *TenantRegistry
+
+// tenantCollectionName returns the MongoDB collection holding the states of the tenant
+func tenantCollectionName(tenantID string) string {
+	if tenantID == defaultTenantID {
+		return "states"
+	}
+	return "states_" + tenantID
+}
+
+// NewTenantRegistry creates a registry serving the default tenant from defaultStore and the
+// allowed tenants from the repositories created by newRepository, keeping at most maxLoaded other
+// tenants in memory
+func NewTenantRegistry(defaultStore *TrieStore, allowed map[string]bool, idleTimeout time.Duration, maxLoaded int,
+	newRepository func(tenantID string) StateRepository, now func() time.Time) *TenantRegistry {
+	defaultTenant := &tenant{store: defaultStore, ready: make(chan struct{})}
+	close(defaultTenant.ready)
+	return &TenantRegistry{
+		now:           now,
+		allowed:       allowed,
+		idleTimeout:   idleTimeout,
+		maxLoaded:     maxLoaded,
+		loadWait:      tenantLoadWait,
+		newRepository: newRepository,
+		tenants:       map[string]*tenant{defaultTenantID: defaultTenant},
+	}
+}
+
+// Allowed reports whether the tenant may be served
+func (r *TenantRegistry) Allowed(tenantID string) bool {
+	return tenantID == defaultTenantID || r.allowed[tenantID]
+}
+
+// Store returns the trie store of the tenant. A tenant that is not loaded starts loading, and the
+// request waits briefly for it before failing with errTenantWarming.
+func (r *TenantRegistry) Store(ctx context.Context, tenantID string) (*TrieStore, error) {
+	if !r.Allowed(tenantID) {
+		return nil, errUnknownTenant
+	}
+
+	r.mu.Lock()
+	t, ok := r.tenants[tenantID]
+	if !ok {
+		t = &tenant{store: newTenantTrieStore(tenantID, r.newRepository(tenantID)), ready: make(chan struct{})}
+		r.tenants[tenantID] = t
+		go r.load(tenantID, t)
+	}
+	t.lastUsed = r.now()
+	if !ok {
+		r.evictOverCapacity()
+	}
+	loadedTenants.Set(float64(len(r.tenants)))
+	r.mu.Unlock()
+
+	select {
+	case <-t.ready:
+		return t.store, t.err
+	case <-time.After(r.loadWait):
+		return nil, errTenantWarming
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// load loads the tenant's trie, dropping the tenant again when loading fails so the next request retries
+func (r *TenantRegistry) load(tenantID string, t *tenant) {
+	t.err = t.store.RebuildTrie(context.Background())
+	close(t.ready)
+	if t.err == nil {
+		log.Printf("Loaded states for tenant %s", tenantID)
+		return
+	}
+
+	log.Printf("Error loading states for tenant %s: %v", tenantID, t.err)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.tenants[tenantID] == t {
+		delete(r.tenants, tenantID)
+		loadedTenants.Set(float64(len(r.tenants)))
+	}
+}
+
+// evictOverCapacity evicts the least recently used tenants while more than maxLoaded tenants
+// besides the default one are loaded. The caller must hold r.mu.
+func (r *TenantRegistry) evictOverCapacity() {
+	for r.maxLoaded > 0 && len(r.tenants)-1 > r.maxLoaded {
+		oldestID := ""
+		var oldest *tenant
+		for tenantID, t := range r.tenants {
+			if tenantID != defaultTenantID && (oldest == nil || t.lastUsed.Before(oldest.lastUsed)) {
+				oldestID, oldest = tenantID, t
+			}
+		}
+		delete(r.tenants, oldestID)
+		tenantEvictionsTotal.WithLabelValues("capacity").Inc()
+		log.Printf("Evicted tenant %s over capacity", oldestID)
+	}
+}
+
+// Loaded returns the trie stores of the tenants that finished loading, by tenant ID
+func (r *TenantRegistry) Loaded() map[string]*TrieStore {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	loaded := make(map[string]*TrieStore, len(r.tenants))
+	for tenantID, t := range r.tenants {
+		select {
+		case <-t.ready:
+			if t.err == nil {
+				loaded[tenantID] = t.store
+			}
+		default:
+		}
+	}
+	return loaded
+}
+
+// LoadedStore returns the trie store of the tenant if it finished loading, without starting a load
+// or counting as a use
+func (r *TenantRegistry) LoadedStore(tenantID string) (*TrieStore, bool) {
+	r.mu.Lock()
+	t, ok := r.tenants[tenantID]
+	r.mu.Unlock()
+	if !ok {
+		return nil, false
+	}
+	select {
+	case <-t.ready:
+		return t.store, t.err == nil
+	default:
+		return nil, false
+	}
+}
+
+// EvictIdle drops the tries of tenants unused for longer than the idle timeout
+func (r *TenantRegistry) EvictIdle() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	cutoff := r.now().Add(-r.idleTimeout)
+	for tenantID, t := range r.tenants {
+		if tenantID != defaultTenantID && t.lastUsed.Before(cutoff) {
+			delete(r.tenants, tenantID)
+			tenantEvictionsTotal.WithLabelValues("idle").Inc()
+			log.Printf("Evicted idle tenant %s", tenantID)
+		}
+	}
+	loadedTenants.Set(float64(len(r.tenants)))
+}
+
+// startTenantEvictor periodically evicts idle tenants
+func startTenantEvictor() {
+	ticker := time.NewTicker(tenantEvictionInterval)
+	go func() {
+		for range ticker.C {
+			tenants.EvictIdle()
+		}
+	}()
+}
+
+// withTenant attaches the tenant of the X-Tenant-Id header to the request context, rejecting
+// tenants that are not configured. Requests without the header use the default tenant.
+func withTenant(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		tenantID := r.Header.Get(tenantHeader)
+		if tenantID == "" {
+			tenantID = defaultTenantID
+		}
+		if !tenants.Allowed(tenantID) {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": errUnknownTenant.Error()})
+			return
+		}
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), tenantContextKey{}, tenantID)))
+	})
+}
+
+// tenantFromContext returns the tenant attached to the context, or the default tenant
+func tenantFromContext(ctx context.Context) string {
+	if tenantID, ok := ctx.Value(tenantContextKey{}).(string); ok {
+		return tenantID
+	}
+	return defaultTenantID
+}
+
+// storeFor returns the trie store of the tenant attached to the context
+func storeFor(ctx context.Context) (*TrieStore, error) {
+	return tenants.Store(ctx, tenantFromContext(ctx))
+}
@@ -0,0 +1,245 @@
+package backend
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// newTestTenants serves the default tenant from a store of the given states and the acme tenant
+// from acmeRepo
+func newTestTenants(t *testing.T, acmeRepo StateRepository, states ...State) *TrieStore {
+	t.Helper()
+	s := newTestStore(t, states...)
+	tenants = NewTenantRegistry(s, map[string]bool{"acme": true}, time.Hour, 10, func(string) StateRepository {
+		return acmeRepo
+	}, time.Now)
+	return s
+}
+
+// tenantSearch runs a states query for the tenant and returns the names found
+func tenantSearch(t *testing.T, tenantID, search string) []string {
+	t.Helper()
+	ctx := context.WithValue(context.Background(), tenantContextKey{}, tenantID)
+	result := runGraphQL(t, ctx, `{ states(search: "`+search+`") { name } }`)
+	if len(result.Errors) > 0 {
+		t.Fatalf("searching %s for tenant %s failed: %v", search, tenantID, result.Errors)
+	}
+	var names []string
+	for _, state := range result.Data.(map[string]interface{})["states"].([]interface{}) {
+		names = append(names, state.(map[string]interface{})["name"].(string))
+	}
+	return names
+}
+
+func TestTenantIsolation(t *testing.T) {
+	withUnreachableMongo(t)
+	acmeRepo := NewInMemoryStateRepository(
+		State{Name: "Puerto Rico", Code: "PR", Enabled: true, Kind: KindTerritory, Frequency: 7},
+		State{Name: "Texas", Code: "TX", Enabled: true, Kind: KindState, Frequency: 100},
+	)
+	defaultStore := newTestTenants(t, acmeRepo,
+		State{Name: "Texas", Code: "TX", Enabled: true, Kind: KindState, Frequency: 1},
+		State{Name: "Tennessee", Code: "TN", Enabled: true, Kind: KindState},
+	)
+
+	for _, test := range []struct {
+		tenantID string
+		search   string
+		want     []string
+	}{
+		{defaultTenantID, "T", []string{"Texas", "Tennessee"}},
+		{"acme", "T", []string{"Texas"}},
+		{"acme", "P", []string{"Puerto Rico"}},
+		{defaultTenantID, "P", nil},
+		{"acme", "Tenn", nil},
+	} {
+		if names := tenantSearch(t, test.tenantID, test.search); !reflect.DeepEqual(names, test.want) {
+			t.Errorf("searching %s for tenant %s = %v, want %v", test.search, test.tenantID, names, test.want)
+		}
+	}
+
+	// each tenant counts its own selections of Texas
+	acmeStore, ok := tenants.LoadedStore("acme")
+	if !ok {
+		t.Fatal("acme is not loaded after searching it")
+	}
+	if frequency := findState(defaultStore.Root(), "Texas").loadFrequency(); frequency != 2 {
+		t.Errorf("Texas of the default tenant has a frequency of %d, want 2", frequency)
+	}
+	if frequency := findState(acmeStore.Root(), "Texas").loadFrequency(); frequency != 101 {
+		t.Errorf("Texas of acme has a frequency of %d, want 101", frequency)
+	}
+	if state, _ := acmeRepo.FindByName(context.Background(), "Texas"); state.Frequency != 101 {
+		t.Errorf("Texas of acme has a persisted frequency of %d, want 101", state.Frequency)
+	}
+	if state, _ := defaultStore.Repository().FindByName(context.Background(), "Texas"); state.Frequency != 2 {
+		t.Errorf("Texas of the default tenant has a persisted frequency of %d, want 2", state.Frequency)
+	}
+}
+
+func TestWithTenant(t *testing.T) {
+	newTestTenants(t, NewInMemoryStateRepository())
+	for header, want := range map[string]int{"": http.StatusOK, "acme": http.StatusOK, "globex": http.StatusBadRequest} {
+		var tenantID string
+		h := withTenant(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			tenantID = tenantFromContext(r.Context())
+		}))
+		r := httptest.NewRequest(http.MethodPost, "/graphql", nil)
+		r.Header.Set(tenantHeader, header)
+		recorder := httptest.NewRecorder()
+		h.ServeHTTP(recorder, r)
+		if recorder.Code != want {
+			t.Errorf("request for tenant %q answered %d, want %d", header, recorder.Code, want)
+		}
+		if wantID := map[string]string{"": defaultTenantID, "acme": "acme"}[header]; tenantID != wantID {
+			t.Errorf("request for tenant %q served tenant %q, want %q", header, tenantID, wantID)
+		}
+	}
+}
+
+func TestTenantEviction(t *testing.T) {
+	clock := &testClock{now: time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC)}
+	s := newTestStore(t)
+	registry := NewTenantRegistry(s, map[string]bool{"acme": true, "globex": true, "initech": true}, time.Hour, 2,
+		func(string) StateRepository { return NewInMemoryStateRepository() }, clock.Now)
+	ctx := context.Background()
+	use := func(tenantID string) {
+		if _, err := registry.Store(ctx, tenantID); err != nil {
+			t.Fatal(err)
+		}
+		clock.now = clock.now.Add(time.Minute)
+	}
+	loaded := func() []string {
+		var ids []string
+		for _, tenantID := range []string{defaultTenantID, "acme", "globex", "initech"} {
+			if _, ok := registry.LoadedStore(tenantID); ok {
+				ids = append(ids, tenantID)
+			}
+		}
+		return ids
+	}
+
+	use("acme")
+	use("globex")
+	use("acme")
+	// the least recently used tenant makes room beyond the maximum
+	use("initech")
+	if ids := loaded(); !reflect.DeepEqual(ids, []string{defaultTenantID, "acme", "initech"}) {
+		t.Errorf("loaded tenants over capacity = %v, want default, acme and initech", ids)
+	}
+
+	clock.now = clock.now.Add(time.Hour - time.Minute)
+	registry.EvictIdle()
+	if ids := loaded(); !reflect.DeepEqual(ids, []string{defaultTenantID, "initech"}) {
+		t.Errorf("loaded tenants after acme went idle = %v, want default and initech", ids)
+	}
+	clock.now = clock.now.Add(time.Hour)
+	registry.EvictIdle()
+	if ids := loaded(); !reflect.DeepEqual(ids, []string{defaultTenantID}) {
+		t.Errorf("loaded tenants after all went idle = %v, want only the default tenant", ids)
+	}
+	if _, err := registry.Store(ctx, "umbrella"); err != errUnknownTenant {
+		t.Errorf("store of an unconfigured tenant: %v, want %v", err, errUnknownTenant)
+	}
+}
+
+// blockingRepository counts the loads of its states, each waiting until release is closed
+type blockingRepository struct {
+	*InMemoryStateRepository
+	release chan struct{}
+	loads   int32
+}
+
+// FindAll counts the load and returns the states once released
+func (r *blockingRepository) FindAll(ctx context.Context) ([]*State, error) {
+	atomic.AddInt32(&r.loads, 1)
+	<-r.release
+	return r.InMemoryStateRepository.FindAll(ctx)
+}
+
+func TestConcurrentFirstRequestsLoadTenantOnce(t *testing.T) {
+	repo := &blockingRepository{
+		InMemoryStateRepository: NewInMemoryStateRepository(State{Name: "Puerto Rico", Code: "PR", Enabled: true, Kind: KindTerritory}),
+		release:                 make(chan struct{}),
+	}
+	registry := NewTenantRegistry(newTestStore(t), map[string]bool{"acme": true}, time.Hour, 10,
+		func(string) StateRepository { return repo }, time.Now)
+	registry.loadWait = 50 * time.Millisecond
+
+	// requests while the trie loads are told to retry
+	var wg sync.WaitGroup
+	errs := make(chan error, 20)
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := registry.Store(context.Background(), "acme")
+			errs <- err
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != errTenantWarming {
+			t.Errorf("request during the load: %v, want %v", err, errTenantWarming)
+		}
+	}
+	if _, ok := registry.LoadedStore("acme"); ok {
+		t.Error("acme is loaded before its states were read")
+	}
+
+	close(repo.release)
+	s, err := registry.Store(context.Background(), "acme")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if findState(s.Root(), "Puerto Rico") == nil {
+		t.Error("acme's trie is missing Puerto Rico")
+	}
+	if loads := atomic.LoadInt32(&repo.loads); loads != 1 {
+		t.Errorf("acme's states were loaded %d times, want once", loads)
+	}
+}
+
+func TestTenantReloadsAfterEviction(t *testing.T) {
+	clock := &testClock{now: time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC)}
+	repo := &blockingRepository{InMemoryStateRepository: NewInMemoryStateRepository(), release: make(chan struct{})}
+	close(repo.release)
+	registry := NewTenantRegistry(newTestStore(t), map[string]bool{"acme": true}, time.Hour, 10,
+		func(string) StateRepository { return repo }, clock.Now)
+	const evictions = `tenant_evictions_total{reason="idle"}`
+	evictionsBefore := scrapeMetric(t, evictions)
+
+	first, err := registry.Store(context.Background(), "acme")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loaded := scrapeMetric(t, "tenants_loaded"); loaded != 2 {
+		t.Errorf("tenants_loaded = %v with acme loaded, want 2", loaded)
+	}
+	clock.now = clock.now.Add(2 * time.Hour)
+	registry.EvictIdle()
+	if _, ok := registry.LoadedStore("acme"); ok {
+		t.Error("acme is still loaded after going idle")
+	}
+	if loaded := scrapeMetric(t, "tenants_loaded"); loaded != 1 {
+		t.Errorf("tenants_loaded = %v after evicting acme, want 1", loaded)
+	}
+	if got := scrapeMetric(t, evictions) - evictionsBefore; got != 1 {
+		t.Errorf("idle evictions went up by %v, want 1", got)
+	}
+
+	second, err := registry.Store(context.Background(), "acme")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loads := atomic.LoadInt32(&repo.loads); second == first || loads != 2 {
+		t.Errorf("acme was not reloaded after its eviction, loaded %d times", loads)
+	}
+}
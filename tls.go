@@ -0,0 +1,55 @@
+package backend
+
+import (
+	"log"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// redirectReadHeaderTimeout bounds how long the HTTP redirect server waits for request headers
+const redirectReadHeaderTimeout = 10 * time.Second
+
+// tlsEnabled reports whether the server is configured to serve HTTPS
+func tlsEnabled() bool {
+	return config.TLSCertFile != "" && config.TLSKeyFile != ""
+}
+
+// redirectHost returns the host of the Host header without its port
+func redirectHost(hostport string) string {
+	host, _, err := net.SplitHostPort(hostport)
+	if err != nil {
+		host = hostport
+	}
+	if strings.Contains(host, ":") && !strings.HasPrefix(host, "[") {
+		// IPv6 addresses keep their brackets in URLs
+		host = "[" + host + "]"
+	}
+	return host
+}
+
+// httpsRedirectHandler permanently redirects every request to the same host and URI over HTTPS
+func httpsRedirectHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		target := "https://" + redirectHost(r.Host) + r.RequestURI
+		http.RedirectHandler(target, http.StatusMovedPermanently).ServeHTTP(w, r)
+	})
+}
+
+// startHTTPSRedirect serves the redirect to HTTPS on the HTTP port, so plaintext requests are never
+// answered with data
+func startHTTPSRedirect(port int) {
+	server := &http.Server{
+		Addr:              ":" + strconv.Itoa(port),
+		Handler:           httpsRedirectHandler(),
+		ReadHeaderTimeout: redirectReadHeaderTimeout,
+	}
+	go func() {
+		log.Printf("Redirecting HTTP on port %d to HTTPS", port)
+		if err := server.ListenAndServe(); err != nil {
+			log.Printf("Error serving the HTTPS redirect on port %d: %v", port, err)
+		}
+	}()
+}
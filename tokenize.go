@@ -0,0 +1,59 @@
+package backend
+
+import (
+	"context"
+	"sort"
+	"strings"
+)
+
+// nameTokens returns the lowercased whitespace-separated tokens of the text
+func nameTokens(text string) []string {
+	return strings.Fields(collationKey(strings.ToLower(text)))
+}
+
+// tokenKey returns the lowercased whitespace-separated tokens of the text sorted alphabetically
+// and joined by a single space, so "York New" and "new york" share the key "new york"
+func tokenKey(text string) string {
+	tokens := nameTokens(text)
+	sort.Strings(tokens)
+	return strings.Join(tokens, " ")
+}
+
+// buildTokenTrie builds a trie keyed by the token-sorted form of every state name under root.
+// Its nodes point at the same states, so results carry the original name.
+func buildTokenTrie(root *TrieNode) *TrieNode {
+	tokens := newTrieRoot()
+	var states []*State
+	collectStates(context.Background(), root, &states)
+	for _, state := range states {
+		insertKey(tokens, tokenKey(state.Name), state)
+	}
+	return tokens
+}
+
+// searchOptions select the index a search runs against
+type searchOptions struct {
+	Tokenize   bool
+	IgnoreCase bool
+	Locale     string
+	// WordFallback matches the start of any word of the names when the search matches no name
+	WordFallback bool
+}
+
+// searchRoot returns the trie of the store and key a search for the prefix runs against. Tokenized
+// searches match English names in any case; otherwise names localized in the locale are matched when
+// one is given, then English names ignoring case when requested.
+func searchRoot(s *TrieStore, prefix string, opts searchOptions) (*TrieNode, string) {
+	if opts.Tokenize {
+		return s.Tokens(), tokenKey(prefix)
+	}
+	if opts.Locale != "" {
+		if root := s.Locale(opts.Locale); root != nil {
+			return root, collationKey(prefix)
+		}
+	}
+	if opts.IgnoreCase {
+		return s.Folded(), foldKey(prefix)
+	}
+	return s.Root(), collationKey(prefix)
+}
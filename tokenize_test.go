@@ -0,0 +1,55 @@
+package backend
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+func TestTokenizedSearchMatchesWordsInAnyOrder(t *testing.T) {
+	withUnreachableMongo(t)
+	newTestStore(t,
+		State{Name: "New York", Code: "NY", Enabled: true, Kind: KindState, Frequency: 2},
+		State{Name: "New Mexico", Code: "NM", Enabled: true, Kind: KindState, Frequency: 1},
+		State{Name: "Texas", Code: "TX", Enabled: true, Kind: KindState},
+	)
+	tests := []struct {
+		search string
+		want   []string
+	}{
+		{"new york", []string{"New York"}},
+		{"york new", []string{"New York"}},
+		{"  York   NEW ", []string{"New York"}},
+		{"mexico new", []string{"New Mexico"}},
+		// keys start with the alphabetically first word, so "mexico new" for New Mexico
+		{"mexico", []string{"New Mexico"}},
+		{"york texas", nil},
+	}
+	for _, test := range tests {
+		result := runGraphQL(t, context.Background(), `{ states(search: "`+test.search+`", tokenize: true) { name } }`)
+		if len(result.Errors) > 0 {
+			t.Fatal(result.Errors)
+		}
+		var names []string
+		for _, state := range result.Data.(map[string]interface{})["states"].([]interface{}) {
+			names = append(names, state.(map[string]interface{})["name"].(string))
+		}
+		if !reflect.DeepEqual(names, test.want) {
+			t.Errorf("tokenized search for %q = %v, want %v", test.search, names, test.want)
+		}
+	}
+}
+
+func TestTokenKey(t *testing.T) {
+	for text, want := range map[string]string{
+		"New York":          "new york",
+		"York New":          "new york",
+		"  york \t new ":    "new york",
+		"District Columbia": "columbia district",
+		"":                  "",
+	} {
+		if got := tokenKey(text); got != want {
+			t.Errorf("tokenKey(%q) = %q, want %q", text, got, want)
+		}
+	}
+}
@@ -0,0 +1,45 @@
+package backend
+
+import (
+	"context"
+	"log"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+// tracerName names the tracer of the backend's own spans
+const tracerName = "state-suggestion-backend"
+
+// tracer creates the backend's spans. It is a no-op until initTracing installs a provider.
+var tracer = otel.Tracer(tracerName)
+
+// tracingConfigured reports whether an OTLP endpoint is set through the standard environment variables
+func tracingConfigured() bool {
+	if getEnvBool("OTEL_SDK_DISABLED", false) {
+		return false
+	}
+	return getEnv("OTEL_EXPORTER_OTLP_ENDPOINT", "") != "" || getEnv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT", "") != ""
+}
+
+// initTracing installs a tracer provider exporting spans over OTLP when an endpoint is configured.
+// The exporter and resource read the standard OTEL_* environment variables.
+func initTracing(ctx context.Context) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+	if !tracingConfigured() {
+		return
+	}
+	exporter, err := otlptracehttp.New(ctx)
+	if err != nil {
+		log.Printf("Error creating OTLP trace exporter: %v", err)
+		return
+	}
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(resource.Default()),
+	))
+	log.Printf("Exporting traces over OTLP")
+}
@@ -0,0 +1,64 @@
+package backend
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/graphql-go/handler"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestSearchSpans(t *testing.T) {
+	withUnreachableMongo(t)
+	newTestStore(t,
+		State{Name: "Texas", Code: "TX", Enabled: true, Kind: KindState},
+		State{Name: "Tennessee", Code: "TN", Enabled: true, Kind: KindState},
+	)
+	exporter := tracetest.NewInMemoryExporter()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	previous := tracer
+	defer func() { tracer = previous }()
+	tracer = provider.Tracer(tracerName)
+
+	schema, err := NewSchema()
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := otelhttp.NewHandler(handler.New(&handler.Config{Schema: &schema}), "/graphql", otelhttp.WithTracerProvider(provider))
+	body, _ := json.Marshal(map[string]string{"query": `{ states(search: "Te") { name } }`})
+	r := httptest.NewRequest(http.MethodPost, "/graphql", bytes.NewReader(body))
+	r.Header.Set("Content-Type", "application/json")
+	h.ServeHTTP(httptest.NewRecorder(), r)
+
+	spans := make(map[string][]tracetest.SpanStub)
+	var names []string
+	for _, span := range exporter.GetSpans() {
+		spans[span.Name] = append(spans[span.Name], span)
+		names = append(names, span.Name)
+	}
+	if len(spans["/graphql"]) != 1 || len(spans["search"]) != 1 || len(spans["frequencies.increment"]) != 2 {
+		t.Fatalf("got spans %v, want one /graphql, one search and two frequencies.increment spans", names)
+	}
+	server, search := spans["/graphql"][0], spans["search"][0]
+	if search.Parent.SpanID() != server.SpanContext.SpanID() {
+		t.Errorf("search span is not a child of the HTTP server span")
+	}
+	for _, increment := range spans["frequencies.increment"] {
+		if increment.Parent.SpanID() != search.SpanContext.SpanID() {
+			t.Errorf("frequencies.increment span is not a child of the search span")
+		}
+	}
+	attributes := make(map[attribute.Key]attribute.Value)
+	for _, kv := range search.Attributes {
+		attributes[kv.Key] = kv.Value
+	}
+	if attributes["search.prefix"].AsString() != "Te" || attributes["search.results"].AsInt64() != 2 {
+		t.Errorf("search span attributes = %v, want the prefix Te and 2 results", search.Attributes)
+	}
+}
@@ -0,0 +1,152 @@
+package backend
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/graphql-go/graphql"
+	"golang.org/x/text/language"
+)
+
+// translationLocale validates the locale tag and returns the language translations are stored under,
+// so "es-MX" becomes "es"
+func translationLocale(locale string) (string, error) {
+	if _, err := language.Parse(strings.TrimSpace(locale)); err != nil {
+		return "", invalidInput("locale %q is not a valid language tag", locale)
+	}
+	locale = normalizeLocale(locale)
+	if locale == defaultLocale {
+		return "", invalidInput("state names are already in %q", defaultLocale)
+	}
+	return locale, nil
+}
+
+// updateTranslations applies the translations to the named state of the store's trie, swapping in a
+// trie whose localized indexes include them
+func updateTranslations(ctx context.Context, s *TrieStore, name string, translations map[string]string) {
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+	state := findState(s.Root(), name)
+	if state == nil {
+		return
+	}
+	updated := copyState(state)
+	updated.Translations = translations
+	applyStateChanges(ctx, s, stateChanges{Updated: []*State{updated}})
+}
+
+// copyTranslations returns a copy of the translations that can be changed safely
+func copyTranslations(translations map[string]string) map[string]string {
+	copied := make(map[string]string, len(translations)+1)
+	for locale, translation := range translations {
+		copied[locale] = translation
+	}
+	return copied
+}
+
+// addTranslation sets the name of the tenant's state in the locale. Translations matching the name
+// of another state are rejected, since searches could not tell them apart.
+func addTranslation(ctx context.Context, name, locale, translation string) (*State, error) {
+	locale, err := translationLocale(locale)
+	if err != nil {
+		return nil, err
+	}
+	translation = nfc(strings.TrimSpace(translation))
+	if translation == "" {
+		return nil, invalidInput("translation must not be empty")
+	}
+	if err := inputPolicy.Validate(translation); err != nil {
+		return nil, err
+	}
+	tenantStore, err := storeFor(ctx)
+	if err != nil {
+		return nil, err
+	}
+	state := findState(tenantStore.Root(), name)
+	if state == nil {
+		return nil, fmt.Errorf("state %q not found", name)
+	}
+	if other := findState(tenantStore.Root(), translation); other != nil && other != state {
+		return nil, invalidInput("translation %q is the name of another state", translation)
+	}
+
+	if err := tenantStore.Repository().SetTranslation(ctx, state.Name, locale, translation); err != nil {
+		return nil, err
+	}
+	translations := copyTranslations(state.Translations)
+	translations[locale] = translation
+	updateTranslations(ctx, tenantStore, state.Name, translations)
+	log.Printf("Added translation for state: %s, Locale: %s, Translation: %s", state.Name, locale, translation)
+	return findState(tenantStore.Root(), state.Name), nil
+}
+
+// removeTranslation removes the name of the tenant's state in the locale
+func removeTranslation(ctx context.Context, name, locale string) (*State, error) {
+	locale, err := translationLocale(locale)
+	if err != nil {
+		return nil, err
+	}
+	tenantStore, err := storeFor(ctx)
+	if err != nil {
+		return nil, err
+	}
+	state := findState(tenantStore.Root(), name)
+	if state == nil {
+		return nil, fmt.Errorf("state %q not found", name)
+	}
+	if _, ok := state.Translations[locale]; !ok {
+		return nil, fmt.Errorf("state %q has no %q translation", state.Name, locale)
+	}
+
+	if err := tenantStore.Repository().RemoveTranslation(ctx, state.Name, locale); err != nil {
+		return nil, err
+	}
+	translations := copyTranslations(state.Translations)
+	delete(translations, locale)
+	updateTranslations(ctx, tenantStore, state.Name, translations)
+	log.Printf("Removed translation for state: %s, Locale: %s", state.Name, locale)
+	return findState(tenantStore.Root(), state.Name), nil
+}
+
+// translationArgs are the arguments selecting the state and locale of a translation
+var translationArgs = graphql.FieldConfigArgument{
+	"name": &graphql.ArgumentConfig{
+		Type: graphql.NewNonNull(graphql.String),
+	},
+	"locale": &graphql.ArgumentConfig{
+		Type:        graphql.NewNonNull(graphql.String),
+		Description: "BCP 47 language tag, e.g. es or tl; region subtags are dropped",
+	},
+}
+
+// addTranslationField sets the name of a state in a locale
+var addTranslationField = &graphql.Field{
+	Type: stateType,
+	Args: graphql.FieldConfigArgument{
+		"name":   translationArgs["name"],
+		"locale": translationArgs["locale"],
+		"translation": &graphql.ArgumentConfig{
+			Type: graphql.NewNonNull(graphql.String),
+		},
+	},
+	Resolve: audited("addTranslation", stateSnapshot, func(p graphql.ResolveParams) (interface{}, error) {
+		if _, err := requireAdmin(p.Context); err != nil {
+			return nil, err
+		}
+		return addTranslation(p.Context, p.Args["name"].(string), p.Args["locale"].(string), p.Args["translation"].(string))
+	}),
+}
+
+// removeTranslationField removes the name of a state in a locale
+var removeTranslationField = &graphql.Field{
+	Type: stateType,
+	Args: translationArgs,
+	Resolve: audited("removeTranslation", stateSnapshot, func(p graphql.ResolveParams) (interface{}, error) {
+		if _, err := requireAdmin(p.Context); err != nil {
+			return nil, err
+		}
+		return removeTranslation(p.Context, p.Args["name"].(string), p.Args["locale"].(string))
+	}),
+}
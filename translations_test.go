@@ -0,0 +1,72 @@
+package backend
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestAddAndRemoveTranslation(t *testing.T) {
+	withUnreachableMongo(t)
+	s := newTestStore(t,
+		State{Name: "Texas", Code: "TX", Enabled: true, Kind: KindState},
+		State{Name: "Tennessee", Code: "TN", Enabled: true, Kind: KindState},
+	)
+	ctx := asAdmin(context.Background(), "ops")
+
+	result := runGraphQL(t, ctx, `mutation { addTranslation(name: "Texas", locale: "es-MX", translation: "Tejas") { name } }`)
+	if len(result.Errors) > 0 {
+		t.Fatal(result.Errors)
+	}
+	// the translation is searchable right away, under the language of the locale
+	if names := searchNames(s.Locale("es"), "Tej"); !reflect.DeepEqual(names, []string{"Texas"}) {
+		t.Errorf("searching Tej in Spanish after adding Tejas = %v, want Texas", names)
+	}
+	if state, err := s.Repository().FindByName(context.Background(), "Texas"); err != nil || state.Translations["es"] != "Tejas" {
+		t.Errorf("Texas in the repository after adding Tejas = %+v, %v", state, err)
+	}
+
+	result = runGraphQL(t, ctx, `mutation { removeTranslation(name: "Texas", locale: "es") { name } }`)
+	if len(result.Errors) > 0 {
+		t.Fatal(result.Errors)
+	}
+	// the Spanish index goes with the last Spanish name
+	if root := s.Locale("es"); root != nil {
+		t.Errorf("searching Tej in Spanish after removing Tejas = %v, want no Spanish index", searchNames(root, "Tej"))
+	}
+	if state, _ := s.Repository().FindByName(context.Background(), "Texas"); len(state.Translations) != 0 {
+		t.Errorf("Texas in the repository after removing Tejas has the translations %q", state.Translations)
+	}
+	if result := runGraphQL(t, ctx, `mutation { removeTranslation(name: "Texas", locale: "es") { name } }`); len(result.Errors) != 1 {
+		t.Errorf("removing a missing translation = %v, want an error", result.Errors)
+	}
+}
+
+func TestAddTranslationRejections(t *testing.T) {
+	withUnreachableMongo(t)
+	s := newTestStore(t,
+		State{Name: "Texas", Code: "TX", Enabled: true, Kind: KindState},
+		State{Name: "Tennessee", Code: "TN", Enabled: true, Kind: KindState},
+	)
+	ctx := asAdmin(context.Background(), "ops")
+
+	for _, test := range []struct {
+		mutation string
+		message  string
+	}{
+		{`addTranslation(name: "Texas", locale: "es", translation: "Tennessee")`, "name of another state"},
+		{`addTranslation(name: "Texas", locale: "not a locale", translation: "Tejas")`, "not a valid language tag"},
+		{`addTranslation(name: "Texas", locale: "en", translation: "Tejas")`, "already in"},
+		{`addTranslation(name: "Texas", locale: "es", translation: "  ")`, "must not be empty"},
+		{`addTranslation(name: "Atlantis", locale: "es", translation: "Atlántida")`, "not found"},
+	} {
+		result := runGraphQL(t, ctx, `mutation { `+test.mutation+` { name } }`)
+		if len(result.Errors) != 1 || !strings.Contains(result.Errors[0].Message, test.message) {
+			t.Errorf("%s = %v, want an error containing %q", test.mutation, result.Errors, test.message)
+		}
+	}
+	if state := findState(s.Root(), "Texas"); len(state.Translations) != 0 {
+		t.Errorf("Texas has the translations %q after rejected ones", state.Translations)
+	}
+}
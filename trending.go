@@ -0,0 +1,278 @@
+package backend
+
+import (
+	"context"
+	"log"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/graphql-go/graphql"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	// trendBucketSize is the width of a single selection count bucket
+	trendBucketSize = time.Hour
+	// trendRetention is how far back selection counts are kept
+	trendRetention = 14 * 24 * time.Hour
+	// trendPersistInterval is how often the buckets are written to MongoDB
+	trendPersistInterval = 5 * time.Minute
+	// defaultTrendWindow is the window used when the query does not give one
+	defaultTrendWindow = 24 * time.Hour
+	// defaultTrendLimit is the number of trending states returned when no limit is given
+	defaultTrendLimit = 10
+)
+
+// trendBucket holds the selection counts per state code for one bucket
+type trendBucket struct {
+	Start  time.Time      `bson:"start"`
+	Counts map[string]int `bson:"counts"`
+}
+
+// TrendTracker keeps per-state selection counts in a ring buffer of time buckets
+type TrendTracker struct {
+	mu      sync.Mutex
+	now     func() time.Time
+	buckets []trendBucket
+}
+
+// TrendingState represents a state ranked by its selections within a window
+type TrendingState struct {
+	State         *State `json:"state"`
+	Count         int    `json:"count"`
+	PreviousCount int    `json:"previousCount"`
+	Delta         int    `json:"delta"`
+}
+
+var trends = NewTrendTracker(time.Now)
+
+// NewTrendTracker creates a tracker that reads the current time from the given clock
+func NewTrendTracker(now func() time.Time) *TrendTracker {
+	return &TrendTracker{
+		now:     now,
+		buckets: make([]trendBucket, int(trendRetention/trendBucketSize)),
+	}
+}
+
+// bucketFor returns the bucket for the given time, evicting whatever it held before
+func (t *TrendTracker) bucketFor(at time.Time) *trendBucket {
+	start := at.Truncate(trendBucketSize)
+	bucket := &t.buckets[int(start.Unix()/int64(trendBucketSize/time.Second))%len(t.buckets)]
+	if !bucket.Start.Equal(start) {
+		bucket.Start = start
+		bucket.Counts = make(map[string]int)
+	}
+	return bucket
+}
+
+// Record counts one selection of the state with the given code
+func (t *TrendTracker) Record(code string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.bucketFor(t.now()).Counts[code]++
+}
+
+// windowBuckets returns the number of buckets covered by the window, clamped to half the retention
+func windowBuckets(window time.Duration) int {
+	n := int((window + trendBucketSize - 1) / trendBucketSize)
+	if n < 1 {
+		n = 1
+	}
+	if maxBuckets := int(trendRetention/trendBucketSize) / 2; n > maxBuckets {
+		n = maxBuckets
+	}
+	return n
+}
+
+// Counts sums the selections per state code in the current window and in the window before it.
+// The current window ends with the bucket holding the current time.
+func (t *TrendTracker) Counts(window time.Duration) (current, previous map[string]int) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	n := windowBuckets(window)
+	currentStart := t.now().Truncate(trendBucketSize).Add(-time.Duration(n-1) * trendBucketSize)
+	previousStart := currentStart.Add(-time.Duration(n) * trendBucketSize)
+
+	current = make(map[string]int)
+	previous = make(map[string]int)
+	for _, bucket := range t.buckets {
+		if bucket.Counts == nil || bucket.Start.Before(previousStart) {
+			continue
+		}
+		target := current
+		if bucket.Start.Before(currentStart) {
+			target = previous
+		}
+		for code, count := range bucket.Counts {
+			target[code] += count
+		}
+	}
+	return current, previous
+}
+
+// Trending ranks the given states by their selections within the window
+func (t *TrendTracker) Trending(states []*State, window time.Duration, limit int) []*TrendingState {
+	current, previous := t.Counts(window)
+
+	results := []*TrendingState{}
+	for _, state := range states {
+		count := current[state.Code]
+		if count == 0 && previous[state.Code] == 0 {
+			continue
+		}
+		results = append(results, &TrendingState{
+			State:         state,
+			Count:         count,
+			PreviousCount: previous[state.Code],
+			Delta:         count - previous[state.Code],
+		})
+	}
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Count != results[j].Count {
+			return results[i].Count > results[j].Count
+		}
+		return results[i].Delta > results[j].Delta
+	})
+	if limit > 0 && len(results) > limit {
+		results = results[:limit]
+	}
+	return results
+}
+
+// snapshot returns copies of the buckets that are still within the retention
+func (t *TrendTracker) snapshot() []trendBucket {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	oldest := t.now().Add(-trendRetention)
+	buckets := []trendBucket{}
+	for _, bucket := range t.buckets {
+		if bucket.Counts == nil || bucket.Start.Before(oldest) {
+			continue
+		}
+		counts := make(map[string]int, len(bucket.Counts))
+		for code, count := range bucket.Counts {
+			counts[code] = count
+		}
+		buckets = append(buckets, trendBucket{Start: bucket.Start, Counts: counts})
+	}
+	return buckets
+}
+
+// restore loads previously persisted buckets that are still within the retention
+func (t *TrendTracker) restore(buckets []trendBucket) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	oldest := t.now().Add(-trendRetention)
+	for _, saved := range buckets {
+		if saved.Start.Before(oldest) {
+			continue
+		}
+		bucket := t.bucketFor(saved.Start)
+		for code, count := range saved.Counts {
+			bucket.Counts[code] += count
+		}
+	}
+}
+
+// persistTrends writes the current buckets to MongoDB and removes expired ones
+func persistTrends() {
+	collection := client.Database(config.MongoDB).Collection("trendBuckets")
+	for _, bucket := range trends.snapshot() {
+		_, err := collection.ReplaceOne(
+			context.Background(),
+			bson.M{"start": bucket.Start},
+			bucket,
+			options.Replace().SetUpsert(true),
+		)
+		if err != nil {
+			log.Printf("Error persisting trend bucket %s: %v", bucket.Start, err)
+		}
+	}
+	_, err := collection.DeleteMany(context.Background(), bson.M{
+		"start": bson.M{"$lt": time.Now().Add(-trendRetention)},
+	})
+	if err != nil {
+		log.Printf("Error deleting expired trend buckets: %v", err)
+	}
+}
+
+// loadTrends restores the persisted buckets from MongoDB
+func loadTrends() {
+	collection := client.Database(config.MongoDB).Collection("trendBuckets")
+	cursor, err := collection.Find(context.Background(), bson.M{})
+	if err != nil {
+		log.Printf("Error loading trend buckets: %v", err)
+		return
+	}
+	defer cursor.Close(context.Background())
+
+	var buckets []trendBucket
+	if err := cursor.All(context.Background(), &buckets); err != nil {
+		log.Printf("Error decoding trend buckets: %v", err)
+		return
+	}
+	trends.restore(buckets)
+	log.Printf("Loaded %d trend buckets", len(buckets))
+}
+
+// startTrendPersister periodically persists the trend buckets in the background
+func startTrendPersister() {
+	go func() {
+		ticker := time.NewTicker(trendPersistInterval)
+		defer ticker.Stop()
+		for range ticker.C {
+			persistTrends()
+		}
+	}()
+}
+
+// Define the GraphQL trending state type
+var trendingStateType = graphql.NewObject(graphql.ObjectConfig{
+	Name: "TrendingState",
+	Fields: graphql.Fields{
+		"state": &graphql.Field{
+			Type: stateType,
+		},
+		"count": &graphql.Field{
+			Type: graphql.Int,
+		},
+		"previousCount": &graphql.Field{
+			Type: graphql.Int,
+		},
+		"delta": &graphql.Field{
+			Type: graphql.Int,
+		},
+	},
+})
+
+// trendingStatesField ranks states by their selections within a recent window
+var trendingStatesField = &graphql.Field{
+	Type: graphql.NewList(trendingStateType),
+	Args: graphql.FieldConfigArgument{
+		"window": &graphql.ArgumentConfig{
+			Type: durationScalar,
+		},
+		"limit": &graphql.ArgumentConfig{
+			Type: graphql.Int,
+		},
+	},
+	Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+		window, ok := p.Args["window"].(time.Duration)
+		if !ok || window <= 0 {
+			window = defaultTrendWindow
+		}
+		limit, ok := p.Args["limit"].(int)
+		if !ok || limit <= 0 {
+			limit = defaultTrendLimit
+		}
+		trieRoot := store.Root()
+		states := make([]*State, 0, trieRoot.SubtreeCount)
+		collectStates(p.Context, trieRoot, &states)
+		return trends.Trending(states, window, limit), nil
+	},
+}
@@ -0,0 +1,162 @@
+package backend
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+// testClock is a settable clock for trackers under test
+type testClock struct {
+	now time.Time
+}
+
+func (c *testClock) Now() time.Time {
+	return c.now
+}
+
+func TestTrendCountsAtBucketBoundaries(t *testing.T) {
+	clock := &testClock{now: time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC)}
+	tracker := NewTrendTracker(clock.Now)
+	record := func(at string, code string, times int) {
+		clock.now, _ = time.Parse(time.RFC3339, "2024-03-01T"+at+"Z")
+		for i := 0; i < times; i++ {
+			tracker.Record(code)
+		}
+	}
+	record("08:59:59", "FL", 100)
+	record("09:00:00", "FL", 1)
+	record("10:59:59", "FL", 2)
+	record("11:00:00", "FL", 4)
+	record("11:00:00", "TX", 1)
+	record("12:59:59", "TX", 8)
+
+	tests := []struct {
+		at       string
+		window   time.Duration
+		current  map[string]int
+		previous map[string]int
+	}{
+		// the 12:00 and 11:00 buckets against the 10:00 and 09:00 ones
+		{"12:59:59", 2 * time.Hour, map[string]int{"FL": 4, "TX": 9}, map[string]int{"FL": 3}},
+		// a partial hour rounds up to a whole bucket
+		{"12:00:00", 90 * time.Minute, map[string]int{"FL": 4, "TX": 9}, map[string]int{"FL": 3}},
+		// the bucket holding the current time counts, however little of it has passed
+		{"13:00:00", time.Hour, map[string]int{}, map[string]int{"TX": 8}},
+		// buckets before the previous window are left out
+		{"14:00:00", 3 * time.Hour, map[string]int{"TX": 8}, map[string]int{"FL": 7, "TX": 1}},
+	}
+	for _, test := range tests {
+		clock.now, _ = time.Parse(time.RFC3339, "2024-03-01T"+test.at+"Z")
+		current, previous := tracker.Counts(test.window)
+		if !reflect.DeepEqual(current, test.current) || !reflect.DeepEqual(previous, test.previous) {
+			t.Errorf("counts at %s over %s = %v and %v before, want %v and %v before",
+				test.at, test.window, current, previous, test.current, test.previous)
+		}
+	}
+}
+
+func TestTrendBucketsExpire(t *testing.T) {
+	clock := &testClock{now: time.Date(2024, 3, 1, 9, 30, 0, 0, time.UTC)}
+	tracker := NewTrendTracker(clock.Now)
+	tracker.Record("FL")
+
+	// the ring wraps around once the retention has passed, reusing the bucket
+	clock.now = clock.now.Add(trendRetention)
+	tracker.Record("TX")
+	current, previous := tracker.Counts(time.Hour)
+	if !reflect.DeepEqual(current, map[string]int{"TX": 1}) || len(previous) != 0 {
+		t.Errorf("counts after the retention = %v and %v before, want only the new selection", current, previous)
+	}
+	if buckets := tracker.snapshot(); len(buckets) != 1 || buckets[0].Counts["FL"] != 0 {
+		t.Errorf("snapshot after the retention = %+v, want only the new bucket", buckets)
+	}
+}
+
+func TestWindowBuckets(t *testing.T) {
+	maxBuckets := int(trendRetention/trendBucketSize) / 2
+	for window, want := range map[time.Duration]int{
+		0:                  1,
+		time.Minute:        1,
+		time.Hour:          1,
+		time.Hour + 1:      2,
+		24 * time.Hour:     24,
+		trendRetention:     maxBuckets,
+		2 * trendRetention: maxBuckets,
+	} {
+		if got := windowBuckets(window); got != want {
+			t.Errorf("windowBuckets(%s) = %d, want %d", window, got, want)
+		}
+	}
+}
+
+func TestTrendingRanksByCountThenDelta(t *testing.T) {
+	clock := &testClock{now: time.Date(2024, 3, 1, 9, 30, 0, 0, time.UTC)}
+	tracker := NewTrendTracker(clock.Now)
+	for code, times := range map[string]int{"FL": 1, "TX": 3, "OH": 5} {
+		for i := 0; i < times; i++ {
+			tracker.Record(code)
+		}
+	}
+	clock.now = clock.now.Add(time.Hour)
+	for code, times := range map[string]int{"FL": 5, "TX": 5, "UT": 2} {
+		for i := 0; i < times; i++ {
+			tracker.Record(code)
+		}
+	}
+	states := []*State{{Name: "Florida", Code: "FL"}, {Name: "Texas", Code: "TX"}, {Name: "Utah", Code: "UT"},
+		{Name: "Ohio", Code: "OH"}, {Name: "Iowa", Code: "IA"}}
+
+	var got []TrendingState
+	for _, trending := range tracker.Trending(states, time.Hour, 0) {
+		got = append(got, TrendingState{Count: trending.Count, PreviousCount: trending.PreviousCount, Delta: trending.Delta, State: &State{Code: trending.State.Code}})
+	}
+	want := []TrendingState{
+		{State: &State{Code: "FL"}, Count: 5, PreviousCount: 1, Delta: 4},
+		{State: &State{Code: "TX"}, Count: 5, PreviousCount: 3, Delta: 2},
+		{State: &State{Code: "UT"}, Count: 2, PreviousCount: 0, Delta: 2},
+		{State: &State{Code: "OH"}, Count: 0, PreviousCount: 5, Delta: -5},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("trending = %+v, want %+v", got, want)
+	}
+	if limited := tracker.Trending(states, time.Hour, 2); len(limited) != 2 {
+		t.Errorf("got %d trending states with a limit of 2", len(limited))
+	}
+}
+
+func TestTrendSnapshotRestore(t *testing.T) {
+	clock := &testClock{now: time.Date(2024, 3, 1, 9, 30, 0, 0, time.UTC)}
+	tracker := NewTrendTracker(clock.Now)
+	tracker.Record("FL")
+	clock.now = clock.now.Add(time.Hour)
+	tracker.Record("TX")
+	tracker.Record("TX")
+
+	restored := NewTrendTracker(clock.Now)
+	restored.restore(tracker.snapshot())
+	// buckets older than the retention are not restored
+	restored.restore([]trendBucket{{Start: clock.now.Add(-2 * trendRetention), Counts: map[string]int{"OH": 9}}})
+	current, previous := restored.Counts(time.Hour)
+	if !reflect.DeepEqual(current, map[string]int{"TX": 2}) || !reflect.DeepEqual(previous, map[string]int{"FL": 1}) {
+		t.Errorf("restored counts = %v and %v before, want TX twice and FL once before", current, previous)
+	}
+}
+
+func TestPersistAndLoadTrends(t *testing.T) {
+	useTestMongo(t)
+	previous := trends
+	defer func() { trends = previous }()
+	trends = NewTrendTracker(time.Now)
+	trends.Record("FL")
+	trends.Record("FL")
+	persistTrends()
+	// persisting again replaces the bucket rather than adding to it
+	persistTrends()
+
+	trends = NewTrendTracker(time.Now)
+	loadTrends()
+	if current, _ := trends.Counts(time.Hour); !reflect.DeepEqual(current, map[string]int{"FL": 2}) {
+		t.Errorf("loaded counts = %v, want FL twice", current)
+	}
+}
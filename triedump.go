@@ -0,0 +1,158 @@
+package backend
+
+import (
+	"net/http"
+	"sort"
+	"strconv"
+)
+
+const (
+	// trieDumpPath dumps a subtree of the tenant's trie as JSON for admins
+	trieDumpPath = "/admin/trie"
+	// defaultTrieDumpDepth is the depth below the prefix node dumped when none is given
+	defaultTrieDumpDepth = 3
+	// maxTrieDumpDepth caps the depth below the prefix node dumped by a single request
+	maxTrieDumpDepth = 10
+	// defaultTrieDumpNodes is the number of nodes dumped when no limit is given
+	defaultTrieDumpNodes = 500
+	// maxTrieDumpNodes caps the nodes dumped by a single request, so the full trie of a large tenant
+	// cannot be dumped by accident
+	maxTrieDumpNodes = 5000
+)
+
+// TrieDumpNode is a trie node as stored, with the full states it ends. Truncated marks nodes whose
+// children were left out by the depth or node limit.
+type TrieDumpNode struct {
+	Char         string          `json:"char"`
+	IsEnd        bool            `json:"isEnd"`
+	Frequency    int64           `json:"frequency"`
+	SubtreeCount int             `json:"subtreeCount"`
+	States       []*State        `json:"states"`
+	Children     []*TrieDumpNode `json:"children"`
+	Truncated    bool            `json:"truncated"`
+}
+
+// TrieDump is the subtree of a trie under a collation key prefix
+type TrieDump struct {
+	Prefix    string        `json:"prefix"`
+	Root      *TrieDumpNode `json:"root"`
+	NodeCount int           `json:"nodeCount"`
+	Truncated bool          `json:"truncated"`
+}
+
+// newDumpNode dumps the node without its children
+func newDumpNode(node *TrieNode, char string) *TrieDumpNode {
+	states := node.States
+	if states == nil {
+		states = []*State{}
+	}
+	return &TrieDumpNode{Char: char, IsEnd: node.IsEnd, Frequency: node.loadFrequency(), SubtreeCount: node.SubtreeCount, States: states, Children: []*TrieDumpNode{}}
+}
+
+// dumpTrie dumps the subtree of the store's trie under the prefix breadth first, stopping at
+// maxDepth levels below the prefix or maxNodes nodes. The trie is walked under the store's read
+// lock, so it is not swapped mid-dump. It returns nil when no name has the prefix.
+func dumpTrie(s *TrieStore, prefix string, maxDepth, maxNodes int) *TrieDump {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	node := findNode(s.root, prefix)
+	if node == nil {
+		return nil
+	}
+
+	type queued struct {
+		node  *TrieNode
+		dump  *TrieDumpNode
+		depth int
+	}
+	char := ""
+	if runes := []rune(prefix); len(runes) > 0 {
+		char = string(runes[len(runes)-1])
+	}
+	result := &TrieDump{Prefix: prefix, Root: newDumpNode(node, char), NodeCount: 1}
+	queue := []queued{{node: node, dump: result.Root}}
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		if len(current.node.Children) == 0 {
+			continue
+		}
+		if current.depth >= maxDepth || result.NodeCount >= maxNodes {
+			current.dump.Truncated = true
+			result.Truncated = true
+			continue
+		}
+
+		chars := make([]rune, 0, len(current.node.Children))
+		for char := range current.node.Children {
+			chars = append(chars, char)
+		}
+		sort.Slice(chars, func(i, j int) bool { return chars[i] < chars[j] })
+		for _, char := range chars {
+			if result.NodeCount >= maxNodes {
+				current.dump.Truncated = true
+				result.Truncated = true
+				break
+			}
+			child := current.node.Children[char]
+			dump := newDumpNode(child, string(char))
+			current.dump.Children = append(current.dump.Children, dump)
+			result.NodeCount++
+			queue = append(queue, queued{node: child, dump: dump, depth: current.depth + 1})
+		}
+	}
+	return result
+}
+
+// queryInt parses the named query parameter as an integer between 0 and max, defaulting to fallback
+// when it is missing
+func queryInt(r *http.Request, name string, fallback, max int) (int, bool) {
+	value := r.URL.Query().Get(name)
+	if value == "" {
+		return fallback, true
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 0 || n > max {
+		return 0, false
+	}
+	return n, true
+}
+
+// trieDumpHandler serves GET /admin/trie?prefix=ne&depth=3&limit=500, which dumps the subtree of the
+// tenant's trie under the prefix with every state it holds, for debugging unexpected matches
+func trieDumpHandler(w http.ResponseWriter, r *http.Request) {
+	actor, err := requireAdmin(r.Context())
+	if err != nil {
+		writeJSON(w, http.StatusUnauthorized, map[string]string{"error": err.Error()})
+		return
+	}
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", http.MethodGet)
+		writeJSON(w, http.StatusMethodNotAllowed, map[string]string{"error": "method not allowed"})
+		return
+	}
+	depth, ok := queryInt(r, "depth", defaultTrieDumpDepth, maxTrieDumpDepth)
+	if !ok {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "depth must be between 0 and " + strconv.Itoa(maxTrieDumpDepth)})
+		return
+	}
+	limit, ok := queryInt(r, "limit", defaultTrieDumpNodes, maxTrieDumpNodes)
+	if !ok || limit < 1 {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "limit must be between 1 and " + strconv.Itoa(maxTrieDumpNodes)})
+		return
+	}
+	tenantStore, err := storeFor(r.Context())
+	if err != nil {
+		writeJSON(w, http.StatusServiceUnavailable, map[string]string{"error": err.Error()})
+		return
+	}
+
+	prefix := collationKey(r.URL.Query().Get("prefix"))
+	dump := dumpTrie(tenantStore, prefix, depth, limit)
+	if dump == nil {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "no name has the prefix"})
+		return
+	}
+	logf(r.Context(), "Dumped %d trie nodes under %q for %s", dump.NodeCount, prefix, actor)
+	writeJSON(w, http.StatusOK, dump)
+}
@@ -0,0 +1,74 @@
+package backend
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestTrieDumpHandler(t *testing.T) {
+	newTestStore(t,
+		State{Name: "Iowa", Code: "IA", Enabled: true, Kind: KindState, Frequency: 3},
+		State{Name: "Idaho", Code: "ID", Enabled: true, Kind: KindState, Frequency: 5},
+	)
+	dump := func(ctx context.Context, method, query string) *httptest.ResponseRecorder {
+		recorder := httptest.NewRecorder()
+		trieDumpHandler(recorder, httptest.NewRequest(method, trieDumpPath+query, nil).WithContext(ctx))
+		return recorder
+	}
+	admin := asAdmin(context.Background(), "ops")
+
+	response := dump(admin, http.MethodGet, "?prefix=Io")
+	want := `{"prefix":"Io","root":{"char":"o","isEnd":false,"frequency":0,"subtreeCount":1,"states":[],"children":[` +
+		`{"char":"w","isEnd":false,"frequency":0,"subtreeCount":1,"states":[],"children":[` +
+		`{"char":"a","isEnd":true,"frequency":3,"subtreeCount":1,"states":[{"name":"Iowa","code":"IA","frequency":3,"enabled":true,"kind":"STATE"}],"children":[],"truncated":false}` +
+		`],"truncated":false}],"truncated":false},"nodeCount":3,"truncated":false}`
+	if got := strings.TrimSpace(response.Body.String()); response.Code != http.StatusOK || got != want {
+		t.Errorf("dump of Io = %d %s, want %s", response.Code, got, want)
+	}
+
+	tests := []struct {
+		query     string
+		nodes     int
+		truncated bool
+	}{
+		{"?prefix=I&depth=10", 8, false},
+		// the depth and node limits leave out the rest of the subtree, three levels by default
+		{"?prefix=I", 7, true},
+		{"?prefix=I&depth=1", 3, true},
+		{"?prefix=I&depth=0", 1, true},
+		{"?prefix=I&limit=4", 4, true},
+	}
+	for _, test := range tests {
+		response := dump(admin, http.MethodGet, test.query)
+		var got TrieDump
+		if err := json.Unmarshal(response.Body.Bytes(), &got); err != nil || response.Code != http.StatusOK {
+			t.Errorf("dump %s = %d %s", test.query, response.Code, response.Body)
+			continue
+		}
+		if got.NodeCount != test.nodes || got.Truncated != test.truncated {
+			t.Errorf("dump %s has %d nodes, truncated %t, want %d, %t", test.query, got.NodeCount, got.Truncated, test.nodes, test.truncated)
+		}
+	}
+
+	for _, test := range []struct {
+		ctx    context.Context
+		method string
+		query  string
+		status int
+	}{
+		{context.Background(), http.MethodGet, "?prefix=I", http.StatusUnauthorized},
+		{admin, http.MethodPost, "?prefix=I", http.StatusMethodNotAllowed},
+		{admin, http.MethodGet, "?prefix=I&depth=11", http.StatusBadRequest},
+		{admin, http.MethodGet, "?prefix=I&limit=0", http.StatusBadRequest},
+		{admin, http.MethodGet, "?prefix=I&limit=5001", http.StatusBadRequest},
+		{admin, http.MethodGet, "?prefix=Iz", http.StatusNotFound},
+	} {
+		if response := dump(test.ctx, test.method, test.query); response.Code != test.status {
+			t.Errorf("%s %s = %d, want %d", test.method, test.query, response.Code, test.status)
+		}
+	}
+}
@@ -0,0 +1,226 @@
+package backend
+
+import (
+	"context"
+	"log"
+	"reflect"
+	"time"
+)
+
+// stateChanges are the differences between a repository and the trie loaded from it. Updated
+// holds the repository's version of states whose name is still in the trie.
+type stateChanges struct {
+	Inserted []*State
+	Updated  []*State
+	Deleted  []*State
+}
+
+// diffStates compares the states under root with the current states of the repository
+func diffStates(ctx context.Context, root *TrieNode, current []*State) stateChanges {
+	var loaded []*State
+	collectStates(ctx, root, &loaded)
+	existing := make(map[string]*State, len(loaded))
+	for _, state := range loaded {
+		existing[state.Name] = state
+	}
+
+	changes := stateChanges{}
+	for _, state := range current {
+		old, ok := existing[state.Name]
+		if !ok {
+			changes.Inserted = append(changes.Inserted, state)
+			continue
+		}
+		delete(existing, state.Name)
+		if !sameState(old, state) {
+			changes.Updated = append(changes.Updated, state)
+		}
+	}
+	for _, state := range existing {
+		changes.Deleted = append(changes.Deleted, state)
+	}
+	return changes
+}
+
+// sameState reports whether both states hold the same values
+func sameState(a, b *State) bool {
+	return a.Code == b.Code && a.loadFrequency() == b.loadFrequency() && a.Enabled == b.Enabled && a.Kind == b.Kind &&
+		sameTranslations(a.Translations, b.Translations)
+}
+
+// sameTranslations reports whether both translation maps hold the same names, treating nil as empty
+func sameTranslations(a, b map[string]string) bool {
+	if len(a) == 0 && len(b) == 0 {
+		return true
+	}
+	return reflect.DeepEqual(a, b)
+}
+
+// cloneTrie copies the nodes under node, sharing the states they point to
+func cloneTrie(node *TrieNode) *TrieNode {
+	clone := &TrieNode{
+		Children:     make(map[rune]*TrieNode, len(node.Children)),
+		IsEnd:        node.IsEnd,
+		States:       append([]*State(nil), node.States...),
+		Frequency:    node.loadFrequency(),
+		SubtreeCount: node.SubtreeCount,
+	}
+	for char, child := range node.Children {
+		clone.Children[char] = cloneTrie(child)
+	}
+	return clone
+}
+
+// removeKey removes the state stored under the key, deletes the nodes left without states and
+// returns the number of nodes deleted
+func removeKey(root *TrieNode, key string, state *State) int {
+	path := []*TrieNode{root}
+	chars := []rune(key)
+	node := root
+	for _, char := range chars {
+		node = node.Children[char]
+		if node == nil {
+			return 0
+		}
+		path = append(path, node)
+	}
+
+	kept := node.States[:0]
+	for _, stored := range node.States {
+		if stored != state {
+			kept = append(kept, stored)
+		}
+	}
+	removed := len(node.States) - len(kept)
+	for _, visited := range path {
+		visited.SubtreeCount -= removed
+	}
+	node.States = kept
+	node.IsEnd = len(kept) > 0
+	refreshNodeFrequency(node)
+
+	deleted := 0
+	for i := len(chars) - 1; i >= 0; i-- {
+		child := path[i+1]
+		if child.IsEnd || len(child.Children) > 0 {
+			break
+		}
+		delete(path[i].Children, chars[i])
+		deleted++
+	}
+	return deleted
+}
+
+// refreshNodeFrequency sets the frequency of the node to the highest frequency of its states
+func refreshNodeFrequency(node *TrieNode) {
+	var frequency int64
+	for i, state := range node.States {
+		if stateFrequency := state.loadFrequency(); i == 0 || stateFrequency > frequency {
+			frequency = stateFrequency
+		}
+	}
+	node.storeFrequency(frequency)
+}
+
+// applyStateChanges applies the changes to the store. A change of frequency alone is stored
+// atomically on the state in the trie and only drops the cached searches it affects. States with
+// any other change are replaced by a copy holding the new values: inserts, deletes and replacements
+// are applied to a copy of the trie that is swapped in once complete, which rebuilds the code and
+// localized indexes, so searches never see a partially changed state or trie. The caller must hold
+// s.writeMu.
+func applyStateChanges(ctx context.Context, s *TrieStore, changes stateChanges) {
+	root := s.Root()
+	var replaced, replacements, invalidated []*State
+	for _, updated := range changes.Updated {
+		node := findNode(root, collationKey(updated.Name))
+		state := stateNamed(node, updated.Name)
+		if state == nil {
+			continue
+		}
+		if state.Code != updated.Code || state.Enabled != updated.Enabled || state.Kind != updated.Kind ||
+			!sameTranslations(state.Translations, updated.Translations) {
+			replaced = append(replaced, state)
+			replacements = append(replacements, copyState(updated))
+			continue
+		}
+		state.storeFrequency(updated.Frequency)
+		refreshNodeFrequency(node)
+		invalidated = append(invalidated, state)
+	}
+	if len(changes.Inserted)+len(changes.Deleted)+len(replaced) == 0 {
+		for _, state := range invalidated {
+			s.Cache().Invalidate(state)
+		}
+		return
+	}
+
+	next := cloneTrie(root)
+	for _, deleted := range changes.Deleted {
+		removeKey(next, collationKey(deleted.Name), deleted)
+	}
+	for _, state := range replaced {
+		removeKey(next, collationKey(state.Name), state)
+	}
+	for _, inserted := range changes.Inserted {
+		insert(ctx, next, inserted)
+	}
+	for _, replacement := range replacements {
+		insert(ctx, next, replacement)
+	}
+	s.Swap(next)
+}
+
+// syncTrie reads the states of the store's repository and applies the differences to its trie
+func syncTrie(ctx context.Context, s *TrieStore) (stateChanges, error) {
+	current, err := s.Repository().FindAll(ctx)
+	if err != nil {
+		return stateChanges{}, err
+	}
+	if err := addFrequencies(ctx, s.Frequencies(), current); err != nil {
+		return stateChanges{}, err
+	}
+	kept := current[:0]
+	for _, state := range current {
+		normalizeState(state)
+		if !s.pruned.contains(state.Name) {
+			kept = append(kept, state)
+		}
+	}
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+	changes := diffStates(ctx, s.Root(), kept)
+	applyStateChanges(ctx, s, changes)
+	return changes, nil
+}
+
+// syncLoadedTries syncs the trie of every loaded tenant with its repository
+func syncLoadedTries(ctx context.Context) {
+	for tenantID, tenantStore := range tenants.Loaded() {
+		changes, err := syncTrie(ctx, tenantStore)
+		if err != nil {
+			log.Printf("Error syncing trie for tenant %s: %v", tenantID, err)
+			continue
+		}
+		if len(changes.Inserted)+len(changes.Updated)+len(changes.Deleted) > 0 {
+			log.Printf("Synced trie for tenant %s, Inserted: %d, Updated: %d, Deleted: %d",
+				tenantID, len(changes.Inserted), len(changes.Updated), len(changes.Deleted))
+		}
+	}
+}
+
+// startTrieSync periodically syncs the loaded tries with MongoDB in the background. Changes to the
+// default tenant's states are also applied live from a change stream when MongoDB supports them.
+func startTrieSync(interval time.Duration) {
+	if interval <= 0 {
+		return
+	}
+	watcher := newStateWatcher(store, client.Database(config.MongoDB).Collection(tenantCollectionName(defaultTenantID)))
+	go watcher.Run(context.Background())
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for range ticker.C {
+			syncLoadedTries(context.Background())
+		}
+	}()
+}
@@ -0,0 +1,88 @@
+package backend
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func TestSyncTrieAppliesExternalWrites(t *testing.T) {
+	s := newTestStore(t,
+		State{Name: "Texas", Code: "TX", Enabled: true, Kind: KindState, Frequency: 4},
+		State{Name: "Tennessee", Code: "TN", Enabled: true, Kind: KindState},
+		State{Name: "Utah", Code: "UT", Enabled: true, Kind: KindState},
+	)
+	repo := s.Repository()
+	ctx := context.Background()
+	texas := findState(s.Root(), "Texas")
+
+	// another service writes to the repository behind the trie's back
+	if err := repo.Insert(ctx, &State{Name: "Tamaulipas", Code: "tm", Enabled: true, Kind: KindProvince}); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Frequencies().Increment(ctx, "Texas", 3); err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.SetKind(ctx, "Tennessee", KindTerritory); err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.Delete(ctx, "Utah"); err != nil {
+		t.Fatal(err)
+	}
+	syncLoadedTries(ctx)
+
+	if names := searchNames(s.Root(), "T"); !reflect.DeepEqual(names, []string{"Texas", "Tamaulipas", "Tennessee"}) {
+		t.Errorf("searching T after the sync = %v, want Texas, Tamaulipas and Tennessee", names)
+	}
+	if state := findState(s.Root(), "Tamaulipas"); state == nil || state.Code != "TM" {
+		t.Errorf("inserted Tamaulipas = %+v, want it with its code uppercased", state)
+	}
+	// frequency changes are applied in place, other changes replace the state
+	if state := findState(s.Root(), "Texas"); state != texas || state.loadFrequency() != 7 {
+		t.Errorf("Texas after the sync = %+v, want the same state with a frequency of 7", state)
+	}
+	if state := findState(s.Root(), "Tennessee"); state == nil || state.Kind != KindTerritory {
+		t.Errorf("Tennessee after the sync = %+v, want a territory", state)
+	}
+	if findState(s.Root(), "Utah") != nil || findNode(s.Root(), collationKey("U")) != nil {
+		t.Error("Utah and its nodes are still in the trie after the sync")
+	}
+	if count := s.Root().SubtreeCount; count != 3 {
+		t.Errorf("trie counts %d states after the sync, want 3", count)
+	}
+
+	// syncing again without external writes changes nothing
+	changes, err := syncTrie(ctx, s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(changes.Inserted)+len(changes.Updated)+len(changes.Deleted) != 0 {
+		t.Errorf("second sync found changes %+v", changes)
+	}
+}
+
+func TestSyncTrieDetectsExternalMongoInserts(t *testing.T) {
+	db := useTestMongo(t)
+	newTestStore(t)
+	s := NewTrieStore(stateRepository("states"))
+	ctx := context.Background()
+	if err := s.RebuildTrie(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := db.Collection("states").InsertOne(ctx, bson.M{"name": "Texas", "code": "TX", "frequency": 2}); err != nil {
+		t.Fatal(err)
+	}
+	changes, err := syncTrie(ctx, s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(changes.Inserted) != 1 || changes.Inserted[0].Name != "Texas" {
+		t.Errorf("sync found %+v, want Texas inserted", changes)
+	}
+	if state := findState(s.Root(), "Texas"); state == nil || !state.Enabled || state.loadFrequency() != 2 {
+		t.Errorf("Texas after the sync = %+v, want it enabled with a frequency of 2", state)
+	}
+}
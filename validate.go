@@ -0,0 +1,38 @@
+package backend
+
+import (
+	"fmt"
+	"unicode/utf8"
+)
+
+// invalidInputCode is the error code reported for mutation arguments failing validation
+const invalidInputCode = "INVALID_INPUT"
+
+// inputError is a validation error reported with the INVALID_INPUT code in the error extensions
+type inputError struct {
+	message string
+}
+
+// Error returns the validation message
+func (e *inputError) Error() string {
+	return e.message
+}
+
+// Extensions returns the error code added to the GraphQL error
+func (e *inputError) Extensions() map[string]interface{} {
+	return map[string]interface{}{"code": invalidInputCode}
+}
+
+// invalidInput returns an INVALID_INPUT error with the formatted message
+func invalidInput(format string, args ...interface{}) error {
+	return &inputError{message: fmt.Sprintf(format, args...)}
+}
+
+// validateCode checks that a state code has exactly the configured length. Codes are uppercased by
+// normalizeState before they are validated.
+func validateCode(code string, length int) error {
+	if utf8.RuneCountInString(code) != length {
+		return invalidInput("code %q must be exactly %d characters", code, length)
+	}
+	return nil
+}
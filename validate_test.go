@@ -0,0 +1,55 @@
+package backend
+
+import (
+	"context"
+	"testing"
+)
+
+func TestValidateCode(t *testing.T) {
+	for _, test := range []struct {
+		code   string
+		length int
+		valid  bool
+	}{
+		{"TX", 2, true},
+		{"T", 2, false},
+		{"", 2, false},
+		{"TEX", 2, false},
+		{"TEX", 3, true},
+		// characters are counted, not bytes
+		{"ÑA", 2, true},
+	} {
+		err := validateCode(test.code, test.length)
+		if (err == nil) != test.valid {
+			t.Errorf("validateCode(%q, %d) = %v, want valid %t", test.code, test.length, err, test.valid)
+		}
+		if inputErr, ok := err.(*inputError); err != nil && (!ok || inputErr.Extensions()["code"] != invalidInputCode) {
+			t.Errorf("validateCode(%q, %d) = %#v, want an %s error", test.code, test.length, err, invalidInputCode)
+		}
+	}
+}
+
+func TestAddStateValidatesCode(t *testing.T) {
+	withUnreachableMongo(t)
+	s := newTestStore(t, State{Name: "Texas", Code: "TX", Enabled: true, Kind: KindState})
+	ctx := asAdmin(context.Background(), "ops")
+
+	for _, code := range []string{"U", "UTA", ""} {
+		result := runGraphQL(t, ctx, `mutation { addState(name: "Utah", code: "`+code+`") { name } }`)
+		if len(result.Errors) != 1 || result.Errors[0].Extensions["code"] != invalidInputCode {
+			t.Errorf("adding Utah with the code %q = %v, want an %s error", code, result.Errors, invalidInputCode)
+		}
+	}
+	if count, _ := s.Repository().Count(context.Background()); count != 1 {
+		t.Errorf("repository holds %d states after rejected codes, want 1", count)
+	}
+
+	// lowercase codes are uppercased before they are validated
+	result := runGraphQL(t, ctx, `mutation { addState(name: "Utah", code: "ut") { name code } }`)
+	if len(result.Errors) > 0 {
+		t.Fatal(result.Errors)
+	}
+	if state := findState(s.Root(), "Utah"); state == nil || state.Code != "UT" {
+		t.Errorf("Utah added with the code ut = %+v, want the code UT", state)
+	}
+}
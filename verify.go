@@ -0,0 +1,88 @@
+package backend
+
+import (
+	"context"
+	"log"
+	"sync/atomic"
+)
+
+// TrieVerification is the outcome of checking a freshly built trie against its repository
+type TrieVerification struct {
+	// Stored is the number of states in the repository
+	Stored int
+	// Loaded is the number of states in the trie, pruned states included
+	Loaded int
+	// Sampled is the number of repository states looked up in the trie
+	Sampled int
+	// SampleMissing names the sampled states the trie does not hold
+	SampleMissing []string
+}
+
+// Missing is the number of states the trie lacks: the count shortfall, or the sampled states not
+// found when those are more
+func (v TrieVerification) Missing() int {
+	missing := v.Stored - v.Loaded
+	if len(v.SampleMissing) > missing {
+		missing = len(v.SampleMissing)
+	}
+	if missing < 0 {
+		return 0
+	}
+	return missing
+}
+
+// verifyTrie checks the trie against the repository it was loaded from, catching loads that stopped
+// early: it compares the repository's state count with the states loaded and looks a random sample
+// of stored states up in the trie. States written while the trie was loading can show up as a
+// small transient mismatch.
+func verifyTrie(ctx context.Context, s *TrieStore, root *TrieNode, loaded, sample int) (TrieVerification, error) {
+	stored, err := s.repo.Count(ctx)
+	if err != nil {
+		return TrieVerification{}, err
+	}
+	verification := TrieVerification{Stored: stored, Loaded: loaded}
+	if sample <= 0 {
+		return verification, nil
+	}
+	sampled, err := s.repo.Sample(ctx, sample)
+	if err != nil {
+		return TrieVerification{}, err
+	}
+	verification.Sampled = len(sampled)
+	for _, state := range sampled {
+		normalizeState(state)
+		if findState(root, state.Name) == nil && !s.pruned.contains(state.Name) {
+			verification.SampleMissing = append(verification.SampleMissing, state.Name)
+		}
+	}
+	return verification, nil
+}
+
+// recordTrieVerification verifies the trie the store just swapped in, logging and counting any
+// discrepancy and keeping the number of missing states for the readiness check
+func recordTrieVerification(ctx context.Context, s *TrieStore, root *TrieNode, loaded int) {
+	verification, err := verifyTrie(ctx, s, root, loaded, config.TrieVerifySample)
+	if err != nil {
+		log.Printf("Error verifying the loaded trie: %v", err)
+		trieVerificationFailuresTotal.Inc()
+		return
+	}
+	missing := verification.Missing()
+	atomic.StoreInt64(&s.missing, int64(missing))
+	if verification.Stored != verification.Loaded {
+		trieVerificationMismatchesTotal.WithLabelValues("count").Inc()
+		log.Printf("Trie verification: %d states stored but %d loaded", verification.Stored, verification.Loaded)
+	}
+	if len(verification.SampleMissing) > 0 {
+		trieVerificationMismatchesTotal.WithLabelValues("sample").Inc()
+		log.Printf("Trie verification: %d of %d sampled states missing from the trie: %v",
+			len(verification.SampleMissing), verification.Sampled, verification.SampleMissing)
+	}
+}
+
+// trieIncomplete reports whether the default tenant's trie lacks more states than
+// TRIE_VERIFY_MAX_MISSING allows, which withholds readiness. A negative limit never does.
+func trieIncomplete() (int, bool) {
+	missing := int(atomic.LoadInt64(&store.missing))
+	return missing, config.TrieVerifyMaxMissing >= 0 && missing > config.TrieVerifyMaxMissing
+}
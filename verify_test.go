@@ -0,0 +1,101 @@
+package backend
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// lossyRepository loses the states after the first keep of every FindAll, like a load whose cursor
+// stopped early, while counting and sampling all of them
+type lossyRepository struct {
+	*InMemoryStateRepository
+	keep int
+}
+
+func (r *lossyRepository) FindAll(ctx context.Context) ([]*State, error) {
+	states, err := r.InMemoryStateRepository.FindAll(ctx)
+	if err != nil || len(states) <= r.keep {
+		return states, err
+	}
+	return states[:r.keep], nil
+}
+
+func TestVerifyTrieDetectsLossyLoad(t *testing.T) {
+	newTestStore(t)
+	previousStartup, previousSample, previousMaxMissing := startup, config.TrieVerifySample, config.TrieVerifyMaxMissing
+	t.Cleanup(func() {
+		startup, config.TrieVerifySample, config.TrieVerifyMaxMissing = previousStartup, previousSample, previousMaxMissing
+	})
+	startup = NewStartupProgress(time.Now)
+	startup.Enter(PhaseReady)
+	config.TrieVerifySample = 10
+
+	repo := &lossyRepository{InMemoryStateRepository: NewInMemoryStateRepository(
+		State{Name: "Texas", Code: "TX", Enabled: true, Kind: KindState},
+		State{Name: "Tennessee", Code: "TN", Enabled: true, Kind: KindState},
+		State{Name: "Iowa", Code: "IA", Enabled: true, Kind: KindState},
+		State{Name: "Idaho", Code: "ID", Enabled: true, Kind: KindState},
+	), keep: 1}
+	store = NewTrieStore(repo)
+	ready := func() (int, readiness) {
+		recorder := httptest.NewRecorder()
+		readyHandler(recorder, httptest.NewRequest(http.MethodGet, readyPath, nil))
+		var status readiness
+		if err := json.Unmarshal(recorder.Body.Bytes(), &status); err != nil {
+			t.Fatal(err)
+		}
+		return recorder.Code, status
+	}
+
+	countMismatches := scrapeMetric(t, `trie_verification_mismatches_total{check="count"}`)
+	sampleMismatches := scrapeMetric(t, `trie_verification_mismatches_total{check="sample"}`)
+	if err := store.RebuildTrie(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	verification, err := verifyTrie(context.Background(), store, store.Root(), countLeaves(store.Root()), 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if verification.Stored != 4 || verification.Loaded != 1 || verification.Sampled != 4 || len(verification.SampleMissing) != 3 || verification.Missing() != 3 {
+		t.Errorf("verification of the lossy load = %+v, want 3 of 4 states missing", verification)
+	}
+	if got := scrapeMetric(t, `trie_verification_mismatches_total{check="count"}`); got != countMismatches+1 {
+		t.Errorf("count mismatches after the lossy load = %v, want %v", got, countMismatches+1)
+	}
+	if got := scrapeMetric(t, `trie_verification_mismatches_total{check="sample"}`); got != sampleMismatches+1 {
+		t.Errorf("sample mismatches after the lossy load = %v, want %v", got, sampleMismatches+1)
+	}
+
+	// readiness is only withheld when a limit is configured and exceeded
+	for _, test := range []struct {
+		maxMissing int
+		code       int
+		status     string
+	}{
+		{-1, http.StatusOK, "active"},
+		{3, http.StatusOK, "active"},
+		{2, http.StatusServiceUnavailable, "incomplete"},
+		{0, http.StatusServiceUnavailable, "incomplete"},
+	} {
+		config.TrieVerifyMaxMissing = test.maxMissing
+		if code, status := ready(); code != test.code || status.Status != test.status {
+			t.Errorf("readiness with at most %d missing = %d %+v, want %d %s", test.maxMissing, code, status, test.code, test.status)
+		}
+	}
+	if _, status := ready(); status.Missing != 3 {
+		t.Errorf("readiness reports %d missing states, want 3", status.Missing)
+	}
+
+	// the check runs again on every reload, so a complete load restores readiness
+	repo.keep = 4
+	if err := store.RebuildTrie(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if code, status := ready(); code != http.StatusOK || status.Status != "active" {
+		t.Errorf("readiness after a complete reload = %d %+v, want 200 active", code, status)
+	}
+}
@@ -0,0 +1,186 @@
+package backend
+
+import (
+	"sort"
+
+	"github.com/graphql-go/graphql"
+)
+
+const (
+	// defaultVisualizationDepth is the depth below the prefix node exported when none is given
+	defaultVisualizationDepth = 5
+	// defaultVisualizationNodes is the number of nodes exported when no limit is given
+	defaultVisualizationNodes = 200
+	// maxVisualizationNodes caps the nodes exported by a single request
+	maxVisualizationNodes = 2000
+)
+
+// TrieVisualNode is a trie node exported for rendering as a graph. Its children are the edges
+// leaving it; Truncated marks nodes whose children were left out by the depth or node limit.
+type TrieVisualNode struct {
+	Char      string            `json:"char"`
+	Prefix    string            `json:"prefix"`
+	IsEnd     bool              `json:"isEnd"`
+	Frequency int64             `json:"frequency"`
+	States    []string          `json:"states"`
+	Children  []*TrieVisualNode `json:"children"`
+	Truncated bool              `json:"truncated"`
+}
+
+// TrieVisualization is the subtree of a trie under a prefix
+type TrieVisualization struct {
+	Root      *TrieVisualNode `json:"root"`
+	NodeCount int             `json:"nodeCount"`
+	Truncated bool            `json:"truncated"`
+}
+
+// newVisualNode exports the node without its children
+func newVisualNode(node *TrieNode, char, prefix string) *TrieVisualNode {
+	visual := &TrieVisualNode{Char: char, Prefix: prefix, IsEnd: node.IsEnd, Frequency: node.loadFrequency(), States: []string{}, Children: []*TrieVisualNode{}}
+	for _, state := range node.States {
+		visual.States = append(visual.States, state.Name)
+	}
+	return visual
+}
+
+// VisualizeTrie exports the subtree under the prefix breadth first, so shallow levels are complete
+// before deeper ones, stopping at maxDepth levels below the prefix or maxNodes nodes. It returns nil
+// when no name has the prefix.
+func VisualizeTrie(root *TrieNode, prefix string, maxDepth, maxNodes int) *TrieVisualization {
+	node := findNode(root, prefix)
+	if node == nil {
+		return nil
+	}
+
+	type queued struct {
+		node   *TrieNode
+		visual *TrieVisualNode
+		depth  int
+	}
+	char := ""
+	if runes := []rune(prefix); len(runes) > 0 {
+		char = string(runes[len(runes)-1])
+	}
+	result := &TrieVisualization{Root: newVisualNode(node, char, prefix), NodeCount: 1}
+	queue := []queued{{node: node, visual: result.Root}}
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		if len(current.node.Children) == 0 {
+			continue
+		}
+		if current.depth >= maxDepth || result.NodeCount >= maxNodes {
+			current.visual.Truncated = true
+			result.Truncated = true
+			continue
+		}
+
+		chars := make([]rune, 0, len(current.node.Children))
+		for char := range current.node.Children {
+			chars = append(chars, char)
+		}
+		sort.Slice(chars, func(i, j int) bool { return chars[i] < chars[j] })
+		for _, char := range chars {
+			if result.NodeCount >= maxNodes {
+				current.visual.Truncated = true
+				result.Truncated = true
+				break
+			}
+			child := current.node.Children[char]
+			visual := newVisualNode(child, string(char), current.visual.Prefix+string(char))
+			current.visual.Children = append(current.visual.Children, visual)
+			result.NodeCount++
+			queue = append(queue, queued{node: child, visual: visual, depth: current.depth + 1})
+		}
+	}
+	return result
+}
+
+// Define the GraphQL trie visual node type
+var trieVisualNodeType = graphql.NewObject(graphql.ObjectConfig{
+	Name: "TrieVisualNode",
+	Fields: graphql.Fields{
+		"char": &graphql.Field{
+			Type: graphql.String,
+		},
+		"prefix": &graphql.Field{
+			Type: graphql.String,
+		},
+		"isEnd": &graphql.Field{
+			Type: graphql.Boolean,
+		},
+		"frequency": &graphql.Field{
+			Type: graphql.Int,
+		},
+		"states": &graphql.Field{
+			Type: graphql.NewList(graphql.String),
+		},
+		"truncated": &graphql.Field{
+			Type:        graphql.Boolean,
+			Description: "Whether children of the node were left out by the depth or node limit",
+		},
+	},
+})
+
+// The children field refers to its own type, so it is added once the type exists
+func init() {
+	trieVisualNodeType.AddFieldConfig("children", &graphql.Field{
+		Type: graphql.NewList(trieVisualNodeType),
+	})
+}
+
+// Define the GraphQL trie visualization type
+var trieVisualizationType = graphql.NewObject(graphql.ObjectConfig{
+	Name: "TrieVisualization",
+	Fields: graphql.Fields{
+		"root": &graphql.Field{
+			Type: trieVisualNodeType,
+		},
+		"nodeCount": &graphql.Field{
+			Type: graphql.Int,
+		},
+		"truncated": &graphql.Field{
+			Type: graphql.Boolean,
+		},
+	},
+})
+
+// trieVisualizationField exports the subtree of the tenant's trie under a prefix for admins
+var trieVisualizationField = &graphql.Field{
+	Type: trieVisualizationType,
+	Args: graphql.FieldConfigArgument{
+		"prefix": &graphql.ArgumentConfig{
+			Type:         graphql.String,
+			DefaultValue: "",
+		},
+		"maxDepth": &graphql.ArgumentConfig{
+			Type:         graphql.Int,
+			Description:  "Levels exported below the prefix",
+			DefaultValue: defaultVisualizationDepth,
+		},
+		"maxNodes": &graphql.ArgumentConfig{
+			Type:         graphql.Int,
+			Description:  "Nodes exported, at most 2000",
+			DefaultValue: defaultVisualizationNodes,
+		},
+	},
+	Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+		if _, err := requireAdmin(p.Context); err != nil {
+			return nil, err
+		}
+		maxDepth, _ := p.Args["maxDepth"].(int)
+		maxNodes, _ := p.Args["maxNodes"].(int)
+		if maxDepth < 0 || maxNodes < 1 {
+			return nil, invalidInput("maxDepth must not be negative and maxNodes must be positive")
+		}
+		if maxNodes > maxVisualizationNodes {
+			maxNodes = maxVisualizationNodes
+		}
+		tenantStore, err := storeFor(p.Context)
+		if err != nil {
+			return nil, err
+		}
+		prefix, _ := p.Args["prefix"].(string)
+		return VisualizeTrie(tenantStore.Root(), collationKey(prefix), maxDepth, maxNodes), nil
+	},
+}
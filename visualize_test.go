@@ -0,0 +1,65 @@
+package backend
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestVisualizeTrie(t *testing.T) {
+	s := newTestStore(t,
+		State{Name: "Iowa", Code: "IA", Enabled: true, Kind: KindState, Frequency: 3},
+		State{Name: "Idaho", Code: "ID", Enabled: true, Kind: KindState, Frequency: 5},
+	)
+	tests := []struct {
+		prefix   string
+		maxDepth int
+		maxNodes int
+		want     string
+	}{
+		{"Io", 5, 10, `{"root":{"char":"o","prefix":"Io","isEnd":false,"frequency":0,"states":[],"children":[` +
+			`{"char":"w","prefix":"Iow","isEnd":false,"frequency":0,"states":[],"children":[` +
+			`{"char":"a","prefix":"Iowa","isEnd":true,"frequency":3,"states":["Iowa"],"children":[],"truncated":false}` +
+			`],"truncated":false}],"truncated":false},"nodeCount":3,"truncated":false}`},
+		// children below the depth limit are left out
+		{"I", 1, 10, `{"root":{"char":"I","prefix":"I","isEnd":false,"frequency":0,"states":[],"children":[` +
+			`{"char":"d","prefix":"Id","isEnd":false,"frequency":0,"states":[],"children":[],"truncated":true},` +
+			`{"char":"o","prefix":"Io","isEnd":false,"frequency":0,"states":[],"children":[],"truncated":true}` +
+			`],"truncated":false},"nodeCount":3,"truncated":true}`},
+		// so are nodes past the node limit
+		{"I", 5, 2, `{"root":{"char":"I","prefix":"I","isEnd":false,"frequency":0,"states":[],"children":[` +
+			`{"char":"d","prefix":"Id","isEnd":false,"frequency":0,"states":[],"children":[],"truncated":true}` +
+			`],"truncated":true},"nodeCount":2,"truncated":true}`},
+		{"Iz", 5, 10, `null`},
+	}
+	for _, test := range tests {
+		data, err := json.Marshal(VisualizeTrie(s.Root(), test.prefix, test.maxDepth, test.maxNodes))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(data) != test.want {
+			t.Errorf("VisualizeTrie(%q, %d, %d) = %s, want %s", test.prefix, test.maxDepth, test.maxNodes, data, test.want)
+		}
+	}
+}
+
+func TestTrieVisualizationQuery(t *testing.T) {
+	newTestStore(t, State{Name: "Iowa", Code: "IA", Enabled: true, Kind: KindState})
+	query := `{ trieVisualization(prefix: "Io", maxDepth: 1) { nodeCount truncated root { prefix children { prefix } } } }`
+
+	if result := runGraphQL(t, context.Background(), query); len(result.Errors) != 1 || !strings.Contains(result.Errors[0].Message, errUnauthorized.Error()) {
+		t.Errorf("trieVisualization without an admin = %v, want %v", result.Errors, errUnauthorized)
+	}
+	result := runGraphQL(t, asAdmin(context.Background(), "ops"), query)
+	if len(result.Errors) > 0 {
+		t.Fatal(result.Errors)
+	}
+	data, _ := json.Marshal(result.Data)
+	if want := `{"trieVisualization":{"nodeCount":2,"root":{"children":[{"prefix":"Iow"}],"prefix":"Io"},"truncated":true}}`; string(data) != want {
+		t.Errorf("trieVisualization = %s, want %s", data, want)
+	}
+	if result := runGraphQL(t, asAdmin(context.Background(), "ops"), `{ trieVisualization(maxNodes: 0) { nodeCount } }`); len(result.Errors) != 1 {
+		t.Errorf("trieVisualization with no nodes allowed = %v, want an error", result.Errors)
+	}
+}
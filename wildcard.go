@@ -0,0 +1,91 @@
+package backend
+
+import (
+	"log"
+	"strings"
+)
+
+const (
+	// wildcardRune matches any run of characters, including none, in a search
+	wildcardRune = '*'
+	// maxWildcardSteps bounds the trie nodes visited by a single wildcard search
+	maxWildcardSteps = 20000
+)
+
+// wildcardStep identifies a trie node reached at a position in the pattern
+type wildcardStep struct {
+	node *TrieNode
+	pos  int
+}
+
+// wildcardMatcher walks the trie along a pattern, branching on wildcards
+type wildcardMatcher struct {
+	pattern []rune
+	seen    map[wildcardStep]bool
+	ends    []*TrieNode
+}
+
+// hasWildcard reports whether the search contains a wildcard
+func hasWildcard(search string) bool {
+	return strings.ContainsRune(search, wildcardRune)
+}
+
+// match records every node where the rest of the pattern from pos matches, stopping
+// once maxWildcardSteps nodes were visited
+func (m *wildcardMatcher) match(node *TrieNode, pos int) {
+	step := wildcardStep{node: node, pos: pos}
+	if m.seen[step] || len(m.seen) >= maxWildcardSteps {
+		return
+	}
+	m.seen[step] = true
+
+	if pos == len(m.pattern) {
+		m.ends = append(m.ends, node)
+		return
+	}
+	if m.pattern[pos] != wildcardRune {
+		if child := node.Children[m.pattern[pos]]; child != nil {
+			m.match(child, pos+1)
+		}
+		return
+	}
+	m.match(node, pos+1)
+	for _, child := range node.Children {
+		m.match(child, pos)
+	}
+}
+
+// collectMatchedStates collects the states under the node, leaving out subtrees rooted at
+// another matched node since those are collected on their own
+func collectMatchedStates(node *TrieNode, ends map[*TrieNode]bool, results *[]*State) {
+	*results = append(*results, node.States...)
+	for _, child := range node.Children {
+		if !ends[child] {
+			collectMatchedStates(child, ends, results)
+		}
+	}
+}
+
+// wildcardSearch searches the trie for states starting with a match of the pattern,
+// where each * matches any run of characters, sorted by frequency
+func wildcardSearch(root *TrieNode, pattern string) []*State {
+	m := &wildcardMatcher{
+		pattern: []rune(pattern),
+		seen:    make(map[wildcardStep]bool),
+	}
+	m.match(root, 0)
+	if len(m.seen) >= maxWildcardSteps {
+		log.Printf("Wildcard search %s stopped after visiting %d nodes", pattern, maxWildcardSteps)
+	}
+
+	ends := make(map[*TrieNode]bool, len(m.ends))
+	for _, end := range m.ends {
+		ends[end] = true
+	}
+	results := []*State{}
+	for _, end := range m.ends {
+		collectMatchedStates(end, ends, &results)
+	}
+	sortStatesByFrequency(results)
+	return results
+}
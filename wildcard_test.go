@@ -0,0 +1,65 @@
+package backend
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+func TestWildcardSearch(t *testing.T) {
+	s := newTestStore(t,
+		State{Name: "New York", Code: "NY", Enabled: true, Kind: KindState, Frequency: 60},
+		State{Name: "New Jersey", Code: "NJ", Enabled: true, Kind: KindState, Frequency: 50},
+		State{Name: "Nevada", Code: "NV", Enabled: true, Kind: KindState, Frequency: 40},
+		State{Name: "North Dakota", Code: "ND", Enabled: true, Kind: KindState, Frequency: 30},
+		State{Name: "Texas", Code: "TX", Enabled: true, Kind: KindState, Frequency: 20},
+		State{Name: "Tennessee", Code: "TN", Enabled: true, Kind: KindState, Frequency: 10},
+		State{Name: "Newland", Code: "NL", Enabled: false, Kind: KindState, Frequency: 70},
+	)
+	tests := []struct {
+		pattern string
+		want    []string
+	}{
+		// a wildcard in the middle
+		{"N*w", []string{"New York", "New Jersey"}},
+		{"N*w Y", []string{"New York"}},
+		{"T*ss", []string{"Tennessee"}},
+		// a leading wildcard
+		{"*xas", []string{"Texas"}},
+		{"*Dak", []string{"North Dakota"}},
+		// a trailing wildcard matches like the prefix before it
+		{"Ne*", []string{"New York", "New Jersey", "Nevada"}},
+		// a wildcard matches no characters too
+		{"Te*x", []string{"Texas"}},
+		// states matched at more than one position are returned once
+		{"*e", []string{"New York", "New Jersey", "Nevada", "Texas", "Tennessee"}},
+		{"*", []string{"New York", "New Jersey", "Nevada", "North Dakota", "Texas", "Tennessee"}},
+		{"Q*", nil},
+		{"*q", nil},
+	}
+	for _, test := range tests {
+		var names []string
+		for _, state := range SearchStates(s.Root(), test.pattern) {
+			names = append(names, state.Name)
+		}
+		if !reflect.DeepEqual(names, test.want) {
+			t.Errorf("searching %q = %v, want %v", test.pattern, names, test.want)
+		}
+	}
+}
+
+func TestWildcardSearchIsBounded(t *testing.T) {
+	root := newTrieRoot()
+	for i := 0; i < 5000; i++ {
+		name := fmt.Sprintf("State %05d", i)
+		insertKey(root, name, &State{Name: name, Enabled: true})
+	}
+	m := &wildcardMatcher{pattern: []rune("*9*9*9*z"), seen: make(map[wildcardStep]bool)}
+	m.match(root, 0)
+	if len(m.seen) > maxWildcardSteps {
+		t.Errorf("matching visited %d nodes, want at most %d", len(m.seen), maxWildcardSteps)
+	}
+	if states := wildcardSearch(root, "*9*9*9*z"); len(states) != 0 {
+		t.Errorf("got %d states for a pattern matching none", len(states))
+	}
+}
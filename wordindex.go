@@ -0,0 +1,53 @@
+package backend
+
+import (
+	"context"
+	"strings"
+)
+
+// buildWordIndex indexes every state under root by each word of its name, so searches can match
+// words other than the first. Words are keyed like tokenized searches, ignoring case.
+func buildWordIndex(root *TrieNode) map[string][]*State {
+	var states []*State
+	collectStates(context.Background(), root, &states)
+	words := make(map[string][]*State)
+	for _, state := range states {
+		for _, word := range nameTokens(state.Name) {
+			words[word] = append(words[word], state)
+		}
+	}
+	return words
+}
+
+// searchWords returns the states having a word starting with each word of the search, most
+// frequently selected first, so "hamp" matches "New Hampshire"
+func searchWords(words map[string][]*State, search string, filters ...stateFilter) []*State {
+	terms := nameTokens(search)
+	if len(terms) == 0 {
+		return nil
+	}
+
+	var matched map[*State]bool
+	for _, term := range terms {
+		current := make(map[*State]bool)
+		for word, states := range words {
+			if !strings.HasPrefix(word, term) {
+				continue
+			}
+			for _, state := range states {
+				if matched == nil || matched[state] {
+					current[state] = true
+				}
+			}
+		}
+		matched = current
+	}
+
+	results := make([]*State, 0, len(matched))
+	for state := range matched {
+		results = append(results, state)
+	}
+	results = filterStates(results, append([]stateFilter{isEnabled}, filters...))
+	sortStatesByFrequency(results)
+	return results
+}